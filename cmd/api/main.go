@@ -5,12 +5,15 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"net"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"sync"
 	"syscall"
+	"time"
 
+	"github.com/led0nk/ark-overseer/internal/backup"
 	"github.com/led0nk/ark-overseer/internal/blacklist"
 	"github.com/led0nk/ark-overseer/internal/observer"
 	"github.com/led0nk/ark-overseer/internal/server"
@@ -35,9 +38,15 @@ func main() {
 		grpcAddr    = flag.String("grpc", "", "grpc address, e.g. localhost:4317")
 		dbPath      = flag.String("db", "testdata", "path to the database")
 		blPath      = flag.String("blacklist", "testdata", "path to the blacklist")
-		domain      = flag.String("domain", "127.0.0.1", "given domain for cookies/mail")
+		domain      = flag.String("domain", "", "given domain for cookies/mail and links in notifications, defaults to the server address")
 		logLevelStr = flag.String("loglevel", "INFO", "define the level for logs")
 		configPath  = flag.String("config", "config", "path to config-file")
+		journalPath = flag.String("journal", "testdata", "path to the event journal")
+		retention   = flag.Duration("journal-retention", 720*time.Hour, "how long events are kept in the journal, 0 keeps them forever")
+		backupPath  = flag.String("backup", "", "export a backup bundle to the given file and exit")
+		restorePath = flag.String("restore", "", "restore a backup bundle from the given file and exit")
+		restoreMode = flag.String("restore-mode", "merge", "how to restore a backup: merge or replace")
+		redact      = flag.Bool("redact", true, "redact secrets when exporting a backup")
 		logLevel    slog.Level
 		shutdownWg  sync.WaitGroup
 		initWg      sync.WaitGroup
@@ -54,12 +63,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *domain == "" {
+		*domain = domainFromAddr(*addr)
+	}
+
 	logger.Info("server address", "addr", *addr)
+	logger.Info("domain", "domain", *domain)
 	logger.Info("grpc address", "grpcaddr", *grpcAddr)
 	logger.Info("level for logging", "loglevel", *logLevelStr)
 	logger.Info("path to database", "db", *dbPath)
 	logger.Info("path to config", "config", *configPath)
 	logger.Info("path to blacklist", "blacklist", *blPath)
+	logger.Info("path to event journal", "journal", *journalPath)
 
 	conn, err := setupOTEL(ctx, *grpcAddr)
 	if err != nil {
@@ -70,8 +85,16 @@ func main() {
 		_ = conn.Close()
 	}()
 
-	eventManager := events.NewEventManager()
-	serviceManager := services.NewServiceManager(eventManager, &initWg)
+	journal, err := events.NewFileJournal(filepath.Join(*journalPath, "journal"), events.RetainFor(*retention))
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to open event journal", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		_ = journal.Close()
+	}()
+
+	eventManager := events.NewEventManager(events.WithJournal(journal))
 
 	database, blackList, obs, cfg, err := initServices(
 		ctx,
@@ -85,24 +108,35 @@ func main() {
 		os.Exit(1)
 	}
 
-	listenerWg.Add(2)
-	startEventListeners(ctx, eventManager, &listenerWg, &shutdownWg, serviceManager, obs)
+	serviceManager := services.NewServiceManager(eventManager, &initWg, database, blackList, *domain)
+
+	if *backupPath != "" || *restorePath != "" {
+		err = runBackup(ctx, database, blackList, cfg, journal, *backupPath, *restorePath, *restoreMode, *redact)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to run backup", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	listenerWg.Add(3)
+	startEventListeners(ctx, eventManager, &listenerWg, &shutdownWg, serviceManager, obs, &subscriptionListener{cfg: cfg, blacklist: blackList.(*blacklist.SubscribedBlacklist)})
 	listenerWg.Wait()
 
 	initWg.Add(2)
 	go func(config.Configuration) {
 		defer initWg.Done()
-		eventManager.Publish(events.EventMessage{Type: "init.services", Payload: cfg})
+		eventManager.Publish(ctx, events.EventMessage{Type: events.TypeInitServices, Payload: cfg})
 	}(cfg)
 	initWg.Wait()
 
 	initWg.Add(1)
 	go func() {
 		defer initWg.Done()
-		eventManager.Publish(events.EventMessage{Type: "init"})
+		eventManager.Publish(ctx, events.EventMessage{Type: events.TypeInit})
 	}()
 
-	srv := server.NewServer(*addr, *domain, database, blackList, cfg)
+	srv := server.NewServer(*addr, *domain, database, blackList, cfg, eventManager)
 	startHTTPServer(ctx, srv, &shutdownWg)
 
 	handleShutdown(ctx, cancel, &initWg, &shutdownWg, database)
@@ -135,24 +169,80 @@ func initServices(
 	storageWrapper := storagewrapper.NewStorageWrapper(database, eventManager)
 	database = storageWrapper
 
-	blackList, err = blacklist.NewBlacklist(filepath.Join(*blpath, "blacklist.json"))
+	configFile, err := config.NewConfiguration(filepath.Join(*configPath, "config.yaml"), eventManager)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to create config: %w", err)
+	}
+	cfg = configFile
+
+	localBlacklist, err := blacklist.NewBlacklist(filepath.Join(*blpath, "blacklist.json"))
 	if err != nil {
 		return nil, nil, nil, nil, fmt.Errorf("failed to create blacklist: %w", err)
 	}
+	blackList = localBlacklist
 
-	obs, err = observer.NewObserver(ctx, database, blackList, eventManager)
+	subscriptions, err := readSubscriptions(configFile)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("failed to create observer: %w", err)
+		return nil, nil, nil, nil, err
 	}
+	blackList = blacklist.NewSubscribedBlacklist(ctx, localBlacklist, subscriptions, nil)
 
-	cfg, err = config.NewConfiguration(filepath.Join(*configPath, "config.yaml"), eventManager)
+	obs, err = observer.NewObserver(ctx, database, blackList, eventManager)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("failed to create config: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("failed to create observer: %w", err)
 	}
 
 	return database, blackList, obs, cfg, nil
 }
 
+func runBackup(
+	ctx context.Context,
+	database storage.Database,
+	blackList blacklist.Blacklister,
+	cfg config.Configuration,
+	journal events.Journal,
+	backupPath, restorePath, restoreMode string,
+	redact bool,
+) error {
+	logger := slog.Default()
+	b := backup.NewBackup(database, blackList, cfg, journal)
+
+	if backupPath != "" {
+		file, err := os.Create(backupPath)
+		if err != nil {
+			return fmt.Errorf("failed to create backup file: %w", err)
+		}
+		defer file.Close()
+
+		err = b.Export(ctx, file, redact)
+		if err != nil {
+			return fmt.Errorf("failed to export backup: %w", err)
+		}
+		logger.InfoContext(ctx, "exported backup", "file", backupPath, "redacted", redact)
+	}
+
+	if restorePath != "" {
+		mode, err := backup.ParseMode(restoreMode)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(restorePath)
+		if err != nil {
+			return fmt.Errorf("failed to open backup file: %w", err)
+		}
+		defer file.Close()
+
+		err = b.Restore(ctx, file, mode)
+		if err != nil {
+			return fmt.Errorf("failed to restore backup: %w", err)
+		}
+		logger.InfoContext(ctx, "restored backup", "file", restorePath, "mode", mode)
+	}
+
+	return database.Save()
+}
+
 func startHTTPServer(
 	ctx context.Context,
 	server *server.Server,
@@ -171,18 +261,63 @@ func startEventListeners(
 	listenerWg, shutdownWg *sync.WaitGroup,
 	sm *services.ServiceManager,
 	obs observer.Overseer,
+	subscriptions *subscriptionListener,
 ) {
 	shutdownWg.Add(1)
 	go func() {
 		defer shutdownWg.Done()
-		em.StartListening(ctx, sm, "serviceManager", func() { listenerWg.Done() })
+		em.StartListening(ctx, sm, "serviceManager", func() { listenerWg.Done() },
+			events.Topics(events.TypeInitServices, events.TypeConfigChanged),
+		)
 	}()
 
 	shutdownWg.Add(1)
 	go func() {
 		defer shutdownWg.Done()
-		em.StartListening(ctx, obs, "observer", func() { listenerWg.Done() })
+		em.StartListening(ctx, obs, "observer", func() { listenerWg.Done() },
+			events.Topics(events.TypeInit, events.TypeServerAdded, events.TypeServerDeleted),
+		)
 	}()
+
+	shutdownWg.Add(1)
+	go func() {
+		defer shutdownWg.Done()
+		em.StartListening(ctx, subscriptions, "blacklistSubscriptions", func() { listenerWg.Done() },
+			events.Topics(events.TypeConfigChanged),
+		)
+	}()
+}
+
+func readSubscriptions(cfg config.Configuration) ([]blacklist.Subscription, error) {
+	current, err := cfg.Export()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	section, ok := current["blacklist-subscriptions"].(map[interface{}]interface{})
+	if !ok {
+		return nil, nil
+	}
+	subscriptions, err := blacklist.ParseSubscriptions(section)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse blacklist subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// subscriptionListener reloads the blacklist subscriptions when the
+// configuration changes, e.g. by restoring a backup.
+type subscriptionListener struct {
+	cfg       config.Configuration
+	blacklist *blacklist.SubscribedBlacklist
+}
+
+func (l *subscriptionListener) HandleEvent(ctx context.Context, event events.EventMessage) {
+	subscriptions, err := readSubscriptions(l.cfg)
+	if err != nil {
+		slog.Default().ErrorContext(ctx, "failed to reload blacklist subscriptions, keeping the current ones", "error", err)
+		return
+	}
+	l.blacklist.SetSubscriptions(subscriptions)
 }
 
 func handleShutdown(
@@ -252,3 +387,16 @@ func setupOTEL(ctx context.Context, grpcaddr string) (conn *grpc.ClientConn, err
 	}
 	return conn, nil
 }
+
+// domainFromAddr returns the URL the server is reached at on this machine,
+// an address without host listens on all interfaces.
+func domainFromAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "http://" + addr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
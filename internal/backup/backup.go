@@ -0,0 +1,520 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/led0nk/ark-overseer/internal/blacklist"
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/led0nk/ark-overseer/internal/storage"
+	"github.com/led0nk/ark-overseer/pkg/config"
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"go.opentelemetry.io/otel"
+	"gopkg.in/yaml.v2"
+)
+
+var tracer = otel.GetTracerProvider().Tracer("github.com/led0nk/ark-overseer/internal/backup")
+
+// Version of the bundle layout written by Export. Restore rejects bundles
+// with a different version.
+const Version = 1
+
+const (
+	manifestFile  = "manifest.json"
+	serversFile   = "servers.json"
+	blacklistFile = "blacklist.json"
+	configFile    = "config.yaml"
+	historyFile   = "history.jsonl"
+)
+
+// Redacted replaces secrets in exported configurations. Restoring a bundle
+// keeps the current value wherever it finds this placeholder.
+const Redacted = "<redacted>"
+
+var secretKeys = []string{"token", "password", "secret", "webhook", "authorization"}
+
+type Mode string
+
+const (
+	// ModeMerge adds everything from the bundle that doesn't exist yet and
+	// keeps existing entries untouched.
+	ModeMerge Mode = "merge"
+	// ModeReplace drops the current state and restores the bundle as is.
+	ModeReplace Mode = "replace"
+)
+
+func ParseMode(mode string) (Mode, error) {
+	switch Mode(mode) {
+	case ModeMerge, ModeReplace:
+		return Mode(mode), nil
+	case "":
+		return ModeMerge, nil
+	default:
+		return "", fmt.Errorf("unknown restore mode %q", mode)
+	}
+}
+
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Redacted  bool      `json:"redacted"`
+	Files     []string  `json:"files"`
+}
+
+type Bundle struct {
+	Manifest  Manifest
+	Servers   []*model.Server
+	Blacklist []*model.BlacklistPlayers
+	Config    map[interface{}]interface{}
+	History   []events.EventMessage
+}
+
+type bundleFile struct {
+	name string
+	data []byte
+}
+
+type Backup struct {
+	sStore    storage.Database
+	blacklist blacklist.Blacklister
+	config    config.Configuration
+	journal   events.Journal
+	logger    *slog.Logger
+}
+
+func NewBackup(
+	sStore storage.Database,
+	blacklist blacklist.Blacklister,
+	config config.Configuration,
+	journal events.Journal,
+) *Backup {
+	return &Backup{
+		sStore:    sStore,
+		blacklist: blacklist,
+		config:    config,
+		journal:   journal,
+		logger:    slog.Default().WithGroup("backup"),
+	}
+}
+
+// Export writes servers, blacklist, configuration and, if there is a journal,
+// the event history as a tar.gz bundle to w. With redact set, secrets in the
+// configuration are replaced by Redacted.
+func (b *Backup) Export(ctx context.Context, w io.Writer, redact bool) error {
+	ctx, span := tracer.Start(ctx, "Export")
+	defer span.End()
+
+	servers, err := b.sStore.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list servers: %w", err)
+	}
+
+	cfg, err := b.config.Export()
+	if err != nil {
+		return fmt.Errorf("failed to export config: %w", err)
+	}
+	if redact {
+		redactSecrets(cfg)
+	}
+
+	serversData, err := json.MarshalIndent(servers, "", "\t")
+	if err != nil {
+		return err
+	}
+	blacklistData, err := json.MarshalIndent(localEntries(b.blacklist.List(ctx)), "", "\t")
+	if err != nil {
+		return err
+	}
+	configData, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	manifest := Manifest{
+		Version:   Version,
+		CreatedAt: time.Now().UTC(),
+		Redacted:  redact,
+		Files:     []string{serversFile, blacklistFile, configFile},
+	}
+
+	var historyData []byte
+	if b.journal != nil {
+		historyData, err = exportHistory(b.journal)
+		if err != nil {
+			return fmt.Errorf("failed to export history: %w", err)
+		}
+		manifest.Files = append(manifest.Files, historyFile)
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	files := []bundleFile{
+		{manifestFile, manifestData},
+		{serversFile, serversData},
+		{blacklistFile, blacklistData},
+		{configFile, configData},
+	}
+	if b.journal != nil {
+		files = append(files, bundleFile{historyFile, historyData})
+	}
+	for _, file := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Name:    file.name,
+			Mode:    0644,
+			Size:    int64(len(file.data)),
+			ModTime: manifest.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// Read parses and validates a bundle without applying it.
+func Read(r io.Reader) (*Bundle, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("bundle is not gzip compressed: %w", err)
+	}
+	defer gr.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, tr); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		files[header.Name] = buf.Bytes()
+	}
+
+	bundle := &Bundle{}
+	manifestData, ok := files[manifestFile]
+	if !ok {
+		return nil, errors.New("bundle has no manifest")
+	}
+	if err := json.Unmarshal(manifestData, &bundle.Manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if bundle.Manifest.Version != Version {
+		return nil, fmt.Errorf("unsupported bundle version %d", bundle.Manifest.Version)
+	}
+	for _, name := range bundle.Manifest.Files {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("bundle is missing %s", name)
+		}
+	}
+
+	if data, ok := files[serversFile]; ok {
+		if err := json.Unmarshal(data, &bundle.Servers); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", serversFile, err)
+		}
+	}
+	for _, server := range bundle.Servers {
+		if server == nil || server.ID == uuid.Nil || server.Addr == "" {
+			return nil, fmt.Errorf("invalid %s: server without id or address", serversFile)
+		}
+	}
+
+	if data, ok := files[blacklistFile]; ok {
+		if err := json.Unmarshal(data, &bundle.Blacklist); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", blacklistFile, err)
+		}
+	}
+	for _, player := range bundle.Blacklist {
+		if player == nil || player.ID == uuid.Nil {
+			return nil, fmt.Errorf("invalid %s: entry without id", blacklistFile)
+		}
+	}
+
+	if data, ok := files[configFile]; ok {
+		if err := yaml.Unmarshal(data, &bundle.Config); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", configFile, err)
+		}
+	}
+
+	if data, ok := files[historyFile]; ok {
+		history, err := readHistory(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", historyFile, err)
+		}
+		bundle.History = history
+	}
+
+	return bundle, nil
+}
+
+// Restore validates the bundle read from r and applies it according to mode.
+// Nothing is changed if the bundle is invalid.
+func (b *Backup) Restore(ctx context.Context, r io.Reader, mode Mode) error {
+	ctx, span := tracer.Start(ctx, "Restore")
+	defer span.End()
+
+	bundle, err := Read(r)
+	if err != nil {
+		return err
+	}
+
+	// history comes first, restoring servers and config publishes events
+	// and the journal wouldn't be empty anymore
+	if err := b.restoreHistory(bundle.History); err != nil {
+		return fmt.Errorf("failed to restore history: %w", err)
+	}
+	if err := b.restoreServers(ctx, bundle.Servers, mode); err != nil {
+		return fmt.Errorf("failed to restore servers: %w", err)
+	}
+	if err := b.restoreBlacklist(ctx, bundle.Blacklist, mode); err != nil {
+		return fmt.Errorf("failed to restore blacklist: %w", err)
+	}
+	if bundle.Config != nil {
+		if err := b.restoreConfig(bundle.Config, mode); err != nil {
+			return fmt.Errorf("failed to restore config: %w", err)
+		}
+	}
+
+	b.logger.InfoContext(ctx, "restored backup", "mode", mode, "created", bundle.Manifest.CreatedAt)
+	return nil
+}
+
+func (b *Backup) restoreServers(ctx context.Context, servers []*model.Server, mode Mode) error {
+	current, err := b.sStore.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[uuid.UUID]bool)
+	for _, server := range current {
+		if mode == ModeReplace {
+			if err := b.sStore.Delete(ctx, server.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		existing[server.ID] = true
+	}
+
+	for _, server := range servers {
+		if existing[server.ID] {
+			continue
+		}
+		if _, err := b.sStore.Create(ctx, server); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Backup) restoreBlacklist(ctx context.Context, players []*model.BlacklistPlayers, mode Mode) error {
+	existing := make(map[uuid.UUID]bool)
+	for _, player := range localEntries(b.blacklist.List(ctx)) {
+		if mode == ModeReplace {
+			if err := b.blacklist.Delete(ctx, player.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		existing[player.ID] = true
+	}
+
+	for _, player := range players {
+		if existing[player.ID] {
+			continue
+		}
+		if _, err := b.blacklist.Create(ctx, player); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Backup) restoreConfig(restored map[interface{}]interface{}, mode Mode) error {
+	current, err := b.config.Export()
+	if err != nil {
+		return err
+	}
+
+	keepSecrets(restored, current)
+	if mode == ModeMerge {
+		restored = mergeMaps(current, restored)
+	}
+	return b.config.Import(restored)
+}
+
+// restoreHistory only fills an empty journal, existing history is never
+// rewritten. Restored events get new IDs.
+func (b *Backup) restoreHistory(history []events.EventMessage) error {
+	if b.journal == nil || len(history) == 0 || b.journal.LastID() != 0 {
+		return nil
+	}
+	for _, event := range history {
+		if _, err := b.journal.Append(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportHistory(journal events.Journal) ([]byte, error) {
+	var buf bytes.Buffer
+	err := journal.Replay(0, func(event events.EventMessage) error {
+		line, err := events.MarshalEvent(event)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+		return nil
+	})
+	return buf.Bytes(), err
+}
+
+func readHistory(data []byte) ([]events.EventMessage, error) {
+	var history []events.EventMessage
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		event, err := events.UnmarshalEvent(scanner.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, event)
+	}
+	return history, scanner.Err()
+}
+
+// localEntries drops entries of subscribed blacklists, which are restored
+// from their source instead.
+func localEntries(players []*model.BlacklistPlayers) []*model.BlacklistPlayers {
+	local := make([]*model.BlacklistPlayers, 0, len(players))
+	for _, player := range players {
+		if blacklist.IsLocal(player) {
+			p := *player
+			p.Source = ""
+			local = append(local, &p)
+		}
+	}
+	return local
+}
+
+func isSecret(key interface{}) bool {
+	k, ok := key.(string)
+	if !ok {
+		return false
+	}
+	k = strings.ToLower(k)
+	for _, secret := range secretKeys {
+		if strings.Contains(k, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactSecrets(m map[interface{}]interface{}) {
+	for key, value := range m {
+		switch v := value.(type) {
+		case map[interface{}]interface{}:
+			redactSecrets(v)
+		case []interface{}:
+			for _, item := range v {
+				if itemMap, ok := item.(map[interface{}]interface{}); ok {
+					redactSecrets(itemMap)
+				}
+			}
+		default:
+			if isSecret(key) && value != nil && value != "" {
+				m[key] = Redacted
+			}
+		}
+	}
+}
+
+// keepSecrets replaces redacted values in restored by the value found at
+// the same position in current, or drops them if there is none.
+func keepSecrets(restored, current map[interface{}]interface{}) {
+	for key, value := range restored {
+		switch v := value.(type) {
+		case map[interface{}]interface{}:
+			currentMap, _ := current[key].(map[interface{}]interface{})
+			keepSecrets(v, currentMap)
+		case []interface{}:
+			currentList, _ := current[key].([]interface{})
+			for i, item := range v {
+				itemMap, ok := item.(map[interface{}]interface{})
+				if !ok {
+					continue
+				}
+				var currentMap map[interface{}]interface{}
+				if i < len(currentList) {
+					currentMap, _ = currentList[i].(map[interface{}]interface{})
+				}
+				keepSecrets(itemMap, currentMap)
+			}
+		case string:
+			if v != Redacted {
+				continue
+			}
+			if currentValue, ok := current[key]; ok {
+				restored[key] = currentValue
+			} else {
+				delete(restored, key)
+			}
+		}
+	}
+}
+
+// mergeMaps returns current extended by all keys of restored it doesn't
+// contain yet. Nested maps are merged recursively.
+func mergeMaps(current, restored map[interface{}]interface{}) map[interface{}]interface{} {
+	if current == nil {
+		return restored
+	}
+	for key, value := range restored {
+		currentValue, exists := current[key]
+		if !exists || currentValue == nil {
+			current[key] = value
+			continue
+		}
+		currentMap, currentOk := currentValue.(map[interface{}]interface{})
+		restoredMap, restoredOk := value.(map[interface{}]interface{})
+		if currentOk && restoredOk {
+			current[key] = mergeMaps(currentMap, restoredMap)
+		}
+	}
+	return current
+}
@@ -0,0 +1,343 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/led0nk/ark-overseer/internal/blacklist"
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/led0nk/ark-overseer/internal/storage"
+	"github.com/led0nk/ark-overseer/internal/storagewrapper"
+	"github.com/led0nk/ark-overseer/pkg/config"
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTempDir(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "backup_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	return dir
+}
+
+func cleanupTempDir(t *testing.T, dir string) {
+	err := os.RemoveAll(dir)
+	if err != nil {
+		t.Fatalf("Failed to remove temp dir: %s", err)
+	}
+}
+
+func newTestBackup(t *testing.T, ctx context.Context, dir string) (*Backup, *storage.ServerStorage, *blacklist.Blacklist, *config.Config) {
+	sStore, err := storage.NewServerStorage(ctx, filepath.Join(dir, "cluster.json"))
+	assert.NoError(t, err)
+	bl, err := blacklist.NewBlacklist(filepath.Join(dir, "blacklist.json"))
+	assert.NoError(t, err)
+	cfg, err := config.NewConfiguration(filepath.Join(dir, "config.yaml"), events.NewEventManager())
+	assert.NoError(t, err)
+	journal, err := events.NewFileJournal(filepath.Join(dir, "journal"))
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = journal.Close() })
+	return NewBackup(sStore, bl, cfg, journal), sStore, bl, cfg
+}
+
+func TestExportAndRestore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srcDir := createTempDir(t)
+	defer cleanupTempDir(t, srcDir)
+	dstDir := createTempDir(t)
+	defer cleanupTempDir(t, dstDir)
+
+	src, srcStore, srcBlacklist, srcConfig := newTestBackup(t, ctx, srcDir)
+	_, err := srcStore.Create(ctx, &model.Server{
+		ID:   uuid.MustParse("64dfb157-37b8-41de-b24d-14f304e15402"),
+		Name: "exported server",
+		Addr: "127.0.0.1:27015",
+	})
+	assert.NoError(t, err)
+	_, err = srcBlacklist.Create(ctx, &model.BlacklistPlayers{
+		ID:   uuid.MustParse("d8e92b5e-4d1d-4f38-bdbc-d1d3f1d2e3b7"),
+		Name: "exported player",
+	})
+	assert.NoError(t, err)
+	err = srcConfig.Update("notification-service", "discord", map[interface{}]interface{}{"token": "secret-token", "channelID": "abcdef"})
+	assert.NoError(t, err)
+
+	dst, dstStore, dstBlacklist, dstConfig := newTestBackup(t, ctx, dstDir)
+	_, err = dstStore.Create(ctx, &model.Server{
+		ID:   uuid.MustParse("047d119f-fa67-4e1f-ae1a-85ca77f67a74"),
+		Name: "local server",
+		Addr: "127.0.0.1:27016",
+	})
+	assert.NoError(t, err)
+	err = dstConfig.Update("notification-service", "discord", map[interface{}]interface{}{"token": "local-token", "channelID": "local"})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		redact        bool
+		mode          Mode
+		servers       []string
+		players       []string
+		expectedToken string
+		expectedChan  string
+	}{
+		{
+			name:          "merge redacted bundle",
+			redact:        true,
+			mode:          ModeMerge,
+			servers:       []string{"exported server", "local server"},
+			players:       []string{"exported player"},
+			expectedToken: "local-token",
+			expectedChan:  "local",
+		},
+		{
+			name:          "replace with full bundle",
+			redact:        false,
+			mode:          ModeReplace,
+			servers:       []string{"exported server"},
+			players:       []string{"exported player"},
+			expectedToken: "secret-token",
+			expectedChan:  "abcdef",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := src.Export(ctx, &buf, tt.redact)
+			assert.NoError(t, err)
+
+			if tt.redact {
+				assert.NotContains(t, readConfig(t, buf.Bytes()), "secret-token")
+			}
+
+			err = dst.Restore(ctx, &buf, tt.mode)
+			assert.NoError(t, err)
+
+			servers, err := dstStore.List(ctx)
+			assert.NoError(t, err)
+			names := make([]string, 0, len(servers))
+			for _, server := range servers {
+				names = append(names, server.Name)
+			}
+			assert.Equal(t, tt.servers, names)
+
+			players := make([]string, 0)
+			for _, player := range dstBlacklist.List(ctx) {
+				players = append(players, player.Name)
+			}
+			assert.Equal(t, tt.players, players)
+
+			section, err := dstConfig.GetSection("notification-service")
+			assert.NoError(t, err)
+			discord := section["discord"].(map[interface{}]interface{})
+			assert.Equal(t, tt.expectedToken, discord["token"])
+			assert.Equal(t, tt.expectedChan, discord["channelID"])
+		})
+	}
+}
+
+func TestExportAndRestoreHistory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srcDir := createTempDir(t)
+	defer cleanupTempDir(t, srcDir)
+	dstDir := createTempDir(t)
+	defer cleanupTempDir(t, dstDir)
+
+	src, _, _, _ := newTestBackup(t, ctx, srcDir)
+	for _, player := range []string{"first", "second"} {
+		_, err := src.journal.Append(events.EventMessage{
+			Type:    events.TypePlayerJoined,
+			Payload: events.PlayerEvent{Player: player, ServerName: "island"},
+		})
+		assert.NoError(t, err)
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, src.Export(ctx, &buf, true))
+	data := buf.Bytes()
+
+	dst, _, _, _ := newTestBackup(t, ctx, dstDir)
+	assert.NoError(t, dst.Restore(ctx, bytes.NewReader(data), ModeMerge))
+
+	history, err := events.Recent(dst.journal, 10)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	player, ok := history[0].PlayerEvent()
+	assert.True(t, ok)
+	assert.Equal(t, "second", player.Player)
+
+	// a journal with history is never rewritten
+	assert.NoError(t, dst.Restore(ctx, bytes.NewReader(data), ModeMerge))
+	assert.Equal(t, uint64(2), dst.journal.LastID())
+}
+
+func TestRestoreServersWithHistory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srcDir := createTempDir(t)
+	defer cleanupTempDir(t, srcDir)
+	dstDir := createTempDir(t)
+	defer cleanupTempDir(t, dstDir)
+
+	src, srcStore, _, _ := newTestBackup(t, ctx, srcDir)
+	_, err := srcStore.Create(ctx, &model.Server{ID: uuid.New(), Name: "exported server", Addr: "127.0.0.1:27015"})
+	assert.NoError(t, err)
+	_, err = src.journal.Append(events.EventMessage{
+		Type:    events.TypePlayerJoined,
+		Payload: events.PlayerEvent{Player: "first", ServerName: "exported server"},
+	})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, src.Export(ctx, &buf, true))
+
+	// like in production, restoring servers and config publishes events into
+	// the journal
+	dst, dstStore, _, _ := newTestBackup(t, ctx, dstDir)
+	em := events.NewEventManager(events.WithJournal(dst.journal))
+	dst.sStore = storagewrapper.NewStorageWrapper(dstStore, em)
+	dst.config, err = config.NewConfiguration(filepath.Join(dstDir, "config.yaml"), em)
+	assert.NoError(t, err)
+
+	assert.NoError(t, dst.Restore(ctx, &buf, ModeMerge))
+
+	servers, err := dstStore.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, servers, 1)
+
+	history := replayTypes(t, dst.journal)
+	assert.Equal(t, events.TypePlayerJoined, history[0], "history is restored before anything else")
+	assert.Contains(t, history, events.TypeServerAdded)
+}
+
+func replayTypes(t *testing.T, journal events.Journal) []string {
+	var types []string
+	err := journal.Replay(0, func(event events.EventMessage) error {
+		types = append(types, event.Type)
+		return nil
+	})
+	assert.NoError(t, err)
+	return types
+}
+
+func TestKeepSecrets(t *testing.T) {
+	current := map[interface{}]interface{}{
+		"discord": map[interface{}]interface{}{"token": "local-token"},
+		"webhook": []interface{}{
+			map[interface{}]interface{}{"url": "https://example.com", "authorization": "local-auth"},
+		},
+	}
+	restored := map[interface{}]interface{}{
+		"discord": map[interface{}]interface{}{"token": Redacted},
+		"webhook": []interface{}{
+			map[interface{}]interface{}{"url": "https://example.com", "authorization": Redacted},
+			map[interface{}]interface{}{"url": "https://example.org", "authorization": Redacted},
+		},
+	}
+
+	keepSecrets(restored, current)
+	assert.Equal(t, "local-token", restored["discord"].(map[interface{}]interface{})["token"])
+	targets := restored["webhook"].([]interface{})
+	assert.Equal(t, "local-auth", targets[0].(map[interface{}]interface{})["authorization"])
+	assert.NotContains(t, targets[1], "authorization", "secrets without a current value are dropped")
+}
+
+func TestRestoreInvalidBundle(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := createTempDir(t)
+	defer cleanupTempDir(t, dir)
+	b, sStore, _, _ := newTestBackup(t, ctx, dir)
+
+	_, err := sStore.Create(ctx, &model.Server{
+		ID:   uuid.MustParse("fd1c54a0-d2c4-4407-8501-9085ffe20902"),
+		Name: "untouched server",
+		Addr: "127.0.0.1:27015",
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{
+			name:  "missing manifest",
+			files: map[string]string{serversFile: "[]"},
+		},
+		{
+			name:  "unsupported version",
+			files: map[string]string{manifestFile: `{"version": 99}`},
+		},
+		{
+			name:  "missing listed file",
+			files: map[string]string{manifestFile: `{"version": 1, "files": ["servers.json"]}`},
+		},
+		{
+			name: "server without address",
+			files: map[string]string{
+				manifestFile: `{"version": 1, "files": ["servers.json"]}`,
+				serversFile:  `[{"id": "3854e285-6cde-4cfa-9924-10620fdcce5c", "name": "broken"}]`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := b.Restore(ctx, writeBundle(t, tt.files), ModeReplace)
+			assert.Error(t, err)
+
+			servers, err := sStore.List(ctx)
+			assert.NoError(t, err)
+			assert.Len(t, servers, 1)
+		})
+	}
+
+	err = b.Restore(ctx, bytes.NewBufferString("not a bundle"), ModeMerge)
+	assert.Error(t, err)
+}
+
+func writeBundle(t *testing.T, files map[string]string) *bytes.Buffer {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+	return &buf
+}
+
+func readConfig(t *testing.T, bundle []byte) string {
+	gr, err := gzip.NewReader(bytes.NewReader(bundle))
+	assert.NoError(t, err)
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			t.Fatalf("config not found in bundle: %s", err)
+		}
+		if header.Name == configFile {
+			var buf bytes.Buffer
+			_, err = buf.ReadFrom(tr)
+			assert.NoError(t, err)
+			return buf.String()
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package blacklist
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/led0nk/ark-overseer/internal/model"
+)
+
+// SourceLocal tags entries that are stored in the local blacklist.
+const SourceLocal = "local"
+
+const (
+	defaultRefreshInterval = 15 * time.Minute
+	// maxListSize bounds the size of a fetched list.
+	maxListSize = 10 << 20
+)
+
+var ErrReadOnly = errors.New("entry belongs to a subscribed blacklist and is read-only")
+
+// Subscription describes a remote blacklist that is fetched via http(s).
+type Subscription struct {
+	Name     string
+	URL      string
+	Format   Format
+	Interval time.Duration
+}
+
+// ParseSubscriptions reads subscriptions from a config section of the form
+//
+//	name:
+//	  url: https://example.com/list.json
+//	  format: json
+//	  interval: 10m
+func ParseSubscriptions(section map[interface{}]interface{}) ([]Subscription, error) {
+	subscriptions := make([]Subscription, 0, len(section))
+	for key, value := range section {
+		name, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid subscription name %v", key)
+		}
+		values, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("subscription %s: invalid type", name)
+		}
+
+		url, _ := values["url"].(string)
+		if url == "" {
+			return nil, fmt.Errorf("subscription %s: url required", name)
+		}
+
+		formatStr, _ := values["format"].(string)
+		if formatStr == "" {
+			formatStr = string(FormatJSON)
+		}
+		format, err := ParseFormat(formatStr)
+		if err != nil {
+			return nil, fmt.Errorf("subscription %s: %w", name, err)
+		}
+
+		interval := defaultRefreshInterval
+		if intervalStr, ok := values["interval"].(string); ok && intervalStr != "" {
+			interval, err = time.ParseDuration(intervalStr)
+			if err != nil {
+				return nil, fmt.Errorf("subscription %s: invalid interval: %w", name, err)
+			}
+		}
+
+		subscriptions = append(subscriptions, Subscription{
+			Name:     name,
+			URL:      url,
+			Format:   format,
+			Interval: interval,
+		})
+	}
+	sort.Slice(subscriptions, func(i, j int) bool { return subscriptions[i].Name < subscriptions[j].Name })
+	return subscriptions, nil
+}
+
+// RemoteList keeps the last successfully fetched copy of a subscription.
+type RemoteList struct {
+	subscription Subscription
+	client       *http.Client
+	logger       *slog.Logger
+	mu           sync.RWMutex
+	players      []*model.BlacklistPlayers
+	etag         string
+	lastModified string
+	lastErr      error
+}
+
+func NewRemoteList(subscription Subscription, client *http.Client) *RemoteList {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &RemoteList{
+		subscription: subscription,
+		client:       client,
+		logger:       slog.Default().WithGroup("blacklist").With("subscription", subscription.Name),
+	}
+}
+
+// Refresh fetches the subscription. Unchanged lists (304) and failures keep
+// the last good copy.
+func (r *RemoteList) Refresh(ctx context.Context) error {
+	err := r.fetch(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastErr = err
+	return err
+}
+
+func (r *RemoteList) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.subscription.URL, nil)
+	if err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	if r.etag != "" {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+	if r.lastModified != "" {
+		req.Header.Set("If-Modified-Since", r.lastModified)
+	}
+	r.mu.RUnlock()
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", r.subscription.URL, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil
+	case http.StatusOK:
+	default:
+		return fmt.Errorf("failed to fetch %s: unexpected status %s", r.subscription.URL, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxListSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", r.subscription.URL, err)
+	}
+	if len(body) > maxListSize {
+		return fmt.Errorf("failed to read %s: larger than %d bytes", r.subscription.URL, maxListSize)
+	}
+	players, err := Decode(r.subscription.Format, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", r.subscription.URL, err)
+	}
+
+	for _, player := range players {
+		player.Source = r.subscription.Name
+		player.ID = uuid.NewSHA1(uuid.NameSpaceURL, []byte(r.subscription.URL+"#"+strings.ToLower(player.Name)+"#"+player.SteamID))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.players = players
+	r.etag = resp.Header.Get("ETag")
+	r.lastModified = resp.Header.Get("Last-Modified")
+	return nil
+}
+
+// Run refreshes the subscription until ctx is done.
+func (r *RemoteList) Run(ctx context.Context) {
+	interval := r.subscription.Interval
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Refresh(ctx); err != nil {
+			r.logger.ErrorContext(ctx, "failed to refresh subscribed blacklist, keeping last copy", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// List returns copies of the entries of the last good fetch.
+func (r *RemoteList) List() []*model.BlacklistPlayers {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	players := make([]*model.BlacklistPlayers, 0, len(r.players))
+	for _, player := range r.players {
+		p := *player
+		players = append(players, &p)
+	}
+	return players
+}
+
+// Err returns the error of the last refresh, if any.
+func (r *RemoteList) Err() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lastErr
+}
+
+// SubscribedBlacklist merges a local blacklist with read-only subscriptions.
+// Changes always go to the local blacklist.
+type SubscribedBlacklist struct {
+	ctx     context.Context
+	local   Blacklister
+	client  *http.Client
+	mu      sync.RWMutex
+	remotes []*RemoteList
+	cancels []context.CancelFunc
+}
+
+// NewSubscribedBlacklist wraps local and keeps the given subscriptions
+// refreshed until ctx is done.
+func NewSubscribedBlacklist(
+	ctx context.Context,
+	local Blacklister,
+	subscriptions []Subscription,
+	client *http.Client,
+) *SubscribedBlacklist {
+	sb := &SubscribedBlacklist{ctx: ctx, local: local, client: client}
+	sb.SetSubscriptions(subscriptions)
+	return sb
+}
+
+// SetSubscriptions replaces the subscriptions. Unchanged ones keep their
+// last copy, removed ones are stopped.
+func (sb *SubscribedBlacklist) SetSubscriptions(subscriptions []Subscription) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	running := make(map[Subscription]int)
+	for i, remote := range sb.remotes {
+		running[remote.subscription] = i
+	}
+
+	remotes := make([]*RemoteList, 0, len(subscriptions))
+	cancels := make([]context.CancelFunc, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		if i, ok := running[subscription]; ok {
+			remotes = append(remotes, sb.remotes[i])
+			cancels = append(cancels, sb.cancels[i])
+			delete(running, subscription)
+			continue
+		}
+		ctx, cancel := context.WithCancel(sb.ctx)
+		remote := NewRemoteList(subscription, sb.client)
+		remotes = append(remotes, remote)
+		cancels = append(cancels, cancel)
+		go remote.Run(ctx)
+	}
+	for _, i := range running {
+		sb.cancels[i]()
+	}
+	sb.remotes, sb.cancels = remotes, cancels
+}
+
+func (sb *SubscribedBlacklist) lists() []*RemoteList {
+	sb.mu.RLock()
+	defer sb.mu.RUnlock()
+	return sb.remotes
+}
+
+func (sb *SubscribedBlacklist) Create(
+	ctx context.Context,
+	player *model.BlacklistPlayers,
+) (*model.BlacklistPlayers, error) {
+	player.Source = ""
+	return sb.local.Create(ctx, player)
+}
+
+func (sb *SubscribedBlacklist) Delete(ctx context.Context, id uuid.UUID) error {
+	for _, player := range sb.List(ctx) {
+		if player.ID == id && !IsLocal(player) {
+			return ErrReadOnly
+		}
+	}
+	return sb.local.Delete(ctx, id)
+}
+
+// List returns the local entries followed by the entries of all
+// subscriptions, each tagged with its source. Entries matching an earlier one
+// by name or Steam-ID are left out, so local entries win over subscribed ones.
+func (sb *SubscribedBlacklist) List(ctx context.Context) []*model.BlacklistPlayers {
+	local := sb.local.List(ctx)
+	players := make([]*model.BlacklistPlayers, 0, len(local))
+	for _, player := range local {
+		p := *player
+		p.Source = SourceLocal
+		players = append(players, &p)
+	}
+	known := newIndex(players)
+	for _, remote := range sb.lists() {
+		for _, player := range remote.List() {
+			if known.contains(player) {
+				continue
+			}
+			known.add(player)
+			players = append(players, player)
+		}
+	}
+	return players
+}
+
+// IsLocal reports whether the entry is stored in the local blacklist.
+func IsLocal(player *model.BlacklistPlayers) bool {
+	return player.Source == "" || player.Source == SourceLocal
+}
@@ -0,0 +1,257 @@
+package blacklist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type remoteStub struct {
+	mu       sync.Mutex
+	body     string
+	etag     string
+	status   int
+	requests int
+	notMod   int
+}
+
+func (rs *remoteStub) set(status int, body, etag string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.status = status
+	rs.body = body
+	rs.etag = etag
+}
+
+func (rs *remoteStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.requests++
+
+	if rs.status != http.StatusOK {
+		w.WriteHeader(rs.status)
+		return
+	}
+	if rs.etag != "" && r.Header.Get("If-None-Match") == rs.etag {
+		rs.notMod++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", rs.etag)
+	_, _ = w.Write([]byte(rs.body))
+}
+
+func TestRemoteListRefresh(t *testing.T) {
+	ctx := context.Background()
+	stub := &remoteStub{}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	remote := NewRemoteList(Subscription{Name: "alliance", URL: srv.URL, Format: FormatJSON}, srv.Client())
+
+	stub.set(http.StatusOK, `[{"name": "Raider", "threatlevel": "high"}]`, `"v1"`)
+	assert.NoError(t, remote.Refresh(ctx))
+	players := remote.List()
+	assert.Len(t, players, 1)
+	assert.Equal(t, "Raider", players[0].Name)
+	assert.Equal(t, "alliance", players[0].Source)
+	firstID := players[0].ID
+
+	assert.NoError(t, remote.Refresh(ctx))
+	assert.Equal(t, 1, stub.notMod, "unchanged list should be answered with 304")
+	assert.Len(t, remote.List(), 1)
+
+	stub.set(http.StatusOK, `[{"name": "Raider"}, {"name": "Scout"}]`, `"v2"`)
+	assert.NoError(t, remote.Refresh(ctx))
+	players = remote.List()
+	assert.Len(t, players, 2)
+	assert.Equal(t, firstID, players[0].ID, "ids must be stable across refreshes")
+
+	stub.set(http.StatusInternalServerError, "", "")
+	assert.Error(t, remote.Refresh(ctx))
+	assert.Error(t, remote.Err())
+	assert.Len(t, remote.List(), 2, "failed refresh must keep the last good copy")
+
+	stub.set(http.StatusOK, `not json`, `"v3"`)
+	assert.Error(t, remote.Refresh(ctx))
+	assert.Len(t, remote.List(), 2, "invalid list must keep the last good copy")
+
+	stub.set(http.StatusOK, `[{"name": "`+strings.Repeat("x", maxListSize)+`"}]`, `"v4"`)
+	assert.ErrorContains(t, remote.Refresh(ctx), "larger than")
+	assert.Len(t, remote.List(), 2, "oversized list must keep the last good copy")
+
+	players[0].Name = "modified"
+	assert.Equal(t, "Raider", remote.List()[0].Name)
+}
+
+func TestSubscribedBlacklist(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := createTempDir(t)
+	defer cleanupTempDir(t, dir)
+
+	stub := &remoteStub{}
+	stub.set(http.StatusOK, "name,threatlevel\nRemote Raider,high\n", `"csv-1"`)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	local, err := NewBlacklist(filepath.Join(dir, "blacklist.json"))
+	assert.NoError(t, err)
+
+	sb := NewSubscribedBlacklist(ctx, local, []Subscription{
+		{Name: "alliance", URL: srv.URL, Format: FormatCSV, Interval: time.Hour},
+	}, srv.Client())
+
+	assert.Eventually(t, func() bool { return len(sb.List(ctx)) == 1 }, time.Second, 10*time.Millisecond)
+
+	created, err := sb.Create(ctx, &model.BlacklistPlayers{Name: "Local Raider"})
+	assert.NoError(t, err)
+
+	sources := make(map[string]string)
+	for _, player := range sb.List(ctx) {
+		sources[player.Name] = player.Source
+	}
+	assert.Equal(t, map[string]string{"Local Raider": SourceLocal, "Remote Raider": "alliance"}, sources)
+
+	for _, player := range sb.List(ctx) {
+		if player.Source == "alliance" {
+			assert.ErrorIs(t, sb.Delete(ctx, player.ID), ErrReadOnly)
+		}
+	}
+	assert.NoError(t, sb.Delete(ctx, created.ID))
+	assert.Len(t, sb.List(ctx), 1)
+	assert.Empty(t, local.List(ctx))
+}
+
+func TestSubscribedBlacklistLocalWins(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := createTempDir(t)
+	defer cleanupTempDir(t, dir)
+
+	stub := &remoteStub{}
+	stub.set(http.StatusOK, "name,steamid,threatlevel\nraider,,low\nRenamed,76561198000000001,low\nScout,,low\n", `"csv-1"`)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	local, err := NewBlacklist(filepath.Join(dir, "blacklist.json"))
+	assert.NoError(t, err)
+	raider, err := local.Create(ctx, &model.BlacklistPlayers{Name: "Raider", ThreatLevel: "high"})
+	assert.NoError(t, err)
+	_, err = local.Create(ctx, &model.BlacklistPlayers{Name: "Trader", SteamID: "76561198000000001"})
+	assert.NoError(t, err)
+
+	sb := NewSubscribedBlacklist(ctx, local, []Subscription{
+		{Name: "alliance", URL: srv.URL, Format: FormatCSV, Interval: time.Hour},
+	}, srv.Client())
+
+	assert.Eventually(t, func() bool { return len(sb.List(ctx)) == 3 }, time.Second, 10*time.Millisecond)
+	sources := make(map[string]string)
+	for _, player := range sb.List(ctx) {
+		sources[player.Name] = player.Source + " " + player.ThreatLevel
+	}
+	assert.Equal(t, map[string]string{
+		"Raider": SourceLocal + " high",
+		"Trader": SourceLocal + " ",
+		"Scout":  "alliance low",
+	}, sources)
+
+	assert.NoError(t, sb.Delete(ctx, raider.ID))
+	assert.Len(t, local.List(ctx), 1)
+}
+
+func TestSetSubscriptions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := createTempDir(t)
+	defer cleanupTempDir(t, dir)
+
+	alliance := &remoteStub{}
+	alliance.set(http.StatusOK, "name\nRemote Raider\n", "")
+	allianceSrv := httptest.NewServer(alliance)
+	defer allianceSrv.Close()
+	tribe := &remoteStub{}
+	tribe.set(http.StatusOK, "name\nTribe Raider\n", "")
+	tribeSrv := httptest.NewServer(tribe)
+	defer tribeSrv.Close()
+
+	local, err := NewBlacklist(filepath.Join(dir, "blacklist.json"))
+	assert.NoError(t, err)
+	sb := NewSubscribedBlacklist(ctx, local, nil, nil)
+	assert.Empty(t, sb.List(ctx))
+
+	allianceSub := Subscription{Name: "alliance", URL: allianceSrv.URL, Format: FormatCSV, Interval: time.Hour}
+	sb.SetSubscriptions([]Subscription{allianceSub})
+	assert.Eventually(t, func() bool { return len(sb.List(ctx)) == 1 }, time.Second, 10*time.Millisecond)
+
+	sb.SetSubscriptions([]Subscription{allianceSub, {Name: "tribe", URL: tribeSrv.URL, Format: FormatCSV, Interval: time.Hour}})
+	assert.Eventually(t, func() bool { return len(sb.List(ctx)) == 2 }, time.Second, 10*time.Millisecond)
+	alliance.mu.Lock()
+	assert.Equal(t, 1, alliance.requests, "unchanged subscriptions keep running")
+	alliance.mu.Unlock()
+
+	sb.SetSubscriptions(nil)
+	assert.Empty(t, sb.List(ctx))
+}
+
+func TestParseSubscriptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		section   map[interface{}]interface{}
+		expected  []Subscription
+		expectErr bool
+	}{
+		{
+			name: "defaults",
+			section: map[interface{}]interface{}{
+				"alliance": map[interface{}]interface{}{"url": "https://example.com/list.json"},
+			},
+			expected: []Subscription{
+				{Name: "alliance", URL: "https://example.com/list.json", Format: FormatJSON, Interval: defaultRefreshInterval},
+			},
+		},
+		{
+			name: "format and interval",
+			section: map[interface{}]interface{}{
+				"tribe": map[interface{}]interface{}{"url": "https://example.com/list.csv", "format": "csv", "interval": "5m"},
+			},
+			expected: []Subscription{
+				{Name: "tribe", URL: "https://example.com/list.csv", Format: FormatCSV, Interval: 5 * time.Minute},
+			},
+		},
+		{
+			name: "missing url",
+			section: map[interface{}]interface{}{
+				"broken": map[interface{}]interface{}{"format": "csv"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid interval",
+			section: map[interface{}]interface{}{
+				"broken": map[interface{}]interface{}{"url": "https://example.com", "interval": "often"},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscriptions, err := ParseSubscriptions(tt.section)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, subscriptions)
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package blacklist
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/led0nk/ark-overseer/internal/model"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+	// FormatBanList is ARK's BanList.txt, one Steam-ID per line.
+	FormatBanList Format = "banlist"
+)
+
+var csvHeader = []string{"name", "steamid", "threatlevel", "tags", "notes"}
+
+func ParseFormat(format string) (Format, error) {
+	switch Format(strings.ToLower(format)) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatBanList, "txt":
+		return FormatBanList, nil
+	default:
+		return "", fmt.Errorf("unknown blacklist format %q", format)
+	}
+}
+
+// ImportResult lists which entries of an import were (or in a dry-run would
+// be) added and which were skipped as duplicates.
+type ImportResult struct {
+	DryRun     bool
+	Added      []*model.BlacklistPlayers
+	Duplicates []*model.BlacklistPlayers
+	// Unmatched are added entries without a name, e.g. from a BanList.txt.
+	// Players are matched by name only, so they never raise an alert.
+	Unmatched []*model.BlacklistPlayers
+}
+
+// Export writes all entries of bl in the given format to w.
+func Export(ctx context.Context, bl Blacklister, format Format, w io.Writer) error {
+	players := bl.List(ctx)
+	sort.Slice(players, func(i, j int) bool { return players[i].Name < players[j].Name })
+
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		for _, player := range players {
+			err := cw.Write([]string{
+				player.Name,
+				player.SteamID,
+				player.ThreatLevel,
+				strings.Join(player.Tags, ";"),
+				player.Notes,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(players)
+	case FormatBanList:
+		for _, player := range players {
+			if player.SteamID == "" {
+				continue
+			}
+			if _, err := fmt.Fprintln(w, player.SteamID); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown blacklist format %q", format)
+	}
+}
+
+// Decode parses entries in the given format from r.
+func Decode(format Format, r io.Reader) ([]*model.BlacklistPlayers, error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatJSON:
+		var players []*model.BlacklistPlayers
+		if err := json.NewDecoder(r).Decode(&players); err != nil {
+			return nil, fmt.Errorf("invalid json: %w", err)
+		}
+		for i, player := range players {
+			if player == nil || (player.Name == "" && player.SteamID == "") {
+				return nil, fmt.Errorf("entry %d: name or steamid required", i+1)
+			}
+		}
+		return players, nil
+	case FormatBanList:
+		return decodeBanList(r)
+	default:
+		return nil, fmt.Errorf("unknown blacklist format %q", format)
+	}
+}
+
+// Import decodes entries from r and adds all entries to bl that don't match
+// an existing entry by name or Steam-ID. With dryRun set, bl is not modified.
+func Import(
+	ctx context.Context,
+	bl Blacklister,
+	format Format,
+	r io.Reader,
+	dryRun bool,
+) (*ImportResult, error) {
+	players, err := Decode(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{DryRun: dryRun}
+	known := newIndex(bl.List(ctx))
+	for _, player := range players {
+		if known.contains(player) {
+			result.Duplicates = append(result.Duplicates, player)
+			continue
+		}
+		known.add(player)
+		result.Added = append(result.Added, player)
+		if player.Name == "" {
+			result.Unmatched = append(result.Unmatched, player)
+		}
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	for _, player := range result.Added {
+		if _, err := bl.Create(ctx, player); err != nil {
+			return nil, fmt.Errorf("failed to add %q: %w", player.Name, err)
+		}
+	}
+	return result, nil
+}
+
+func decodeCSV(r io.Reader) ([]*model.BlacklistPlayers, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	_, hasName := columns["name"]
+	_, hasSteamID := columns["steamid"]
+	if !hasName && !hasSteamID {
+		return nil, errors.New("invalid csv header: name or steamid column required")
+	}
+
+	var players []*model.BlacklistPlayers
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		player := &model.BlacklistPlayers{
+			Name:        field("name"),
+			SteamID:     field("steamid"),
+			ThreatLevel: field("threatlevel"),
+			Notes:       field("notes"),
+		}
+		player.Tags = SplitTags(field("tags"))
+		if player.Name == "" && player.SteamID == "" {
+			return nil, fmt.Errorf("csv line %d: name or steamid required", line)
+		}
+		players = append(players, player)
+	}
+	return players, nil
+}
+
+// SplitTags splits a list of tags separated by semicolons.
+func SplitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ";") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func decodeBanList(r io.Reader) ([]*model.BlacklistPlayers, error) {
+	var players []*model.BlacklistPlayers
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		steamID := strings.TrimSpace(scanner.Text())
+		if steamID == "" || strings.HasPrefix(steamID, "#") || strings.HasPrefix(steamID, "//") {
+			continue
+		}
+		if !isSteamID(steamID) {
+			return nil, fmt.Errorf("banlist line %d: %q is not a steam-id", line, steamID)
+		}
+		players = append(players, &model.BlacklistPlayers{SteamID: steamID})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return players, nil
+}
+
+func isSteamID(s string) bool {
+	if len(s) != 17 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+type index struct {
+	ids      map[uuid.UUID]bool
+	names    map[string]bool
+	steamIDs map[string]bool
+}
+
+func newIndex(players []*model.BlacklistPlayers) *index {
+	idx := &index{
+		ids:      make(map[uuid.UUID]bool),
+		names:    make(map[string]bool),
+		steamIDs: make(map[string]bool),
+	}
+	for _, player := range players {
+		idx.add(player)
+	}
+	return idx
+}
+
+func (i *index) add(player *model.BlacklistPlayers) {
+	if player.ID != uuid.Nil {
+		i.ids[player.ID] = true
+	}
+	if player.Name != "" {
+		i.names[strings.ToLower(player.Name)] = true
+	}
+	if player.SteamID != "" {
+		i.steamIDs[player.SteamID] = true
+	}
+}
+
+func (i *index) contains(player *model.BlacklistPlayers) bool {
+	if player.ID != uuid.Nil && i.ids[player.ID] {
+		return true
+	}
+	if player.Name != "" && i.names[strings.ToLower(player.Name)] {
+		return true
+	}
+	return player.SteamID != "" && i.steamIDs[player.SteamID]
+}
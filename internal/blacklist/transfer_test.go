@@ -0,0 +1,182 @@
+package blacklist
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		format     Format
+		input      string
+		dryRun     bool
+		added      []string
+		duplicates []string
+		unmatched  []string
+		expectErr  bool
+	}{
+		{
+			name:   "csv with metadata",
+			format: FormatCSV,
+			input: "name,steamid,threatlevel,tags,notes\n" +
+				"Raider,76561198000000001,high,alpha;pvp,main base north\n" +
+				"Scout,,low,,\n",
+			added:      []string{"Raider", "Scout"},
+			duplicates: nil,
+		},
+		{
+			name:   "csv columns in any order",
+			format: FormatCSV,
+			input: "notes,name\n" +
+				"seen at the volcano,Builder\n",
+			added: []string{"Builder"},
+		},
+		{
+			name:   "csv duplicates by name and steam-id",
+			format: FormatCSV,
+			input: "name,steamid\n" +
+				"existing player,\n" +
+				"renamed,76561198000000099\n" +
+				"new player,\n" +
+				"NEW PLAYER,\n",
+			added:      []string{"new player"},
+			duplicates: []string{"existing player", "renamed", "NEW PLAYER"},
+		},
+		{
+			name:       "json dry-run",
+			format:     FormatJSON,
+			input:      `[{"name": "Existing Player"}, {"name": "json player", "threatlevel": "medium"}]`,
+			dryRun:     true,
+			added:      []string{"json player"},
+			duplicates: []string{"Existing Player"},
+		},
+		{
+			name:   "banlist",
+			format: FormatBanList,
+			input: "76561198000000002\n" +
+				"\n" +
+				"# comment\n" +
+				"76561198000000099\n",
+			added:      []string{"76561198000000002"},
+			duplicates: []string{"76561198000000099"},
+			unmatched:  []string{"76561198000000002"},
+		},
+		{
+			name:      "banlist with invalid steam-id",
+			format:    FormatBanList,
+			input:     "not-a-steam-id\n",
+			expectErr: true,
+		},
+		{
+			name:      "csv without name column",
+			format:    FormatCSV,
+			input:     "notes\nsomething\n",
+			expectErr: true,
+		},
+		{
+			name:      "json entry without name",
+			format:    FormatJSON,
+			input:     `[{"notes": "nameless"}]`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := createTempDir(t)
+			defer cleanupTempDir(t, dir)
+
+			bl, err := NewBlacklist(filepath.Join(dir, "blacklist.json"))
+			assert.NoError(t, err)
+			_, err = bl.Create(ctx, &model.BlacklistPlayers{Name: "existing player", SteamID: "76561198000000099"})
+			assert.NoError(t, err)
+
+			result, err := Import(ctx, bl, tt.format, strings.NewReader(tt.input), tt.dryRun)
+			if tt.expectErr {
+				assert.Error(t, err)
+				assert.Len(t, bl.List(ctx), 1, "failed import must not change the blacklist")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.added, identifiers(result.Added))
+			assert.Equal(t, tt.duplicates, identifiers(result.Duplicates))
+			assert.Equal(t, tt.unmatched, identifiers(result.Unmatched))
+
+			expected := 1 + len(tt.added)
+			if tt.dryRun {
+				expected = 1
+			}
+			assert.Len(t, bl.List(ctx), expected)
+		})
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	for _, format := range []Format{FormatCSV, FormatJSON, FormatBanList} {
+		t.Run(string(format), func(t *testing.T) {
+			srcDir := createTempDir(t)
+			defer cleanupTempDir(t, srcDir)
+			dstDir := createTempDir(t)
+			defer cleanupTempDir(t, dstDir)
+
+			src, err := NewBlacklist(filepath.Join(srcDir, "blacklist.json"))
+			assert.NoError(t, err)
+			dst, err := NewBlacklist(filepath.Join(dstDir, "blacklist.json"))
+			assert.NoError(t, err)
+
+			players := []*model.BlacklistPlayers{
+				{Name: "Raider", SteamID: "76561198000000001", ThreatLevel: "high", Tags: []string{"alpha", "pvp"}, Notes: "main, base"},
+				{Name: "Scout", SteamID: "76561198000000002", ThreatLevel: "low"},
+			}
+			for _, player := range players {
+				_, err := src.Create(ctx, player)
+				assert.NoError(t, err)
+			}
+
+			var buf bytes.Buffer
+			err = Export(ctx, src, format, &buf)
+			assert.NoError(t, err)
+
+			result, err := Import(ctx, dst, format, &buf, false)
+			assert.NoError(t, err)
+			assert.Len(t, result.Added, 2)
+
+			imported := dst.List(ctx)
+			sort.Slice(imported, func(i, j int) bool { return imported[i].SteamID < imported[j].SteamID })
+			for i, player := range imported {
+				assert.Equal(t, players[i].SteamID, player.SteamID)
+				if format == FormatBanList {
+					continue
+				}
+				assert.Equal(t, players[i].Name, player.Name)
+				assert.Equal(t, players[i].ThreatLevel, player.ThreatLevel)
+				assert.Equal(t, players[i].Tags, player.Tags)
+				assert.Equal(t, players[i].Notes, player.Notes)
+			}
+		})
+	}
+}
+
+func identifiers(players []*model.BlacklistPlayers) []string {
+	var ids []string
+	for _, player := range players {
+		if player.Name != "" {
+			ids = append(ids, player.Name)
+			continue
+		}
+		ids = append(ids, player.SteamID)
+	}
+	return ids
+}
@@ -11,6 +11,7 @@ type Server struct {
 	ID          uuid.UUID    `json:"id" form:"-"`
 	Name        string       `json:"name" form:"-"`
 	Addr        string       `json:"addr" form:"-"`
+	Cluster     string       `json:"cluster,omitempty" form:"-"`
 	Status      bool         `json:"status" form:"-"`
 	ServerInfo  *ServerInfo  `json:"serverinfo" form:"-"`
 	PlayersInfo *PlayersInfo `json:"playersinfo" form:"-"`
@@ -50,8 +51,42 @@ type Players struct {
 }
 
 type BlacklistPlayers struct {
-	ID       uuid.UUID     `json:"id" form:"-"`
-	Name     string        `json:"name" form:"-"`
-	Score    int           `json:"score" form:"-"`
-	Duration time.Duration `json:"duration" form:"-"`
+	ID          uuid.UUID     `json:"id" form:"-"`
+	Name        string        `json:"name" form:"-"`
+	Score       int           `json:"score" form:"-"`
+	Duration    time.Duration `json:"duration" form:"-"`
+	SteamID     string        `json:"steamid,omitempty" form:"-"`
+	ThreatLevel string        `json:"threatlevel,omitempty" form:"-"`
+	Tags        []string      `json:"tags,omitempty" form:"-"`
+	Notes       string        `json:"notes,omitempty" form:"-"`
+	Source      string        `json:"source,omitempty" form:"-"`
+}
+
+// Clone returns a deep copy of the server, so the copy can be read and
+// modified without affecting the original.
+func (s *Server) Clone() *Server {
+	if s == nil {
+		return nil
+	}
+	clone := *s
+	if s.ServerInfo != nil {
+		serverInfo := *s.ServerInfo
+		clone.ServerInfo = &serverInfo
+	}
+	if s.PlayersInfo != nil {
+		playersInfo := &PlayersInfo{}
+		// a nil slice stays nil, so the copy is equal to the original
+		if s.PlayersInfo.Players != nil {
+			playersInfo.Players = make([]*Players, 0, len(s.PlayersInfo.Players))
+		}
+		for _, player := range s.PlayersInfo.Players {
+			if player == nil {
+				continue
+			}
+			p := *player
+			playersInfo.Players = append(playersInfo.Players, &p)
+		}
+		clone.PlayersInfo = playersInfo
+	}
+	return &clone
 }
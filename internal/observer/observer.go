@@ -16,10 +16,16 @@ import (
 	"github.com/led0nk/ark-overseer/internal/storage"
 	"github.com/led0nk/ark-overseer/pkg/events"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var meter = otel.GetMeterProvider().Meter("github.com/led0nk/ark-overseer/internal/observer")
+var (
+	meter  = otel.GetMeterProvider().Meter("github.com/led0nk/ark-overseer/internal/observer")
+	tracer = otel.GetTracerProvider().Tracer("github.com/led0nk/ark-overseer/internal/observer")
+)
 
 type Overseer interface {
 	HandleEvent(context.Context, events.EventMessage)
@@ -33,13 +39,24 @@ type Observer struct {
 	em          *events.EventManager
 	logger      *slog.Logger
 	mu          sync.Mutex
-	resultCh    map[uuid.UUID]chan *model.Server
+	resultCh    map[uuid.UUID]chan scrapeResult
+}
+
+// scrapeResult passes a scraped server down the pipeline together with the
+// span of its scrape, so scan, update and notifications share one trace.
+type scrapeResult struct {
+	spanContext trace.SpanContext
+	server      *model.Server
+	// failed is set if the server couldn't be scraped, server is the last
+	// known state marked offline then.
+	failed bool
 }
 
 type NotificationStatus struct {
 	isActive       bool
 	joinedNotified bool
 	leftNotified   bool
+	duration       time.Duration
 }
 
 func NewObserver(
@@ -55,7 +72,7 @@ func NewObserver(
 		blacklist:   blacklist,
 		em:          eventManager,
 		logger:      slog.Default().WithGroup("observer"),
-		resultCh:    make(map[uuid.UUID]chan *model.Server),
+		resultCh:    make(map[uuid.UUID]chan scrapeResult),
 	}
 	go observer.processResults(ctx)
 	return observer, nil
@@ -73,7 +90,7 @@ func (o *Observer) readEndpoint(target *model.Server) error {
 	return nil
 }
 
-func (o *Observer) dataScraper(ctx context.Context, target *model.Server) chan *model.Server {
+func (o *Observer) dataScraper(ctx context.Context, target *model.Server) chan scrapeResult {
 	scrapesCtr, err := meter.Int64UpDownCounter(
 		"scrapeCtr",
 		metric.WithDescription("number of data scrapes from steam server"),
@@ -92,57 +109,37 @@ func (o *Observer) dataScraper(ctx context.Context, target *model.Server) chan *
 		return nil
 	}
 
-	out := make(chan *model.Server)
+	out := make(chan scrapeResult)
 	go func() {
 		defer close(out)
+		// the first scrape continues the trace that added the scraper, every
+		// following scrape starts its own trace linked to it
+		origin := trace.LinkFromContext(ctx)
+		spanOpts := []trace.SpanStartOption{}
+		last := target
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			default:
-				helpSrv, err := steam.Connect(target.Addr)
-				if err != nil {
-					o.logger.ErrorContext(ctx, "error connecting to endpoint", "error", err)
-					continue
-				}
+				scrapeCtx, span := tracer.Start(ctx, "scrape", append(spanOpts, trace.WithAttributes(attribute.String("server.addr", target.Addr)))...)
+				spanOpts = []trace.SpanStartOption{trace.WithNewRoot(), trace.WithLinks(origin)}
 
-				infoResponse, err := helpSrv.Info()
+				result := scrapeResult{spanContext: span.SpanContext()}
+				server, err := o.scrape(scrapeCtx, target)
 				if err != nil {
-					o.logger.ErrorContext(ctx, "error fetching ServerInfo", "error", err)
+					span.RecordError(err)
+					span.SetStatus(codes.Error, err.Error())
 					failedScrapesCtr.Add(ctx, 1)
-					continue
-				}
-				scrapesCtr.Add(ctx, 1)
-
-				playerResponse, err := helpSrv.PlayersInfo()
-				if err != nil {
-					o.logger.ErrorContext(ctx, "error fetching PlayersInfo", "error", err)
-					continue
-				}
-
-				ping, err := helpSrv.Ping()
-				if err != nil {
-					o.logger.ErrorContext(ctx, "failed to ping server", "error", err)
-					continue
-				}
-
-				var status bool
-				if ping < time.Duration(5*time.Second) {
-					status = true
+					result.server, result.failed = offline(last), true
+				} else {
+					scrapesCtr.Add(ctx, 1)
+					result.server, last = server, server
 				}
+				span.End()
 
-				server := &model.Server{
-					Name:        target.Name,
-					Addr:        target.Addr,
-					ID:          target.ID,
-					Status:      status,
-					ServerInfo:  model.ToServerInfo(infoResponse),
-					PlayersInfo: model.ToPlayerInfo(playerResponse),
-				}
-				replaceNullCharsInStruct(server)
-				server = correctPlayerNum(server)
 				select {
-				case out <- server:
+				case out <- result:
 				default:
 				}
 			}
@@ -151,7 +148,63 @@ func (o *Observer) dataScraper(ctx context.Context, target *model.Server) chan *
 	return out
 }
 
-func (o *Observer) scanner(ctx context.Context, in chan *model.Server) chan *model.Server {
+func (o *Observer) scrape(ctx context.Context, target *model.Server) (*model.Server, error) {
+	helpSrv, err := steam.Connect(target.Addr)
+	if err != nil {
+		o.logger.ErrorContext(ctx, "error connecting to endpoint", "error", err)
+		return nil, err
+	}
+
+	infoResponse, err := helpSrv.Info()
+	if err != nil {
+		o.logger.ErrorContext(ctx, "error fetching ServerInfo", "error", err)
+		return nil, err
+	}
+
+	playerResponse, err := helpSrv.PlayersInfo()
+	if err != nil {
+		o.logger.ErrorContext(ctx, "error fetching PlayersInfo", "error", err)
+		return nil, err
+	}
+
+	ping, err := helpSrv.Ping()
+	if err != nil {
+		o.logger.ErrorContext(ctx, "failed to ping server", "error", err)
+		return nil, err
+	}
+
+	var status bool
+	if ping < time.Duration(5*time.Second) {
+		status = true
+	}
+
+	server := &model.Server{
+		Name:        target.Name,
+		Addr:        target.Addr,
+		Cluster:     target.Cluster,
+		ID:          target.ID,
+		Status:      status,
+		ServerInfo:  model.ToServerInfo(infoResponse),
+		PlayersInfo: model.ToPlayerInfo(playerResponse),
+	}
+	replaceNullCharsInStruct(server)
+	return correctPlayerNum(server), nil
+}
+
+// offline returns the last known state of an unreachable server, without
+// players.
+func offline(last *model.Server) *model.Server {
+	server := last.Clone()
+	server.Status = false
+	server.PlayersInfo = &model.PlayersInfo{}
+	if server.ServerInfo == nil {
+		server.ServerInfo = &model.ServerInfo{}
+	}
+	server.ServerInfo.Players = 0
+	return server
+}
+
+func (o *Observer) scanner(ctx context.Context, in chan scrapeResult) chan scrapeResult {
 	scanCtr, err := meter.Int64UpDownCounter(
 		"scanCtr",
 		metric.WithDescription("number of scans happened"),
@@ -160,25 +213,43 @@ func (o *Observer) scanner(ctx context.Context, in chan *model.Server) chan *mod
 		return nil
 	}
 
-	out := make(chan *model.Server)
+	out := make(chan scrapeResult)
 	go func() {
 		defer close(out)
 		previousPlayers := make(map[string]*NotificationStatus)
+		var online, seen bool
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case server, ok := <-in:
+			case result, ok := <-in:
 				if !ok {
 					return
 				}
-				blacklist := o.blacklist.List(ctx)
+				server := result.server
 				if server.PlayersInfo == nil {
 					continue
 				}
-				previousPlayers = o.scan(blacklist, server, previousPlayers)
+
+				scanCtx, span := tracer.Start(
+					trace.ContextWithSpanContext(ctx, result.spanContext),
+					"scan",
+					trace.WithAttributes(attribute.String("server.addr", server.Addr)),
+				)
+				// players of an unreachable server haven't left, they are
+				// scanned again once it's back
+				if !result.failed {
+					blacklist := o.blacklist.List(scanCtx)
+					previousPlayers = o.scan(scanCtx, blacklist, server, previousPlayers)
+				}
+				if seen && server.Status != online {
+					o.publishStatus(scanCtx, server)
+				}
+				online, seen = server.Status, true
+				span.End()
+
 				select {
-				case out <- server:
+				case out <- scrapeResult{spanContext: span.SpanContext(), server: server}:
 					scanCtr.Add(ctx, 1)
 				default:
 				}
@@ -189,14 +260,18 @@ func (o *Observer) scanner(ctx context.Context, in chan *model.Server) chan *mod
 }
 
 func (o *Observer) scan(
+	ctx context.Context,
 	blacklist []*model.BlacklistPlayers,
 	server *model.Server,
 	previousPlayers map[string]*NotificationStatus,
 ) map[string]*NotificationStatus {
 
-	blacklistMap := make(map[string]bool)
+	blacklistMap := make(map[string]*model.BlacklistPlayers)
 	for _, blacklistedPlayer := range blacklist {
-		blacklistMap[blacklistedPlayer.Name] = true
+		if blacklistedPlayer.Name == "" {
+			continue
+		}
+		blacklistMap[blacklistedPlayer.Name] = blacklistedPlayer
 	}
 
 	for _, status := range previousPlayers {
@@ -210,13 +285,15 @@ func (o *Observer) scan(
 			previousPlayers[player.Name] = status
 		}
 		status.isActive = true
+		status.duration = player.Duration
 
-		if blacklistMap[player.Name] {
+		if entry, ok := blacklistMap[player.Name]; ok {
 			if !status.joinedNotified {
 				o.em.Publish(
+					ctx,
 					events.EventMessage{
-						Type:    "player.joined",
-						Payload: player.Name + " joined the server " + server.Name,
+						Type:    events.TypePlayerJoined,
+						Payload: newPlayerEvent(player.Name, server, status.duration, entry),
 					},
 				)
 				status.joinedNotified = true
@@ -226,11 +303,13 @@ func (o *Observer) scan(
 	}
 
 	for playerName, status := range previousPlayers {
-		if blacklistMap[playerName] && !status.isActive && !status.leftNotified {
+		entry, ok := blacklistMap[playerName]
+		if ok && !status.isActive && !status.leftNotified {
 			o.em.Publish(
+				ctx,
 				events.EventMessage{
-					Type:    "player.left",
-					Payload: playerName + " left the server " + server.Name,
+					Type:    events.TypePlayerLeft,
+					Payload: newPlayerEvent(playerName, server, status.duration, entry),
 				},
 			)
 			status.leftNotified = true
@@ -241,6 +320,46 @@ func (o *Observer) scan(
 	return previousPlayers
 }
 
+func (o *Observer) publishStatus(ctx context.Context, server *model.Server) {
+	eventType := events.TypeServerOffline
+	if server.Status {
+		eventType = events.TypeServerOnline
+	}
+
+	serverEvent := events.ServerEvent{
+		ServerID:   server.ID,
+		ServerName: server.Name,
+		ServerAddr: server.Addr,
+		Cluster:    server.Cluster,
+		Timestamp:  time.Now(),
+		Online:     server.Status,
+	}
+	if server.ServerInfo != nil {
+		serverEvent.Players = server.ServerInfo.Players
+		serverEvent.MaxPlayers = server.ServerInfo.MaxPlayers
+	}
+
+	o.em.Publish(ctx, events.EventMessage{Type: eventType, Payload: serverEvent})
+}
+
+func newPlayerEvent(
+	player string,
+	server *model.Server,
+	duration time.Duration,
+	entry *model.BlacklistPlayers,
+) events.PlayerEvent {
+	return events.PlayerEvent{
+		Player:     player,
+		ServerID:   server.ID,
+		ServerName: server.Name,
+		ServerAddr: server.Addr,
+		Cluster:    server.Cluster,
+		Timestamp:  time.Now(),
+		Duration:   duration,
+		Entry:      entry,
+	}
+}
+
 func (o *Observer) spawnScraper(ctx context.Context) {
 	select {
 	case <-ctx.Done():
@@ -280,10 +399,10 @@ func (o *Observer) processResults(ctx context.Context) {
 				case <-ctx.Done():
 					return
 				case result := <-ch:
-					if result == nil {
+					if result.server == nil {
 						continue
 					}
-					err := o.serverStore.Update(ctx, result)
+					err := o.serverStore.Update(trace.ContextWithSpanContext(ctx, result.spanContext), result.server)
 					if err != nil {
 						o.logger.Error("failed to update server info", "error", err)
 					}
@@ -326,10 +445,10 @@ func (o *Observer) killScraper(targetID uuid.UUID) error {
 
 func (o *Observer) HandleEvent(ctx context.Context, event events.EventMessage) {
 	switch event.Type {
-	case "init":
+	case events.TypeInit:
 		o.spawnScraper(ctx)
-	case "server.added":
-		server, ok := event.Payload.(*model.Server)
+	case events.TypeServerAdded:
+		server, ok := event.Server()
 		if !ok {
 			o.logger.ErrorContext(ctx, "invalid payload type", "error", event.Type)
 			return
@@ -339,8 +458,8 @@ func (o *Observer) HandleEvent(ctx context.Context, event events.EventMessage) {
 			o.logger.ErrorContext(ctx, "failed to add scraper", "error", err)
 			return
 		}
-	case "server.deleted":
-		id, ok := event.Payload.(uuid.UUID)
+	case events.TypeServerDeleted:
+		id, ok := event.ServerID()
 		if !ok {
 			o.logger.ErrorContext(ctx, "invalid payload type", "error", event.Type)
 			return
@@ -0,0 +1,79 @@
+package observer
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/led0nk/ark-overseer/internal/blacklist"
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestObserver(t *testing.T) (*Observer, *events.EventManager) {
+	bl, err := blacklist.NewBlacklist(filepath.Join(t.TempDir(), "blacklist.json"))
+	assert.NoError(t, err)
+	em := events.NewEventManager()
+	return &Observer{
+		blacklist: bl,
+		em:        em,
+		logger:    slog.Default(),
+	}, em
+}
+
+func TestUnreachableServerGoesOffline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	o, em := newTestObserver(t)
+	_, statusCh := em.Subscribe("status", events.Topics("server.*"))
+
+	// nothing listens on the discard port
+	target := &model.Server{
+		ID:          uuid.New(),
+		Name:        "island",
+		Addr:        "127.0.0.1:9",
+		Status:      true,
+		ServerInfo:  &model.ServerInfo{Name: "island", Map: "TheIsland", Players: 2, MaxPlayers: 70},
+		PlayersInfo: &model.PlayersInfo{Players: []*model.Players{{Name: "Raider"}}},
+	}
+
+	in := make(chan scrapeResult)
+	out := o.scanner(ctx, in)
+	go func() {
+		for range out {
+		}
+	}()
+
+	// the server was online before it became unreachable
+	in <- scrapeResult{server: target.Clone()}
+
+	var result scrapeResult
+	select {
+	case result = <-o.dataScraper(ctx, target):
+	case <-time.After(5 * time.Second):
+		t.Fatal("unreachable server wasn't reported")
+	}
+	assert.True(t, result.failed)
+	assert.False(t, result.server.Status)
+	assert.Equal(t, "TheIsland", result.server.ServerInfo.Map, "the last known state is kept")
+	assert.Equal(t, 0, result.server.ServerInfo.Players)
+	assert.Empty(t, result.server.PlayersInfo.Players)
+	assert.True(t, target.Status, "the target isn't changed")
+
+	in <- result
+	select {
+	case event := <-statusCh:
+		assert.Equal(t, events.TypeServerOffline, event.Type)
+		serverEvent, ok := event.ServerEvent()
+		assert.True(t, ok)
+		assert.Equal(t, "island", serverEvent.ServerName)
+		assert.False(t, serverEvent.Online)
+	case <-time.After(time.Second):
+		t.Fatal("server.offline wasn't published")
+	}
+}
@@ -6,13 +6,19 @@ import (
 	"fmt"
 	"html"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/a-h/templ"
 	"github.com/google/uuid"
 	"github.com/led0nk/ark-overseer/cmd/web"
+	"github.com/led0nk/ark-overseer/internal/backup"
+	"github.com/led0nk/ark-overseer/internal/blacklist"
 	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/led0nk/ark-overseer/internal/services"
+	"github.com/led0nk/ark-overseer/internal/services/registry"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
@@ -20,6 +26,9 @@ import (
 
 var tracer = otel.GetTracerProvider().Tracer("github.com/led0nk/ark-overseer/internal/server")
 
+// historyLimit is the number of events shown on the history page.
+const historyLimit = 200
+
 func (s *Server) mainPage(w http.ResponseWriter, r *http.Request) {
 	var (
 		serverList []*model.Server
@@ -79,126 +88,102 @@ func (s *Server) showPlayers(w http.ResponseWriter, r *http.Request) {
 func (s *Server) sseServerUpdate(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	ctx, span := tracer.Start(ctx, "sseServerUpdate")
+	defer span.End()
 
-	type event struct {
-		Type string
-		Data any
+	id, err := uuid.Parse(r.PathValue("ID"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to parse uuid", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+
+	updates, err := s.sStore.Watch(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to watch server", "error", err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	dataCh := make(chan event)
 
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	go func(ctx context.Context) {
-		for {
-			select {
-			case <-ctx.Done():
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case srv, ok := <-updates:
+			if !ok {
 				return
-			case event := <-dataCh:
-				_, _ = fmt.Fprintf(w, "event: %s\n", event.Type)
-				_, _ = fmt.Fprintf(w, "data: %s\n\n", event.Data)
-				w.(http.Flusher).Flush()
 			}
-		}
-	}(ctx)
-
-	go func() {
-		defer close(dataCh)
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				id, err := uuid.Parse(r.PathValue("ID"))
-				if err != nil {
-					span.RecordError(err)
-					span.SetStatus(codes.Error, err.Error())
-					s.logger.ErrorContext(ctx, "failed to parse uuid", "error", err)
-					continue
-				}
-				srv, err := s.sStore.GetByID(ctx, id)
-				if err != nil {
-					span.RecordError(err)
-					span.SetStatus(codes.Error, err.Error())
-					s.logger.ErrorContext(ctx, "failed to get server", "error", err)
-					continue
-				}
-				if srv.ServerInfo == nil {
-					span.RecordError(err)
-					span.SetStatus(codes.Error, err.Error())
-					s.logger.ErrorContext(ctx, "server not found", "error", err)
-					time.Sleep(time.Second)
-					continue
-				}
-				status := `<span class="inline-flex items-center gap-1 rounded-full dark:bg-[#0D1117] bg-green-50 px-2 py-1 text-xs font-semibold text-green-600"><span class="h-1.5 w-1.5 rounded-full bg-green-600"></span>online</span>`
-				if !srv.Status {
-					srv.ServerInfo.Players = 0
-					status = `<span class="inline-flex items-center gap-1 rounded-full dark:bg-[#0D1117] bg-red-50 px-2 py-1 text-xs font-semibold text-red-600"><span class="h-1.5 w-1.5 rounded-full bg-red-600"></span>offline</span>`
-				}
-				playerInfo := strconv.Itoa(srv.ServerInfo.Players) + "/" + strconv.Itoa(srv.ServerInfo.MaxPlayers)
-				select {
-				case dataCh <- event{Type: "PlayerCounter", Data: playerInfo}:
-				default:
-				}
-				select {
-				case dataCh <- event{Type: "ServerStatus", Data: status}:
-				default:
-				}
-				time.Sleep(5 * time.Second)
+			if srv.ServerInfo == nil {
+				continue
 			}
+			players := srv.ServerInfo.Players
+			status := `<span class="inline-flex items-center gap-1 rounded-full dark:bg-[#0D1117] bg-green-50 px-2 py-1 text-xs font-semibold text-green-600"><span class="h-1.5 w-1.5 rounded-full bg-green-600"></span>online</span>`
+			if !srv.Status {
+				players = 0
+				status = `<span class="inline-flex items-center gap-1 rounded-full dark:bg-[#0D1117] bg-red-50 px-2 py-1 text-xs font-semibold text-red-600"><span class="h-1.5 w-1.5 rounded-full bg-red-600"></span>offline</span>`
+			}
+			playerInfo := strconv.Itoa(players) + "/" + strconv.Itoa(srv.ServerInfo.MaxPlayers)
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", "PlayerCounter", playerInfo)
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", "ServerStatus", status)
+			w.(http.Flusher).Flush()
 		}
-	}()
-	<-ctx.Done()
+	}
 }
 
 func (s *Server) ssePlayerInfo(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, span := tracer.Start(ctx, "ssePlayerInfo")
+	defer span.End()
+
+	id, err := uuid.Parse(r.PathValue("ID"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to parse uuid", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updates, err := s.sStore.Watch(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to watch server", "error", err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	ctx := r.Context()
-	ctx, _ = tracer.Start(ctx, "ssePlayerInfo")
-	dataCh := make(chan *model.Server)
-
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
 
-	go func(ctx context.Context) {
-		defer close(dataCh)
-		for {
-			select {
-			case <-ctx.Done():
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case srv, ok := <-updates:
+			if !ok {
 				return
-			default:
-				srv, err := s.sStore.GetByID(ctx, uuid.MustParse(r.PathValue("ID")))
-				if err != nil {
-					s.logger.ErrorContext(ctx, "failed to get server", "error", err)
-				}
-				dataCh <- srv
-				time.Sleep(1 * time.Second)
 			}
-		}
-	}(ctx)
-
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case data := <-dataCh:
-				var buffer bytes.Buffer
-				for _, player := range data.PlayersInfo.Players {
-					playerRow := fmt.Sprintf(`<tr class="hover:bg-gray-50 dark:hover:bg-[#21262d]/50"><td class="px-6 py-4"><div class="font-medium text-gray-700 dark:text-gray-200">%s</div></td><td class="px-6 py-4"><div class="font-medium text-gray-700 dark:text-gray-200">%s</div></td></tr>`, player.Name, player.Duration)
-					buffer.WriteString(playerRow)
-				}
-				_, _ = fmt.Fprintf(w, "data: %s\n\n", buffer.String())
-				w.(http.Flusher).Flush()
+			if srv.PlayersInfo == nil {
+				continue
+			}
+			var buffer bytes.Buffer
+			for _, player := range srv.PlayersInfo.Players {
+				playerRow := fmt.Sprintf(`<tr class="hover:bg-gray-50 dark:hover:bg-[#21262d]/50"><td class="px-6 py-4"><div class="font-medium text-gray-700 dark:text-gray-200">%s</div></td><td class="px-6 py-4"><div class="font-medium text-gray-700 dark:text-gray-200">%s</div></td></tr>`, player.Name, player.Duration)
+				buffer.WriteString(playerRow)
 			}
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", buffer.String())
+			w.(http.Flusher).Flush()
 		}
-	}()
-	<-ctx.Done()
+	}
 }
 
 func (s *Server) deleteServer(w http.ResponseWriter, r *http.Request) {
@@ -248,8 +233,9 @@ func (s *Server) addServer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	newServer := &model.Server{
-		Name: html.EscapeString(r.FormValue("servername")),
-		Addr: html.EscapeString(r.FormValue("address")),
+		Name:    html.EscapeString(r.FormValue("servername")),
+		Addr:    html.EscapeString(r.FormValue("address")),
+		Cluster: html.EscapeString(r.FormValue("cluster")),
 	}
 	_, err = s.sStore.Create(ctx, newServer)
 	if err != nil {
@@ -283,11 +269,41 @@ func (s *Server) blacklistPage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) historyPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, span := tracer.Start(ctx, "historyPage")
+	defer span.End()
+
+	history, err := s.events.History(historyLimit)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to read event history", "error", err)
+		http.Error(w, "failed to read event history", http.StatusInternalServerError)
+		return
+	}
+
+	err = web.Render(ctx, w, web.History(history, s.events.DeadLetters()))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to render templ", "error", err)
+		return
+	}
+}
+
 func (s *Server) setupPage(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	ctx, span := tracer.Start(ctx, "setupPage")
+	defer span.End()
 
-	err := web.Render(ctx, w, web.Setup())
+	section := s.notificationSection(ctx)
+	routes, err := services.ParseRoutes(section[services.RoutesKey])
+	if err != nil {
+		s.logger.WarnContext(ctx, "invalid routes", "error", err)
+	}
+
+	err = web.Render(ctx, w, web.Setup(notifierForms(section), routes, services.Instances(section)))
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -296,10 +312,106 @@ func (s *Server) setupPage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// NOTE: possible implementation of other services
-func (s *Server) saveChanges(w http.ResponseWriter, r *http.Request) {
+// notifierForms returns a form per configured instance and one for every
+// type that isn't configured yet, types without fields are left out.
+func notifierForms(section map[interface{}]interface{}) []web.NotifierForm {
+	var forms []web.NotifierForm
+	configured := make(map[string]bool)
+	for _, name := range services.Instances(section) {
+		current, _ := section[name].(map[interface{}]interface{})
+		typ, ok := registry.Lookup(services.InstanceType(name, current))
+		if !ok || len(typ.Fields) == 0 {
+			continue
+		}
+		configured[name] = true
+
+		form := web.NotifierForm{
+			Name:     name,
+			Type:     typ,
+			Values:   make(map[string]string),
+			Secrets:  make(map[string]bool),
+			ReadOnly: make(map[string]bool),
+		}
+		for _, field := range typ.Fields {
+			value, ok := current[field.Key]
+			if !ok || value == nil || value == "" {
+				continue
+			}
+			if field.Type == registry.FieldSecret {
+				form.Secrets[field.Key] = true
+				continue
+			}
+			form.Values[field.Key] = fieldValue(value)
+			form.ReadOnly[field.Key] = !editable(value)
+		}
+		forms = append(forms, form)
+	}
+
+	for _, typ := range registry.Types() {
+		if len(typ.Fields) > 0 && !configured[typ.Name] {
+			forms = append(forms, web.NotifierForm{Name: typ.Name, Type: typ})
+		}
+	}
+	return forms
+}
+
+// fieldValue formats a config value for its input, lists are comma
+// separated and maps written like yaml flow mappings.
+func fieldValue(value interface{}) string {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fieldValue(item))
+		}
+		return strings.Join(items, ", ")
+	case map[interface{}]interface{}:
+		pairs := make([]string, 0, len(v))
+		for key, item := range v {
+			pairs = append(pairs, fmt.Sprintf("%v: %s", key, fieldValue(item)))
+		}
+		sort.Strings(pairs)
+		return "{" + strings.Join(pairs, ", ") + "}"
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// editable reports whether the value survives a round trip through its
+// input, lists of maps like telegram chats with a threadID don't and are
+// only edited in config.yaml.
+func editable(value interface{}) bool {
+	list, ok := value.([]interface{})
+	if !ok {
+		_, isMap := value.(map[interface{}]interface{})
+		return !isMap
+	}
+	for _, item := range list {
+		switch item.(type) {
+		case []interface{}, map[interface{}]interface{}:
+			return false
+		}
+	}
+	return true
+}
+
+// saveNotifier updates the notifier instance with the fields of the form,
+// keys that aren't part of the form are kept. Empty fields unset their key,
+// except for secrets which keep their value then. Values that aren't
+// editable on the page are never overwritten. Unknown names create an
+// instance of the type with that name.
+func (s *Server) saveNotifier(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	ctx, span := tracer.Start(ctx, "saveChanges")
+	ctx, span := tracer.Start(ctx, "saveNotifier")
+	defer span.End()
+
+	name := r.PathValue("name")
+	current, _ := s.notificationSection(ctx)[name].(map[interface{}]interface{})
+	typ, ok := registry.Lookup(services.InstanceType(name, current))
+	if !ok {
+		http.Error(w, "unknown notifier", http.StatusNotFound)
+		return
+	}
 
 	err := r.ParseForm()
 	if err != nil {
@@ -310,10 +422,37 @@ func (s *Server) saveChanges(w http.ResponseWriter, r *http.Request) {
 	}
 
 	sectionMap := make(map[interface{}]interface{})
-	sectionMap["token"] = r.FormValue("token")
-	sectionMap["channelID"] = r.FormValue("channelID")
+	for key, value := range current {
+		sectionMap[key] = value
+	}
+	for _, field := range typ.Fields {
+		if value, ok := current[field.Key]; ok && !editable(value) {
+			continue
+		}
+		value, err := formValue(r, field)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if value == nil {
+			if field.Type != registry.FieldSecret {
+				delete(sectionMap, field.Key)
+			}
+			continue
+		}
+		sectionMap[field.Key] = value
+	}
 
-	err = s.config.Update("notification-service", "discord", sectionMap)
+	err = typ.Validate(sectionMap)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "invalid notifier config", "error", err, "notifier", name)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = s.config.Update("notification-service", name, sectionMap)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -324,6 +463,169 @@ func (s *Server) saveChanges(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/", http.StatusFound)
 }
 
+// formValue converts the form value of the field to its config type, empty
+// values are nil.
+func formValue(r *http.Request, field registry.Field) (interface{}, error) {
+	value := strings.TrimSpace(r.FormValue(field.Key))
+	switch field.Type {
+	case registry.FieldCheckbox:
+		return value != "", nil
+	case registry.FieldNumber:
+		if value == "" {
+			return nil, nil
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", field.Key, err)
+		}
+		return number, nil
+	case registry.FieldList:
+		if list := splitList(value); len(list) > 0 {
+			return list, nil
+		}
+		return nil, nil
+	default:
+		if value == "" {
+			return nil, nil
+		}
+		return value, nil
+	}
+}
+
+// notificationSection returns a copy of the notification-service section.
+func (s *Server) notificationSection(ctx context.Context) map[interface{}]interface{} {
+	current, err := s.config.Export()
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to read config", "error", err)
+		return nil
+	}
+	section, _ := current["notification-service"].(map[interface{}]interface{})
+	return section
+}
+
+// saveRoutes replaces the routing table with the rows of the form, an invalid
+// table is rejected without writing it.
+func (s *Server) saveRoutes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, span := tracer.Start(ctx, "saveRoutes")
+	defer span.End()
+
+	err := r.ParseForm()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to parse form", "error", err)
+		return
+	}
+
+	eventList := r.Form["routeEvents"]
+	servers := r.Form["routeServers"]
+	severities := r.Form["routeSeverity"]
+	targets := r.Form["routeTargets"]
+	rows := make([]interface{}, 0, len(targets))
+	for i := range targets {
+		if i >= len(eventList) || i >= len(servers) || i >= len(severities) {
+			break
+		}
+		if strings.TrimSpace(eventList[i]+servers[i]+severities[i]+targets[i]) == "" {
+			continue
+		}
+		rows = append(rows, map[interface{}]interface{}{
+			"events":   splitList(eventList[i]),
+			"servers":  splitList(servers[i]),
+			"severity": severities[i],
+			"targets":  splitList(targets[i]),
+		})
+	}
+
+	routes, err := services.ParseRoutes(rows)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "invalid routes", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = s.config.Update("notification-service", services.RoutesKey, routes.Section())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to update config", "error", err)
+		return
+	}
+
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// splitList splits comma separated form values.
+func splitList(value string) []interface{} {
+	var list []interface{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func (s *Server) exportBackup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, span := tracer.Start(ctx, "exportBackup")
+	defer span.End()
+
+	redact := r.URL.Query().Get("redact") != "false"
+	filename := "ark-overseer-backup-" + time.Now().UTC().Format("20060102-150405") + ".tar.gz"
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	err := s.backup.Export(ctx, w, redact)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to export backup", "error", err)
+		return
+	}
+}
+
+func (s *Server) restoreBackup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, span := tracer.Start(ctx, "restoreBackup")
+	defer span.End()
+
+	mode, err := backup.ParseMode(r.FormValue("mode"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to parse restore mode", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bundle, _, err := r.FormFile("bundle")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to read backup bundle", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer bundle.Close()
+
+	err = s.backup.Restore(ctx, bundle, mode)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to restore backup", "error", err)
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/")
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
 func (s *Server) blacklistAdd(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	ctx, span := tracer.Start(ctx, "blacklistAdd")
@@ -336,7 +638,11 @@ func (s *Server) blacklistAdd(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	_, err = s.blacklist.Create(ctx, &model.BlacklistPlayers{
-		Name: r.FormValue("blacklistPlayer"),
+		Name:        r.FormValue("blacklistPlayer"),
+		SteamID:     strings.TrimSpace(r.FormValue("steamID")),
+		ThreatLevel: strings.TrimSpace(r.FormValue("threatLevel")),
+		Tags:        blacklist.SplitTags(r.FormValue("tags")),
+		Notes:       strings.TrimSpace(r.FormValue("notes")),
 	})
 	if err != nil {
 		span.RecordError(err)
@@ -372,6 +678,96 @@ func (s *Server) blacklistDelete(w http.ResponseWriter, r *http.Request) {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		s.logger.ErrorContext(ctx, "failed to delete from blacklist", "error", err)
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+}
+
+func (s *Server) blacklistExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, span := tracer.Start(ctx, "blacklistExport")
+	defer span.End()
+
+	format, err := blacklist.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to parse format", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filename := "blacklist." + string(format)
+	contentType := "text/plain"
+	switch format {
+	case blacklist.FormatCSV:
+		contentType = "text/csv"
+	case blacklist.FormatJSON:
+		contentType = "application/json"
+	case blacklist.FormatBanList:
+		filename = "BanList.txt"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	err = blacklist.Export(ctx, s.blacklist, format, w)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to export blacklist", "error", err)
+		return
+	}
+}
+
+func (s *Server) blacklistImport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, span := tracer.Start(ctx, "blacklistImport")
+	defer span.End()
+
+	format, err := blacklist.ParseFormat(r.FormValue("format"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to parse format", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to read import file", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	dryRun := r.FormValue("dryrun") == "true"
+	result, err := blacklist.Import(ctx, s.blacklist, format, file, dryRun)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to import blacklist", "error", err)
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = web.Render(ctx, w, web.BlacklistImportResult(result.DryRun, result.Added, result.Duplicates, len(result.Unmatched)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.ErrorContext(ctx, "failed to render templ", "error", err)
+		return
+	}
+
+	if !dryRun {
+		err = web.Render(ctx, w, web.BlacklistTableUpdate(s.blacklist.List(ctx)))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			s.logger.ErrorContext(ctx, "failed to render templ", "error", err)
+			return
+		}
+	}
 }
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/led0nk/ark-overseer/internal/blacklist"
+	"github.com/led0nk/ark-overseer/internal/storage"
+	"github.com/led0nk/ark-overseer/pkg/config"
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+const telegramConfig = `notification-service:
+  telegram:
+    token: 123456:ABC-DEF
+    chats:
+      - -1001234567890
+      - chatID: -1009876543210
+        threadID: 42
+`
+
+func newTestServer(t *testing.T, configData string) (*Server, *config.Config) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(configData), 0644))
+
+	em := events.NewEventManager()
+	sStore, err := storage.NewServerStorage(context.Background(), filepath.Join(dir, "cluster.json"))
+	assert.NoError(t, err)
+	bl, err := blacklist.NewBlacklist(filepath.Join(dir, "blacklist.json"))
+	assert.NoError(t, err)
+	cfg, err := config.NewConfiguration(filepath.Join(dir, "config.yaml"), em)
+	assert.NoError(t, err)
+	return NewServer("localhost:8080", "http://localhost:8080", sStore, bl, cfg, em), cfg
+}
+
+func TestSaveNotifierKeepsStructuredLists(t *testing.T) {
+	s, cfg := newTestServer(t, telegramConfig)
+	before, err := cfg.GetSection("notification-service")
+	assert.NoError(t, err)
+	expected := before["telegram"]
+
+	w := httptest.NewRecorder()
+	s.setupPage(w, httptest.NewRequest(http.MethodGet, "/settings", nil))
+	assert.Contains(t, w.Body.String(), "-1001234567890, {chatID: -1009876543210, threadID: 42}")
+	assert.Contains(t, w.Body.String(), "edit in config.yaml")
+
+	// the disabled chats input isn't submitted, the token is left empty
+	for _, form := range []url.Values{
+		{"token": {""}},
+		{"token": {""}, "chats": {"map[chatID:-1009876543210 threadID:42]"}},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/settings/telegram", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetPathValue("name", "telegram")
+		w := httptest.NewRecorder()
+		s.saveNotifier(w, req)
+		assert.Equal(t, http.StatusFound, w.Code)
+
+		after, err := cfg.GetSection("notification-service")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, after["telegram"])
+	}
+}
+
+func TestSaveRoutesRejectsInvalidTable(t *testing.T) {
+	s, cfg := newTestServer(t, telegramConfig)
+
+	// the route has no targets
+	form := url.Values{
+		"routeEvents":   {"player.*"},
+		"routeServers":  {""},
+		"routeSeverity": {""},
+		"routeTargets":  {""},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/settings/routes", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	s.saveRoutes(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	section, err := cfg.GetSection("notification-service")
+	assert.NoError(t, err)
+	assert.NotContains(t, section, "routes")
+}
@@ -5,9 +5,11 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/led0nk/ark-overseer/internal/backup"
 	"github.com/led0nk/ark-overseer/internal/blacklist"
 	"github.com/led0nk/ark-overseer/internal/storage"
 	"github.com/led0nk/ark-overseer/pkg/config"
+	"github.com/led0nk/ark-overseer/pkg/events"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	sloghttp "github.com/samber/slog-http"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
@@ -21,6 +23,8 @@ type Server struct {
 	sStore    storage.Database
 	blacklist blacklist.Blacklister
 	config    config.Configuration
+	backup    *backup.Backup
+	events    *events.EventManager
 }
 
 func NewServer(
@@ -29,6 +33,7 @@ func NewServer(
 	sStore storage.Database,
 	blacklist blacklist.Blacklister,
 	config config.Configuration,
+	eventManager *events.EventManager,
 ) *Server {
 	return &Server{
 		addr:      address,
@@ -37,6 +42,8 @@ func NewServer(
 		sStore:    sStore,
 		blacklist: blacklist,
 		config:    config,
+		backup:    backup.NewBackup(sStore, blacklist, config, eventManager.Journal()),
+		events:    eventManager,
 	}
 }
 
@@ -62,11 +69,17 @@ func (s *Server) ServeHTTP(ctx context.Context) {
 	r.Handle("DELETE /{ID}", http.HandlerFunc(s.deleteServer))
 	r.Handle("GET /serverdata/{ID}", http.HandlerFunc(s.sseServerUpdate))
 	r.Handle("GET /serverdata/{ID}/players", http.HandlerFunc(s.ssePlayerInfo))
+	r.Handle("GET /history", http.HandlerFunc(s.historyPage))
 	r.Handle("GET /settings", http.HandlerFunc(s.setupPage))
-	r.Handle("POST /settings", http.HandlerFunc(s.saveChanges))
+	r.Handle("POST /settings/routes", http.HandlerFunc(s.saveRoutes))
+	r.Handle("POST /settings/{name}", http.HandlerFunc(s.saveNotifier))
+	r.Handle("GET /backup", http.HandlerFunc(s.exportBackup))
+	r.Handle("POST /backup", http.HandlerFunc(s.restoreBackup))
 	r.Handle("GET /blacklist", http.HandlerFunc(s.blacklistPage))
 	r.Handle("POST /blacklist", http.HandlerFunc(s.blacklistAdd))
 	r.Handle("DELETE /blacklist/{ID}", http.HandlerFunc(s.blacklistDelete))
+	r.Handle("GET /blacklist/export", http.HandlerFunc(s.blacklistExport))
+	r.Handle("POST /blacklist/import", http.HandlerFunc(s.blacklistImport))
 
 	s.logger.Info("listen and serve", "addr", s.addr)
 
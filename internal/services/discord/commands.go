@@ -0,0 +1,591 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/google/uuid"
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/led0nk/ark-overseer/internal/services/retry"
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+const (
+	// historyLimit bounds the journaled events /lastseen and /history
+	// search through.
+	historyLimit = 1000
+	// historyEntries is the number of events /history shows.
+	historyEntries = 15
+	// maxChoices is the limit of autocomplete choices.
+	maxChoices = 25
+)
+
+// restricted commands change the watchlist, nobody may use them until
+// roles are configured for them.
+var restricted = []string{"watch.add", "watch.remove"}
+
+// History reads the latest journaled events, newest first.
+type History interface {
+	History(limit int) ([]events.EventMessage, error)
+}
+
+var commands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "status",
+		Description: "Status of all servers or a single one",
+		Options: []*discordgo.ApplicationCommandOption{
+			serverOption(false),
+		},
+	},
+	{
+		Name:        "players",
+		Description: "Players on a server",
+		Options: []*discordgo.ApplicationCommandOption{
+			serverOption(true),
+		},
+	},
+	{
+		Name:        "watch",
+		Description: "Manage the watchlist",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Watch a player",
+				Options: []*discordgo.ApplicationCommandOption{
+					playerOption(),
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "threat",
+						Description: "Threat level",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "low", Value: "low"},
+							{Name: "medium", Value: "medium"},
+							{Name: "high", Value: "high"},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "notes",
+						Description: "Notes about the player",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Stop watching a player",
+				Options:     []*discordgo.ApplicationCommandOption{playerOption()},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List the watched players",
+			},
+		},
+	},
+	{
+		Name:        "lastseen",
+		Description: "When and where a player was seen last",
+		Options:     []*discordgo.ApplicationCommandOption{playerOption()},
+	},
+	{
+		Name:        "history",
+		Description: "Latest events of a server",
+		Options: []*discordgo.ApplicationCommandOption{
+			serverOption(true),
+		},
+	},
+}
+
+func serverOption(required bool) *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionString,
+		Name:         "server",
+		Description:  "Server name",
+		Required:     required,
+		Autocomplete: true,
+	}
+}
+
+func playerOption() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "name",
+		Description: "Player name",
+		Required:    true,
+	}
+}
+
+// commandBots holds the tokens of the bots whose commands are answered by a
+// connected instance, a second one would answer every command again.
+var (
+	commandBotsMu sync.Mutex
+	commandBots   = make(map[string]bool)
+)
+
+// claimCommands reports whether the instance may answer the commands of
+// the bot, releaseCommands frees them again.
+func claimCommands(token string) bool {
+	commandBotsMu.Lock()
+	defer commandBotsMu.Unlock()
+	if commandBots[token] {
+		return false
+	}
+	commandBots[token] = true
+	return true
+}
+
+func releaseCommands(token string) {
+	commandBotsMu.Lock()
+	defer commandBotsMu.Unlock()
+	delete(commandBots, token)
+}
+
+// registerCommands replaces the commands of the application with ours.
+func (dn *DiscordNotifier) registerCommands(session *discordgo.Session) error {
+	if session.State == nil || session.State.User == nil {
+		return errors.New("session has no application user")
+	}
+	_, err := session.ApplicationCommandBulkOverwrite(session.State.User.ID, dn.cfg.GuildID, commands)
+	return err
+}
+
+func (dn *DiscordNotifier) onInteraction(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	ctx, cancel := context.WithTimeout(context.Background(), retry.Timeout)
+	defer cancel()
+
+	var response *discordgo.InteractionResponse
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		var roles []string
+		if i.Member != nil {
+			roles = i.Member.Roles
+		}
+		response = &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: dn.handleCommand(ctx, i.ApplicationCommandData(), roles),
+		}
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		response = &discordgo.InteractionResponse{
+			Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+			Data: &discordgo.InteractionResponseData{Choices: dn.serverChoices(ctx, i.ApplicationCommandData())},
+		}
+	default:
+		return
+	}
+
+	err := session.InteractionRespond(i.Interaction, response, discordgo.WithContext(ctx))
+	if err != nil {
+		dn.logger.ErrorContext(ctx, "failed to respond to interaction", "error", err)
+	}
+}
+
+// handleCommand runs the command and returns the response, errors are only
+// shown to the member who used the command.
+func (dn *DiscordNotifier) handleCommand(ctx context.Context, data discordgo.ApplicationCommandInteractionData, roles []string) *discordgo.InteractionResponseData {
+	name := data.Name
+	options := data.Options
+	if name == "watch" && len(options) == 1 && options[0].Type == discordgo.ApplicationCommandOptionSubCommand {
+		name += "." + options[0].Name
+		options = options[0].Options
+	}
+
+	if !dn.allowed(name, roles) {
+		return ephemeral("You are not allowed to use this command.")
+	}
+
+	var response *discordgo.InteractionResponseData
+	var err error
+	switch name {
+	case "status":
+		response, err = dn.status(ctx, option(options, "server"))
+	case "players":
+		response, err = dn.players(ctx, option(options, "server"))
+	case "watch.add":
+		response, err = dn.watchAdd(ctx, option(options, "name"), option(options, "threat"), option(options, "notes"))
+	case "watch.remove":
+		response, err = dn.watchRemove(ctx, option(options, "name"))
+	case "watch.list":
+		response = dn.watchList(ctx)
+	case "lastseen":
+		response, err = dn.lastSeen(ctx, option(options, "name"))
+	case "history":
+		response, err = dn.serverHistory(ctx, option(options, "server"))
+	default:
+		err = fmt.Errorf("unknown command %s", name)
+	}
+	if err != nil {
+		dn.logger.WarnContext(ctx, "command failed", "error", err, "command", name)
+		return ephemeral(err.Error())
+	}
+	response.AllowedMentions = &discordgo.MessageAllowedMentions{}
+	return response
+}
+
+// allowed checks the roles configured for the subcommand, then for the
+// command. Restricted commands without roles are denied, an empty list of
+// roles opens them to everyone.
+func (dn *DiscordNotifier) allowed(name string, roles []string) bool {
+	required, ok := dn.cfg.CommandRoles[name]
+	if !ok {
+		parent, _, _ := strings.Cut(name, ".")
+		required, ok = dn.cfg.CommandRoles[parent]
+	}
+	if !ok {
+		return !slices.Contains(restricted, name)
+	}
+	if len(required) == 0 {
+		return true
+	}
+	for _, role := range roles {
+		if slices.Contains(required, role) {
+			return true
+		}
+	}
+	return false
+}
+
+func option(options []*discordgo.ApplicationCommandInteractionDataOption, name string) string {
+	for _, o := range options {
+		if o.Name == name {
+			return strings.TrimSpace(o.StringValue())
+		}
+	}
+	return ""
+}
+
+func ephemeral(content string) *discordgo.InteractionResponseData {
+	return &discordgo.InteractionResponseData{
+		Content:         content,
+		Flags:           discordgo.MessageFlagsEphemeral,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+}
+
+// serverChoices completes the server option with the names of the known
+// servers.
+func (dn *DiscordNotifier) serverChoices(ctx context.Context, data discordgo.ApplicationCommandInteractionData) []*discordgo.ApplicationCommandOptionChoice {
+	var prefix string
+	for _, o := range data.Options {
+		if o.Focused {
+			prefix = strings.ToLower(o.StringValue())
+		}
+	}
+
+	servers, err := dn.sortedServers(ctx)
+	if err != nil {
+		dn.logger.ErrorContext(ctx, "failed to list servers", "error", err)
+		return nil
+	}
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, maxChoices)
+	for _, server := range servers {
+		if len(choices) == maxChoices {
+			break
+		}
+		if strings.Contains(strings.ToLower(server.Name), prefix) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: server.Name, Value: server.Name})
+		}
+	}
+	return choices
+}
+
+func (dn *DiscordNotifier) sortedServers(ctx context.Context) ([]*model.Server, error) {
+	servers, err := dn.sStore.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+	return servers, nil
+}
+
+// server finds a server by name, ignoring case.
+func (dn *DiscordNotifier) server(ctx context.Context, name string) (*model.Server, error) {
+	servers, err := dn.sStore.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, server := range servers {
+		if strings.EqualFold(server.Name, name) {
+			return server, nil
+		}
+	}
+	return nil, fmt.Errorf("server %s not found", name)
+}
+
+func (dn *DiscordNotifier) status(ctx context.Context, name string) (*discordgo.InteractionResponseData, error) {
+	if name != "" {
+		server, err := dn.server(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{dn.serverEmbed(ctx, server)}}, nil
+	}
+
+	servers, err := dn.sortedServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return &discordgo.InteractionResponseData{Content: "No servers are observed."}, nil
+	}
+	lines := make([]string, 0, len(servers))
+	for _, server := range servers {
+		lines = append(lines, statusLine(server))
+	}
+	return &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{{
+		Title:       "Server status",
+		URL:         baseURL(dn.cfg.Domain),
+		Description: truncate(strings.Join(lines, "\n"), maxDescription),
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}}}, nil
+}
+
+func statusLine(server *model.Server) string {
+	if !server.Status {
+		return "🔴 **" + server.Name + "** offline"
+	}
+	line := "🟢 **" + server.Name + "**"
+	if server.ServerInfo != nil {
+		line += fmt.Sprintf(" %d/%d", server.ServerInfo.Players, server.ServerInfo.MaxPlayers)
+		if server.ServerInfo.Map != "" {
+			line += " · " + server.ServerInfo.Map
+		}
+	}
+	return line
+}
+
+func (dn *DiscordNotifier) serverEmbed(ctx context.Context, server *model.Server) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:     server.Name,
+		URL:       baseURL(dn.cfg.Domain),
+		Color:     colors[events.TypeServerOffline],
+		Timestamp: time.Now().Format(time.RFC3339),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Address", Value: "`" + server.Addr + "`", Inline: true},
+			{Name: "Status", Value: "offline", Inline: true},
+		},
+	}
+	if server.Status {
+		embed.Color = colors[events.TypeServerOnline]
+		embed.Fields[1].Value = "online"
+	}
+	if server.Cluster != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Cluster", Value: server.Cluster, Inline: true})
+	}
+	if server.ServerInfo != nil {
+		if server.ServerInfo.Map != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Map", Value: server.ServerInfo.Map, Inline: true})
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Population",
+			Value:  fmt.Sprintf("%d/%d", server.ServerInfo.Players, server.ServerInfo.MaxPlayers),
+			Inline: true,
+		})
+	}
+	if watched := dn.watchedOnline(ctx, server); len(watched) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Watched players", Value: truncate(strings.Join(watched, ", "), maxFieldValue)})
+	}
+	return embed
+}
+
+// watchedOnline returns the names of watched players on the server.
+func (dn *DiscordNotifier) watchedOnline(ctx context.Context, server *model.Server) []string {
+	if server.PlayersInfo == nil {
+		return nil
+	}
+	watched := dn.watched(ctx)
+	var names []string
+	for _, player := range server.PlayersInfo.Players {
+		if player != nil && watched[player.Name] != nil {
+			names = append(names, player.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (dn *DiscordNotifier) watched(ctx context.Context) map[string]*model.BlacklistPlayers {
+	watched := make(map[string]*model.BlacklistPlayers)
+	for _, entry := range dn.blacklist.List(ctx) {
+		if entry.Name != "" {
+			watched[entry.Name] = entry
+		}
+	}
+	return watched
+}
+
+func (dn *DiscordNotifier) players(ctx context.Context, name string) (*discordgo.InteractionResponseData, error) {
+	server, err := dn.server(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if server.PlayersInfo == nil || len(server.PlayersInfo.Players) == 0 {
+		return &discordgo.InteractionResponseData{Content: "Nobody is on " + server.Name + "."}, nil
+	}
+
+	watched := dn.watched(ctx)
+	players := slices.Clone(server.PlayersInfo.Players)
+	sort.Slice(players, func(i, j int) bool { return players[i].Duration > players[j].Duration })
+	lines := make([]string, 0, len(players))
+	for _, player := range players {
+		if player == nil || player.Name == "" {
+			continue
+		}
+		line := player.Name + " · " + player.Duration.Round(time.Minute).String()
+		if entry := watched[player.Name]; entry != nil {
+			line = "⚠️ **" + player.Name + "** · " + player.Duration.Round(time.Minute).String()
+			if entry.ThreatLevel != "" {
+				line += " · threat " + entry.ThreatLevel
+			}
+		}
+		lines = append(lines, line)
+	}
+	return &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{{
+		Title:       fmt.Sprintf("Players on %s (%d)", server.Name, len(lines)),
+		URL:         baseURL(dn.cfg.Domain),
+		Description: truncate(strings.Join(lines, "\n"), maxDescription),
+	}}}, nil
+}
+
+func (dn *DiscordNotifier) watchAdd(ctx context.Context, name string, threat string, notes string) (*discordgo.InteractionResponseData, error) {
+	if name == "" {
+		return nil, errors.New("name required")
+	}
+	if dn.watched(ctx)[name] != nil {
+		return nil, fmt.Errorf("%s is already watched", name)
+	}
+	_, err := dn.blacklist.Create(ctx, &model.BlacklistPlayers{
+		Name:        name,
+		ThreatLevel: threat,
+		Notes:       notes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch %s: %w", name, err)
+	}
+	return &discordgo.InteractionResponseData{Content: "Watching **" + name + "**."}, nil
+}
+
+func (dn *DiscordNotifier) watchRemove(ctx context.Context, name string) (*discordgo.InteractionResponseData, error) {
+	entry := dn.watched(ctx)[name]
+	if entry == nil {
+		return nil, fmt.Errorf("%s is not watched", name)
+	}
+	if err := dn.blacklist.Delete(ctx, entry.ID); err != nil {
+		return nil, fmt.Errorf("failed to remove %s: %w", name, err)
+	}
+	return &discordgo.InteractionResponseData{Content: "Stopped watching **" + name + "**."}, nil
+}
+
+func (dn *DiscordNotifier) watchList(ctx context.Context) *discordgo.InteractionResponseData {
+	entries := dn.blacklist.List(ctx)
+	if len(entries) == 0 {
+		return &discordgo.InteractionResponseData{Content: "Nobody is watched."}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		line := entry.Name
+		if entry.ThreatLevel != "" {
+			line += " · threat " + entry.ThreatLevel
+		}
+		if len(entry.Tags) > 0 {
+			line += " · " + strings.Join(entry.Tags, ", ")
+		}
+		lines = append(lines, line)
+	}
+	return &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{{
+		Title:       fmt.Sprintf("Watchlist (%d)", len(entries)),
+		URL:         baseURL(dn.cfg.Domain) + "blacklist",
+		Description: truncate(strings.Join(lines, "\n"), maxDescription),
+	}}}
+}
+
+// lastSeen looks for the player on the servers first, then in the history.
+// Only watched players are journaled.
+func (dn *DiscordNotifier) lastSeen(ctx context.Context, name string) (*discordgo.InteractionResponseData, error) {
+	servers, err := dn.sortedServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, server := range servers {
+		if server.PlayersInfo == nil {
+			continue
+		}
+		for _, player := range server.PlayersInfo.Players {
+			if player != nil && strings.EqualFold(player.Name, name) {
+				return &discordgo.InteractionResponseData{
+					Content: fmt.Sprintf("**%s** is online on **%s** for %s.", player.Name, server.Name, player.Duration.Round(time.Minute)),
+				}, nil
+			}
+		}
+	}
+
+	history, err := dn.journal.History(historyLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	for _, event := range history {
+		player, ok := event.PlayerEvent()
+		if !ok || !strings.EqualFold(player.Player, name) {
+			continue
+		}
+		return &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("**%s** was last seen on **%s** %s.", player.Player, player.ServerName, discordTime(event.Timestamp, "R")),
+		}, nil
+	}
+	return &discordgo.InteractionResponseData{Content: name + " hasn't been seen, only watched players are remembered."}, nil
+}
+
+func (dn *DiscordNotifier) serverHistory(ctx context.Context, name string) (*discordgo.InteractionResponseData, error) {
+	server, err := dn.server(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	history, err := dn.journal.History(historyLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var lines []string
+	for _, event := range history {
+		if len(lines) == historyEntries {
+			break
+		}
+		if eventServer(event) == server.ID {
+			lines = append(lines, discordTime(event.Timestamp, "f")+" "+event.Summary())
+		}
+	}
+	if len(lines) == 0 {
+		return &discordgo.InteractionResponseData{Content: "No events for " + server.Name + " yet."}, nil
+	}
+	return &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{{
+		Title:       "History of " + server.Name,
+		URL:         baseURL(dn.cfg.Domain) + "history",
+		Description: truncate(strings.Join(lines, "\n"), maxDescription),
+	}}}, nil
+}
+
+// eventServer returns the server of player and server events.
+func eventServer(event events.EventMessage) uuid.UUID {
+	if player, ok := event.PlayerEvent(); ok {
+		return player.ServerID
+	}
+	if server, ok := event.ServerEvent(); ok {
+		return server.ServerID
+	}
+	return uuid.Nil
+}
+
+// discordTime renders in the local time of the reader, style is one of
+// Discord's timestamp styles like R for relative.
+func discordTime(t time.Time, style string) string {
+	return fmt.Sprintf("<t:%d:%s>", t.Unix(), style)
+}
@@ -0,0 +1,176 @@
+package discord
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/led0nk/ark-overseer/internal/blacklist"
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/led0nk/ark-overseer/internal/storage"
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type testHistory []events.EventMessage
+
+func (h testHistory) History(limit int) ([]events.EventMessage, error) {
+	return h, nil
+}
+
+func stringOption(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  name,
+		Type:  discordgo.ApplicationCommandOptionString,
+		Value: value,
+	}
+}
+
+func watchCommand(sub string, options ...*discordgo.ApplicationCommandInteractionDataOption) discordgo.ApplicationCommandInteractionData {
+	return discordgo.ApplicationCommandInteractionData{
+		Name: "watch",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+			Name:    sub,
+			Type:    discordgo.ApplicationCommandOptionSubCommand,
+			Options: options,
+		}},
+	}
+}
+
+func newTestCommands(t *testing.T) (*DiscordNotifier, *model.Server) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	sStore, err := storage.NewServerStorage(ctx, filepath.Join(dir, "cluster.json"))
+	assert.NoError(t, err)
+	bl, err := blacklist.NewBlacklist(filepath.Join(dir, "blacklist.json"))
+	assert.NoError(t, err)
+
+	island, err := sStore.Create(ctx, &model.Server{
+		Name:       "island",
+		Addr:       "127.0.0.1:27015",
+		Status:     true,
+		ServerInfo: &model.ServerInfo{Map: "TheIsland", Players: 2, MaxPlayers: 70},
+		PlayersInfo: &model.PlayersInfo{Players: []*model.Players{
+			{Name: "Raider", Duration: 90 * time.Minute},
+			{Name: "Builder", Duration: 10 * time.Minute},
+		}},
+	})
+	assert.NoError(t, err)
+	_, err = sStore.Create(ctx, &model.Server{Name: "ragnarok", Addr: "127.0.0.1:27017"})
+	assert.NoError(t, err)
+	_, err = bl.Create(ctx, &model.BlacklistPlayers{Name: "Raider", ThreatLevel: "high"})
+	assert.NoError(t, err)
+
+	seen := time.Date(2024, 5, 1, 18, 30, 0, 0, time.UTC)
+	history := testHistory{
+		{Type: events.TypeServerOffline, Timestamp: seen, Payload: events.ServerEvent{ServerID: island.ID, ServerName: "island"}},
+		{Type: events.TypePlayerLeft, Timestamp: seen, Payload: events.PlayerEvent{Player: "Scout", ServerID: island.ID, ServerName: "island"}},
+	}
+
+	return &DiscordNotifier{
+		logger: slog.Default(),
+		cfg: Config{
+			CommandRoles: map[string][]string{"watch": {"100"}, "watch.list": {}},
+		},
+		sStore:    sStore,
+		blacklist: bl,
+		journal:   history,
+	}, island
+}
+
+func TestCommands(t *testing.T) {
+	ctx := context.Background()
+	dn, _ := newTestCommands(t)
+
+	response := dn.handleCommand(ctx, discordgo.ApplicationCommandInteractionData{Name: "status"}, nil)
+	assert.Len(t, response.Embeds, 1)
+	assert.Equal(t, "🟢 **island** 2/70 · TheIsland\n🔴 **ragnarok** offline", response.Embeds[0].Description)
+	assert.Equal(t, &discordgo.MessageAllowedMentions{}, response.AllowedMentions)
+
+	response = dn.handleCommand(ctx, discordgo.ApplicationCommandInteractionData{
+		Name:    "status",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{stringOption("server", "Island")},
+	}, nil)
+	assert.Equal(t, "island", response.Embeds[0].Title)
+	assert.Contains(t, response.Embeds[0].Fields, &discordgo.MessageEmbedField{Name: "Watched players", Value: "Raider"})
+
+	response = dn.handleCommand(ctx, discordgo.ApplicationCommandInteractionData{
+		Name:    "players",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{stringOption("server", "island")},
+	}, nil)
+	assert.Equal(t, "⚠️ **Raider** · 1h30m0s · threat high\nBuilder · 10m0s", response.Embeds[0].Description)
+
+	response = dn.handleCommand(ctx, discordgo.ApplicationCommandInteractionData{
+		Name:    "players",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{stringOption("server", "missing")},
+	}, nil)
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, response.Flags)
+	assert.Equal(t, "server missing not found", response.Content)
+
+	response = dn.handleCommand(ctx, discordgo.ApplicationCommandInteractionData{
+		Name:    "lastseen",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{stringOption("name", "raider")},
+	}, nil)
+	assert.Equal(t, "**Raider** is online on **island** for 1h30m0s.", response.Content)
+
+	response = dn.handleCommand(ctx, discordgo.ApplicationCommandInteractionData{
+		Name:    "lastseen",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{stringOption("name", "scout")},
+	}, nil)
+	assert.Equal(t, "**Scout** was last seen on **island** <t:1714588200:R>.", response.Content)
+
+	response = dn.handleCommand(ctx, discordgo.ApplicationCommandInteractionData{
+		Name:    "history",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{stringOption("server", "island")},
+	}, nil)
+	assert.Equal(t, "<t:1714588200:f> island is offline\n<t:1714588200:f> Scout left island", response.Embeds[0].Description)
+}
+
+func TestWatchCommand(t *testing.T) {
+	ctx := context.Background()
+	dn, _ := newTestCommands(t)
+
+	response := dn.handleCommand(ctx, watchCommand("add", stringOption("name", "Scout")), []string{"200"})
+	assert.Equal(t, "You are not allowed to use this command.", response.Content)
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, response.Flags)
+
+	response = dn.handleCommand(ctx, watchCommand("add", stringOption("name", "Scout"), stringOption("threat", "low")), []string{"200", "100"})
+	assert.Equal(t, "Watching **Scout**.", response.Content)
+
+	response = dn.handleCommand(ctx, watchCommand("add", stringOption("name", "Scout")), []string{"100"})
+	assert.Equal(t, "Scout is already watched", response.Content)
+
+	response = dn.handleCommand(ctx, watchCommand("list"), nil)
+	assert.Equal(t, "Raider · threat high\nScout · threat low", response.Embeds[0].Description)
+
+	response = dn.handleCommand(ctx, watchCommand("remove", stringOption("name", "Raider")), []string{"100"})
+	assert.Equal(t, "Stopped watching **Raider**.", response.Content)
+	entries := dn.blacklist.List(ctx)
+	assert.Len(t, entries, 1)
+	assert.True(t, blacklist.IsLocal(entries[0]), "entries added in Discord are local")
+
+	// without roles the watchlist can't be changed
+	dn.cfg.CommandRoles = map[string][]string{}
+	response = dn.handleCommand(ctx, watchCommand("remove", stringOption("name", "Scout")), []string{"100"})
+	assert.Equal(t, "You are not allowed to use this command.", response.Content)
+	response = dn.handleCommand(ctx, watchCommand("list"), nil)
+	assert.Equal(t, "Scout · threat low", response.Embeds[0].Description)
+
+	dn.cfg.CommandRoles = map[string][]string{"watch.remove": {}}
+	response = dn.handleCommand(ctx, watchCommand("remove", stringOption("name", "Scout")), nil)
+	assert.Equal(t, "Stopped watching **Scout**.", response.Content)
+}
+
+func TestClaimCommands(t *testing.T) {
+	assert.True(t, claimCommands("shared-token"))
+	assert.False(t, claimCommands("shared-token"), "a second instance of the bot doesn't answer commands")
+	assert.True(t, claimCommands("other-token"))
+
+	releaseCommands("shared-token")
+	releaseCommands("other-token")
+	assert.True(t, claimCommands("shared-token"))
+	releaseCommands("shared-token")
+}
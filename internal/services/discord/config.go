@@ -0,0 +1,217 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultStatusInterval = time.Minute
+	// minStatusInterval keeps the edits well below the rate limits.
+	minStatusInterval = 10 * time.Second
+)
+
+// Config of the discord notifier, read from the notification-service
+// section. Either a bot token with a channel or a webhook is required:
+//
+//	discord:
+//	  token: bot-token
+//	  channelID: "123456789012345678"
+//	  statusChannels: ["456789012345678901"]  # pinned live status message
+//	  statusInterval: 1m
+//	  mentions:                               # see MentionRule
+//	    - threatLevels: [high]
+//	      roles: ["345678901234567890"]
+//	  guildID: "234567890123456789"   # registers slash commands instantly
+//	  commandRoles:                    # roles allowed to use a command
+//	    watch: ["345678901234567890"]
+//	  commands: false                  # another instance of the bot answers them
+//
+//	discord:
+//	  webhookURL: https://discord.com/api/webhooks/123/abc
+//	  username: Ark Overseer
+//	  avatarURL: https://example.com/overseer.png
+type Config struct {
+	Token     string
+	ChannelID string
+	// WebhookURL sends messages through a channel webhook, no bot account
+	// or gateway session is needed then.
+	WebhookURL string
+	// Username and AvatarURL override the defaults of the webhook.
+	Username  string
+	AvatarURL string
+	// GuildID registers the slash commands for a single guild, they are
+	// registered globally otherwise, which takes up to an hour.
+	GuildID string
+	// CommandRoles maps commands, or subcommands like "watch.add", to the
+	// role IDs allowed to use them. Commands without roles are open to
+	// everyone in the channel, except for watch.add and watch.remove.
+	CommandRoles map[string][]string
+	// Commands answers slash commands with this instance, defaults to true.
+	// Only one instance per bot may answer them, disable them on the
+	// others.
+	Commands bool
+	// StatusChannels get a pinned message with the status of all servers,
+	// edited every StatusInterval. ChannelID may be left empty then to not
+	// send any player events.
+	StatusChannels []string
+	StatusInterval time.Duration
+	// Mentions ping roles and users on matching events.
+	Mentions []MentionRule
+	// Domain of the overseer UI embeds link to, taken from the -domain
+	// flag instead of the config.
+	Domain string
+}
+
+func ParseConfig(section map[interface{}]interface{}) (Config, error) {
+	cfg := Config{
+		CommandRoles:   make(map[string][]string),
+		Commands:       true,
+		StatusInterval: defaultStatusInterval,
+	}
+
+	stringFields := map[string]*string{
+		"token":      &cfg.Token,
+		"channelID":  &cfg.ChannelID,
+		"guildID":    &cfg.GuildID,
+		"webhookURL": &cfg.WebhookURL,
+		"username":   &cfg.Username,
+		"avatarURL":  &cfg.AvatarURL,
+	}
+	for key, target := range stringFields {
+		value, ok := section[key]
+		if !ok || value == nil {
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			return Config{}, fmt.Errorf("invalid %s type", key)
+		}
+		*target = str
+	}
+
+	if value, ok := section["commandRoles"]; ok && value != nil {
+		commandRoles, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return Config{}, errors.New("invalid commandRoles type")
+		}
+		for key, value := range commandRoles {
+			command, ok := key.(string)
+			if !ok {
+				return Config{}, fmt.Errorf("invalid command %v", key)
+			}
+			roles, err := parseIDs(value)
+			if err != nil {
+				return Config{}, fmt.Errorf("command %s: %w", command, err)
+			}
+			cfg.CommandRoles[command] = roles
+		}
+	}
+
+	if value, ok := section["commands"]; ok && value != nil {
+		commands, ok := value.(bool)
+		if !ok {
+			return Config{}, errors.New("invalid commands type")
+		}
+		cfg.Commands = commands
+	}
+
+	if value, ok := section["mentions"]; ok && value != nil {
+		mentions, err := parseMentions(value)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Mentions = mentions
+	}
+
+	if value, ok := section["statusChannels"]; ok && value != nil {
+		channels, err := parseIDs(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("statusChannels: %w", err)
+		}
+		cfg.StatusChannels = channels
+	}
+
+	if value, ok := section["statusInterval"]; ok && value != nil {
+		str, ok := value.(string)
+		if !ok {
+			return Config{}, errors.New("invalid statusInterval type")
+		}
+		interval, err := time.ParseDuration(str)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid statusInterval: %w", err)
+		}
+		cfg.StatusInterval = interval
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// parseIDs reads a single value or a list, like snowflake IDs, yaml decodes
+// unquoted IDs as int.
+func parseIDs(value interface{}) ([]string, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		list = []interface{}{value}
+	}
+	ids := make([]string, 0, len(list))
+	for _, item := range list {
+		switch id := item.(type) {
+		case string:
+			ids = append(ids, id)
+		case int:
+			ids = append(ids, strconv.Itoa(id))
+		default:
+			return nil, fmt.Errorf("invalid id %v", item)
+		}
+	}
+	return ids, nil
+}
+
+func (c Config) Validate() error {
+	if c.usesWebhook() {
+		if err := validateURL(c.WebhookURL); err != nil {
+			return fmt.Errorf("invalid webhookURL: %w", err)
+		}
+		if c.AvatarURL != "" {
+			if err := validateURL(c.AvatarURL); err != nil {
+				return fmt.Errorf("invalid avatarURL: %w", err)
+			}
+		}
+		if len(c.StatusChannels) > 0 {
+			return errors.New("statusChannels require a token, webhooks can't pin messages")
+		}
+		return nil
+	}
+
+	if c.Token == "" {
+		return errors.New("token or webhookURL required")
+	}
+	if c.ChannelID == "" && len(c.StatusChannels) == 0 {
+		return errors.New("channelID or statusChannels required with token")
+	}
+	if len(c.StatusChannels) > 0 && c.StatusInterval < minStatusInterval {
+		return fmt.Errorf("statusInterval must be at least %s", minStatusInterval)
+	}
+	return nil
+}
+
+// usesWebhook reports whether messages are sent through the webhook
+// instead of the bot session.
+func (c Config) usesWebhook() bool {
+	return c.WebhookURL != ""
+}
+
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	return nil
+}
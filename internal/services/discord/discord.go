@@ -4,23 +4,36 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/led0nk/ark-overseer/internal/blacklist"
+	"github.com/led0nk/ark-overseer/internal/storage"
 	"github.com/led0nk/ark-overseer/pkg/events"
 )
 
 type DiscordNotifier struct {
 	logger    *slog.Logger
-	session   *discordgo.Session
-	token     string
-	channelID string
+	cfg       Config
+	sender    sender
+	sStore    storage.Database
+	blacklist blacklist.Blacklister
+	journal   History
+	board     *statusBoard
+	// commands is set if the instance answers the slash commands of the bot.
+	commands bool
 }
 
-func NewDiscordNotifier(ctx context.Context, token string, channelID string) (*DiscordNotifier, error) {
+// NewDiscordNotifier sends player and server events as embeds, the current
+// map and population of the server are read from sStore. With a bot token the
+// slash commands are answered from sStore, bl and journal.
+func NewDiscordNotifier(ctx context.Context, cfg Config, sStore storage.Database, bl blacklist.Blacklister, journal History) (*DiscordNotifier, error) {
 	discord := &DiscordNotifier{
 		logger:    slog.Default().WithGroup("discord"),
-		token:     token,
-		channelID: channelID,
+		cfg:       cfg,
+		sStore:    sStore,
+		blacklist: bl,
+		journal:   journal,
 	}
 	err := discord.Connect(ctx)
 	if err != nil {
@@ -30,25 +43,47 @@ func NewDiscordNotifier(ctx context.Context, token string, channelID string) (*D
 	return discord, nil
 }
 
+func (dn *DiscordNotifier) Topics() []string {
+	return events.AlertTopics
+}
+
 func (dn *DiscordNotifier) HandleEvent(ctx context.Context, event events.EventMessage) {
+	// only the status message is kept up to date without a channel
+	if !dn.cfg.usesWebhook() && dn.cfg.ChannelID == "" {
+		return
+	}
 	switch event.Type {
-	case "player.joined":
-		msg, ok := event.Payload.(string)
+	case events.TypePlayerJoined, events.TypePlayerLeft:
+		playerEvent, ok := event.PlayerEvent()
 		if !ok {
-			dn.logger.ErrorContext(ctx, "invalid payload type for playerJoined event", "error", errors.New("payload not of type string"))
+			dn.logger.ErrorContext(ctx, "invalid payload type for player event", "error", errors.New("payload not of type PlayerEvent"), "type", event.Type)
 			return
 		}
-		err := dn.Send(ctx, msg)
+		server, err := dn.sStore.GetByID(ctx, playerEvent.ServerID)
+		if err != nil {
+			dn.logger.WarnContext(ctx, "failed to read server", "error", err, "server", playerEvent.ServerID)
+		}
+		content, allowedMentions := mentions(dn.cfg.Mentions, event)
+		err = dn.sendMessage(ctx, &discordgo.MessageSend{
+			Content:         content,
+			Embeds:          []*discordgo.MessageEmbed{playerEmbed(event, playerEvent, server, baseURL(dn.cfg.Domain))},
+			AllowedMentions: allowedMentions,
+		})
 		if err != nil {
 			dn.logger.ErrorContext(ctx, "failed to send message", "error", err)
 		}
-	case "player.left":
-		msg, ok := event.Payload.(string)
+	case events.TypeServerOnline, events.TypeServerOffline:
+		serverEvent, ok := event.ServerEvent()
 		if !ok {
-			dn.logger.ErrorContext(ctx, "invalid payload type for playerLeft event", "error", errors.New("payload not of type string"))
+			dn.logger.ErrorContext(ctx, "invalid payload type for server event", "error", errors.New("payload not of type ServerEvent"), "type", event.Type)
 			return
 		}
-		err := dn.Send(ctx, msg)
+		content, allowedMentions := mentions(dn.cfg.Mentions, event)
+		err := dn.sendMessage(ctx, &discordgo.MessageSend{
+			Content:         content,
+			Embeds:          []*discordgo.MessageEmbed{serverEmbed(event, serverEvent, baseURL(dn.cfg.Domain))},
+			AllowedMentions: allowedMentions,
+		})
 		if err != nil {
 			dn.logger.ErrorContext(ctx, "failed to send message", "error", err)
 		}
@@ -57,25 +92,84 @@ func (dn *DiscordNotifier) HandleEvent(ctx context.Context, event events.EventMe
 	}
 }
 
+func formatPlayerEvent(eventType string, e events.PlayerEvent) string {
+	if eventType == events.TypePlayerLeft {
+		return e.Player + " left the server " + e.ServerName
+	}
+	return e.Player + " joined the server " + e.ServerName
+}
+
+// Connect opens a gateway session with the bot token, or prepares the
+// webhook if one is configured.
 func (dn *DiscordNotifier) Connect(ctx context.Context) error {
-	session, err := discordgo.New(dn.token)
+	if err := dn.cfg.Validate(); err != nil {
+		return err
+	}
+
+	if dn.cfg.usesWebhook() {
+		webhook, err := newWebhookSender(dn.cfg)
+		if err != nil {
+			dn.logger.ErrorContext(ctx, "failed to create webhook", "error", err)
+			return err
+		}
+		dn.sender = webhook
+		return nil
+	}
+
+	// bot tokens have to be sent with the Bot prefix
+	token := dn.cfg.Token
+	if !strings.HasPrefix(token, "Bot ") {
+		token = "Bot " + token
+	}
+	session, err := discordgo.New(token)
 	if err != nil {
 		dn.logger.ErrorContext(ctx, "failed to create session", "error", err)
 		return err
 	}
 
-	dn.session = session
-	err = dn.session.Open()
+	if dn.cfg.Commands {
+		dn.commands = claimCommands(dn.cfg.Token)
+		if !dn.commands {
+			dn.logger.WarnContext(ctx, "commands of the bot are answered by another instance, set commands to false")
+		}
+	}
+	if dn.commands {
+		session.AddHandler(dn.onInteraction)
+	}
+
+	err = session.Open()
 	if err != nil {
 		dn.logger.ErrorContext(ctx, "failed to open session", "error", err)
+		dn.releaseCommands()
 		return err
 	}
 
+	// notifications still work without commands
+	if dn.commands {
+		err = dn.registerCommands(session)
+		if err != nil {
+			dn.logger.ErrorContext(ctx, "failed to register commands", "error", err)
+		}
+	}
+
+	if len(dn.cfg.StatusChannels) > 0 {
+		dn.board = newStatusBoard(session, session.State.User.ID, dn.cfg, dn.statusEmbed)
+		dn.board.start()
+	}
+	dn.sender = &sessionSender{session: session, channelID: dn.cfg.ChannelID}
+
 	return nil
 }
 
+func (dn *DiscordNotifier) releaseCommands() {
+	if dn.commands {
+		releaseCommands(dn.cfg.Token)
+		dn.commands = false
+	}
+}
+
 func (dn *DiscordNotifier) Send(ctx context.Context, message string) error {
-	_, err := dn.session.ChannelMessageSend(dn.channelID, message)
+	err := dn.sendMessage(ctx, &discordgo.MessageSend{Content: message})
 	if err != nil {
 		dn.logger.ErrorContext(ctx, "failed to send discord message", "error", err)
 		return err
@@ -83,6 +177,15 @@ func (dn *DiscordNotifier) Send(ctx context.Context, message string) error {
 	return nil
 }
 
+// sendMessage only pings the roles and users the message allows explicitly,
+// never player names or notes.
+func (dn *DiscordNotifier) sendMessage(ctx context.Context, msg *discordgo.MessageSend) error {
+	if msg.AllowedMentions == nil {
+		msg.AllowedMentions = &discordgo.MessageAllowedMentions{}
+	}
+	return dn.sender.send(ctx, msg)
+}
+
 func (dn *DiscordNotifier) Setup(ctx context.Context, newDN *DiscordNotifier) error {
 	dn = newDN
 
@@ -94,10 +197,16 @@ func (dn *DiscordNotifier) Setup(ctx context.Context, newDN *DiscordNotifier) er
 	return nil
 }
 
+// Disconnect stops the status board and closes the session. The config is
+// kept, events still in flight may read it.
 func (dn *DiscordNotifier) Disconnect() error {
-
-	dn.channelID = ""
-	dn.token = ""
-
-	return dn.session.Close()
+	if dn.board != nil {
+		dn.board.stop()
+		dn.board = nil
+	}
+	dn.releaseCommands()
+	if dn.sender == nil {
+		return nil
+	}
+	return dn.sender.close()
 }
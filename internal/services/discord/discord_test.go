@@ -1,5 +1,19 @@
 package discord
 
+import (
+	"testing"
+
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatPlayerEvent(t *testing.T) {
+	playerEvent := events.PlayerEvent{Player: "Raider", ServerName: "Island"}
+
+	assert.Equal(t, "Raider joined the server Island", formatPlayerEvent(events.TypePlayerJoined, playerEvent))
+	assert.Equal(t, "Raider left the server Island", formatPlayerEvent(events.TypePlayerLeft, playerEvent))
+}
+
 //TODO: setup mock server for discord
 
 //func TestDiscordMessages(t *testing.T) {
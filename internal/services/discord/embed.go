@@ -0,0 +1,134 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+// colors of the embed sidebar per event type.
+var colors = map[string]int{
+	events.TypePlayerJoined:  0xda3633,
+	events.TypePlayerLeft:    0x8b949e,
+	events.TypeServerOnline:  0x238636,
+	events.TypeServerOffline: 0x9e6a03,
+}
+
+// limits Discord puts on embeds.
+const (
+	maxFieldValue  = 1024
+	maxDescription = 4096
+)
+
+// baseURL turns the -domain flag into a link, http is assumed if the
+// domain has no scheme.
+func baseURL(domain string) string {
+	if domain == "" {
+		return ""
+	}
+	if !strings.Contains(domain, "://") {
+		domain = "http://" + domain
+	}
+	return strings.TrimSuffix(domain, "/") + "/"
+}
+
+// playerEmbed describes the player event, server is the current state of
+// the server the player joined or left and may be nil.
+func playerEmbed(event events.EventMessage, e events.PlayerEvent, server *model.Server, link string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:  formatPlayerEvent(event.Type, e),
+		URL:    link,
+		Color:  colors[event.Type],
+		Footer: &discordgo.MessageEmbedFooter{Text: "Ark Overseer"},
+	}
+
+	embed.Fields = append(embed.Fields, serverField(e.ServerName, e.ServerAddr))
+
+	if server != nil && server.ServerInfo != nil {
+		if server.ServerInfo.Map != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Map", Value: server.ServerInfo.Map, Inline: true})
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Population",
+			Value:  fmt.Sprintf("%d/%d", server.ServerInfo.Players, server.ServerInfo.MaxPlayers),
+			Inline: true,
+		})
+	}
+
+	if e.Duration > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Session", Value: e.Duration.Round(time.Second).String(), Inline: true})
+	}
+
+	if e.Entry != nil {
+		if e.Entry.ThreatLevel != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Threat level", Value: e.Entry.ThreatLevel, Inline: true})
+		}
+		if len(e.Entry.Tags) > 0 {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Tags", Value: strings.Join(e.Entry.Tags, ", "), Inline: true})
+		}
+		if e.Entry.Notes != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Notes", Value: truncate(e.Entry.Notes, maxFieldValue)})
+		}
+	}
+
+	timestamp := event.Timestamp
+	if timestamp.IsZero() {
+		timestamp = e.Timestamp
+	}
+	if !timestamp.IsZero() {
+		embed.Timestamp = timestamp.Format(time.RFC3339)
+	}
+	return embed
+}
+
+// serverEmbed describes the server going on- or offline.
+func serverEmbed(event events.EventMessage, e events.ServerEvent, link string) *discordgo.MessageEmbed {
+	title := e.ServerName + " is offline"
+	if event.Type == events.TypeServerOnline {
+		title = e.ServerName + " is online"
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:  title,
+		URL:    link,
+		Color:  colors[event.Type],
+		Footer: &discordgo.MessageEmbedFooter{Text: "Ark Overseer"},
+	}
+
+	embed.Fields = append(embed.Fields, serverField(e.ServerName, e.ServerAddr))
+	if event.Type == events.TypeServerOnline {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Population",
+			Value:  fmt.Sprintf("%d/%d", e.Players, e.MaxPlayers),
+			Inline: true,
+		})
+	}
+
+	timestamp := event.Timestamp
+	if timestamp.IsZero() {
+		timestamp = e.Timestamp
+	}
+	if !timestamp.IsZero() {
+		embed.Timestamp = timestamp.Format(time.RFC3339)
+	}
+	return embed
+}
+
+// serverField names the server, with its address if known.
+func serverField(name, addr string) *discordgo.MessageEmbedField {
+	if addr != "" {
+		name += "\n`" + addr + "`"
+	}
+	return &discordgo.MessageEmbedField{Name: "Server", Value: name, Inline: true}
+}
+
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
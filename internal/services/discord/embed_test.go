@@ -0,0 +1,80 @@
+package discord
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaseURL(t *testing.T) {
+	assert.Equal(t, "http://127.0.0.1/", baseURL("127.0.0.1"))
+	assert.Equal(t, "https://overseer.example.com/", baseURL("https://overseer.example.com/"))
+	assert.Equal(t, "", baseURL(""))
+}
+
+func TestPlayerEmbed(t *testing.T) {
+	timestamp := time.Date(2024, 5, 1, 18, 30, 0, 0, time.UTC)
+	player := events.PlayerEvent{
+		Player:     "Raider",
+		ServerName: "island",
+		ServerAddr: "127.0.0.1:27015",
+		Timestamp:  timestamp,
+		Duration:   90*time.Minute + 400*time.Millisecond,
+		Entry: &model.BlacklistPlayers{
+			Name:        "Raider",
+			ThreatLevel: "high",
+			Tags:        []string{"alpha", "pvp"},
+			Notes:       "main base at the volcano",
+		},
+	}
+	server := &model.Server{ServerInfo: &model.ServerInfo{Map: "TheIsland", Players: 12, MaxPlayers: 70}}
+
+	embed := playerEmbed(events.EventMessage{Type: events.TypePlayerLeft, Payload: player}, player, server, "http://127.0.0.1/")
+	assert.Equal(t, "Raider left the server island", embed.Title)
+	assert.Equal(t, "http://127.0.0.1/", embed.URL)
+	assert.Equal(t, colors[events.TypePlayerLeft], embed.Color)
+	assert.Equal(t, "2024-05-01T18:30:00Z", embed.Timestamp, "the player timestamp is used as fallback")
+	assert.Equal(t, []*discordgo.MessageEmbedField{
+		{Name: "Server", Value: "island\n`127.0.0.1:27015`", Inline: true},
+		{Name: "Map", Value: "TheIsland", Inline: true},
+		{Name: "Population", Value: "12/70", Inline: true},
+		{Name: "Session", Value: "1h30m0s", Inline: true},
+		{Name: "Threat level", Value: "high", Inline: true},
+		{Name: "Tags", Value: "alpha, pvp", Inline: true},
+		{Name: "Notes", Value: "main base at the volcano"},
+	}, embed.Fields)
+
+	joined := events.PlayerEvent{Player: "Scout", ServerName: "island"}
+	embed = playerEmbed(events.EventMessage{Type: events.TypePlayerJoined, Payload: joined}, joined, nil, "")
+	assert.Equal(t, colors[events.TypePlayerJoined], embed.Color)
+	assert.Empty(t, embed.URL)
+	assert.Empty(t, embed.Timestamp)
+	assert.Equal(t, []*discordgo.MessageEmbedField{{Name: "Server", Value: "island", Inline: true}}, embed.Fields)
+}
+
+func TestServerEmbed(t *testing.T) {
+	online := events.ServerEvent{ServerName: "island", ServerAddr: "127.0.0.1:27015", Online: true, Players: 3, MaxPlayers: 70}
+	embed := serverEmbed(events.EventMessage{Type: events.TypeServerOnline, Payload: online}, online, "http://127.0.0.1/")
+	assert.Equal(t, "island is online", embed.Title)
+	assert.Equal(t, colors[events.TypeServerOnline], embed.Color)
+	assert.Equal(t, []*discordgo.MessageEmbedField{
+		{Name: "Server", Value: "island\n`127.0.0.1:27015`", Inline: true},
+		{Name: "Population", Value: "3/70", Inline: true},
+	}, embed.Fields)
+
+	offline := events.ServerEvent{ServerName: "island", Timestamp: time.Date(2024, 5, 1, 18, 30, 0, 0, time.UTC)}
+	embed = serverEmbed(events.EventMessage{Type: events.TypeServerOffline, Payload: offline}, offline, "")
+	assert.Equal(t, "island is offline", embed.Title)
+	assert.Equal(t, colors[events.TypeServerOffline], embed.Color)
+	assert.Equal(t, "2024-05-01T18:30:00Z", embed.Timestamp)
+	assert.Equal(t, []*discordgo.MessageEmbedField{{Name: "Server", Value: "island", Inline: true}}, embed.Fields)
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "äbc…", truncate("äbcdef", 4))
+}
@@ -0,0 +1,147 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+// MentionRule pings roles and users on matching events. Empty conditions
+// match everything, a rule matches if all of its conditions do.
+type MentionRule struct {
+	// Events are event type patterns, see events.MatchTopic.
+	Events []string
+	// ThreatLevels and Tags match the watchlist entry of player events.
+	ThreatLevels []string
+	Tags         []string
+	// Servers match the name or cluster of the server.
+	Servers []string
+	Roles   []string
+	Users   []string
+}
+
+// parseMentions reads the mention rules:
+//
+//	mentions:
+//	  - threatLevels: [high]
+//	    events: [player.joined]
+//	    roles: ["345678901234567890"]
+//	  - servers: [island]
+//	    tags: [alpha]
+//	    users: ["456789012345678901"]
+func parseMentions(value interface{}) ([]MentionRule, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("invalid mentions type")
+	}
+	rules := make([]MentionRule, 0, len(list))
+	for i, item := range list {
+		section, ok := item.(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("mention %d: invalid type", i+1)
+		}
+		var rule MentionRule
+		fields := map[string]*[]string{
+			"events":       &rule.Events,
+			"threatLevels": &rule.ThreatLevels,
+			"tags":         &rule.Tags,
+			"servers":      &rule.Servers,
+			"roles":        &rule.Roles,
+			"users":        &rule.Users,
+		}
+		for key, target := range fields {
+			value, ok := section[key]
+			if !ok || value == nil {
+				continue
+			}
+			values, err := parseIDs(value)
+			if err != nil {
+				return nil, fmt.Errorf("mention %d: %s: %w", i+1, key, err)
+			}
+			*target = values
+		}
+		for _, id := range slices.Concat(rule.Roles, rule.Users) {
+			if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+				return nil, fmt.Errorf("mention %d: invalid id %q", i+1, id)
+			}
+		}
+		if len(rule.Roles) == 0 && len(rule.Users) == 0 {
+			return nil, fmt.Errorf("mention %d: roles or users required", i+1)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (r MentionRule) matches(event events.EventMessage) bool {
+	if len(r.Events) > 0 && !slices.ContainsFunc(r.Events, func(pattern string) bool {
+		return events.MatchTopic(pattern, event.Type)
+	}) {
+		return false
+	}
+
+	var serverName, cluster, threatLevel string
+	var tags []string
+	if player, ok := event.PlayerEvent(); ok {
+		serverName, cluster = player.ServerName, player.Cluster
+		if player.Entry != nil {
+			threatLevel, tags = player.Entry.ThreatLevel, player.Entry.Tags
+		}
+	} else if server, ok := event.ServerEvent(); ok {
+		serverName, cluster = server.ServerName, server.Cluster
+	}
+
+	if len(r.Servers) > 0 && !containsFold(r.Servers, serverName) && !containsFold(r.Servers, cluster) {
+		return false
+	}
+	if len(r.ThreatLevels) > 0 && !containsFold(r.ThreatLevels, threatLevel) {
+		return false
+	}
+	if len(r.Tags) > 0 && !slices.ContainsFunc(tags, func(tag string) bool { return containsFold(r.Tags, tag) }) {
+		return false
+	}
+	return true
+}
+
+func containsFold(list []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	return slices.ContainsFunc(list, func(item string) bool { return strings.EqualFold(item, value) })
+}
+
+// mentions returns the content pinging the roles and users of all matching
+// rules, and allowed mentions limited to exactly those. Nobody else can be
+// pinged, even if player names or notes contain mentions.
+func mentions(rules []MentionRule, event events.EventMessage) (string, *discordgo.MessageAllowedMentions) {
+	allowed := &discordgo.MessageAllowedMentions{}
+	for _, rule := range rules {
+		if !rule.matches(event) {
+			continue
+		}
+		for _, role := range rule.Roles {
+			if !slices.Contains(allowed.Roles, role) {
+				allowed.Roles = append(allowed.Roles, role)
+			}
+		}
+		for _, user := range rule.Users {
+			if !slices.Contains(allowed.Users, user) {
+				allowed.Users = append(allowed.Users, user)
+			}
+		}
+	}
+
+	pings := make([]string, 0, len(allowed.Roles)+len(allowed.Users))
+	for _, role := range allowed.Roles {
+		pings = append(pings, "<@&"+role+">")
+	}
+	for _, user := range allowed.Users {
+		pings = append(pings, "<@"+user+">")
+	}
+	return strings.Join(pings, " "), allowed
+}
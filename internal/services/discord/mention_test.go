@@ -0,0 +1,90 @@
+package discord
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMentions(t *testing.T) {
+	rules, err := parseMentions([]interface{}{
+		map[interface{}]interface{}{
+			"events":       []interface{}{"player.joined"},
+			"threatLevels": "high",
+			"roles":        []interface{}{111},
+		},
+		map[interface{}]interface{}{
+			"servers": []interface{}{"pvp-cluster"},
+			"tags":    []interface{}{"alpha"},
+			"roles":   []interface{}{"111"},
+			"users":   []interface{}{"222"},
+		},
+	})
+	assert.NoError(t, err)
+
+	player := func(eventType, threatLevel string, tags ...string) events.EventMessage {
+		return events.EventMessage{Type: eventType, Payload: events.PlayerEvent{
+			Player:     "Raider",
+			ServerName: "island",
+			Cluster:    "pvp-cluster",
+			Entry:      &model.BlacklistPlayers{Name: "Raider", ThreatLevel: threatLevel, Tags: tags},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		event    events.EventMessage
+		content  string
+		expected *discordgo.MessageAllowedMentions
+	}{
+		{
+			name:     "high threat raider",
+			event:    player(events.TypePlayerJoined, "High"),
+			content:  "<@&111>",
+			expected: &discordgo.MessageAllowedMentions{Roles: []string{"111"}},
+		},
+		{
+			name:     "low threat scout",
+			event:    player(events.TypePlayerJoined, "low"),
+			expected: &discordgo.MessageAllowedMentions{},
+		},
+		{
+			name:     "high threat leaving",
+			event:    player(events.TypePlayerLeft, "high"),
+			expected: &discordgo.MessageAllowedMentions{},
+		},
+		{
+			name:     "tagged on cluster",
+			event:    player(events.TypePlayerJoined, "high", "alpha"),
+			content:  "<@&111> <@222>",
+			expected: &discordgo.MessageAllowedMentions{Roles: []string{"111"}, Users: []string{"222"}},
+		},
+		{
+			name:     "server event",
+			event:    events.EventMessage{Type: events.TypeServerOffline, Payload: events.ServerEvent{ServerName: "island"}},
+			expected: &discordgo.MessageAllowedMentions{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, allowed := mentions(rules, tt.event)
+			assert.Equal(t, tt.content, content)
+			assert.Equal(t, tt.expected, allowed)
+		})
+	}
+}
+
+func TestParseMentions(t *testing.T) {
+	_, err := parseMentions([]interface{}{map[interface{}]interface{}{"events": "player.*"}})
+	assert.EqualError(t, err, "mention 1: roles or users required")
+
+	_, err = parseMentions([]interface{}{map[interface{}]interface{}{"roles": "everyone"}})
+	assert.EqualError(t, err, `mention 1: invalid id "everyone"`)
+
+	_, err = parseMentions(map[interface{}]interface{}{"roles": "111"})
+	assert.Error(t, err)
+}
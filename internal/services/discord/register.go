@@ -0,0 +1,30 @@
+package discord
+
+import (
+	"context"
+
+	"github.com/led0nk/ark-overseer/internal/services/registry"
+)
+
+func init() {
+	registry.Register("discord", registry.Definition[Config]{
+		Label: "Discord",
+		Fields: []registry.Field{
+			{Key: "token", Label: "Token", Placeholder: "Discord token...", Type: registry.FieldSecret},
+			{Key: "channelID", Label: "Channel-ID", Placeholder: "Channel-ID...", Type: registry.FieldText},
+			{Key: "guildID", Label: "Guild-ID", Placeholder: "Guild-ID for slash commands (all guilds if empty)", Type: registry.FieldText},
+			{Key: "webhookURL", Label: "Webhook-URL", Placeholder: "https://discord.com/api/webhooks/... (instead of a bot)", Type: registry.FieldSecret},
+			{Key: "username", Label: "Webhook-Username", Placeholder: "Ark Overseer", Type: registry.FieldText},
+			{Key: "avatarURL", Label: "Webhook-Avatar", Placeholder: "https://example.com/avatar.png", Type: registry.FieldText},
+		},
+		Parse: ParseConfig,
+		New: func(ctx context.Context, cfg Config, deps registry.Deps) (registry.Notification, error) {
+			cfg.Domain = deps.Domain
+			notifier, err := NewDiscordNotifier(ctx, cfg, deps.Storage, deps.Blacklist, deps.Events)
+			if err != nil {
+				return nil, err
+			}
+			return notifier, nil
+		},
+	})
+}
@@ -0,0 +1,266 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/led0nk/ark-overseer/internal/services/retry"
+)
+
+// statusTitle identifies the status message among the pinned messages, so it
+// is reused after a restart.
+const statusTitle = "Server status"
+
+// statusSession is the part of the discordgo session the status board uses.
+type statusSession interface {
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageEditComplex(m *discordgo.MessageEdit, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessagePin(channelID, messageID string, options ...discordgo.RequestOption) error
+	ChannelMessagesPinned(channelID string, options ...discordgo.RequestOption) ([]*discordgo.Message, error)
+}
+
+// statusBoard keeps one pinned message per channel up to date.
+type statusBoard struct {
+	logger   *slog.Logger
+	session  statusSession
+	userID   string
+	channels []string
+	interval time.Duration
+	render   func(context.Context) (*discordgo.MessageEmbed, error)
+
+	// messages maps the channels to their status message.
+	messages map[string]string
+	// last is the embed that was sent to the channel, unchanged embeds
+	// aren't edited to save requests.
+	last map[string]*discordgo.MessageEmbed
+	// retryAt delays channels that were rate limited, requests aren't
+	// retried to not hold up the other channels.
+	retryAt map[string]time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newStatusBoard(session statusSession, userID string, cfg Config, render func(context.Context) (*discordgo.MessageEmbed, error)) *statusBoard {
+	return &statusBoard{
+		logger:   slog.Default().WithGroup("discord"),
+		session:  session,
+		userID:   userID,
+		channels: cfg.StatusChannels,
+		interval: cfg.StatusInterval,
+		render:   render,
+		messages: make(map[string]string),
+		last:     make(map[string]*discordgo.MessageEmbed),
+		retryAt:  make(map[string]time.Time),
+	}
+}
+
+// start updates the messages right away and then every interval until stop
+// is called.
+func (b *statusBoard) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+		for {
+			b.update(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (b *statusBoard) stop() {
+	if b.cancel != nil {
+		b.cancel()
+	}
+	b.wg.Wait()
+}
+
+func (b *statusBoard) update(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, retry.Timeout)
+	defer cancel()
+
+	embed, err := b.render(ctx)
+	if err != nil {
+		b.logger.ErrorContext(ctx, "failed to render status", "error", err)
+		return
+	}
+	for _, channel := range b.channels {
+		if time.Now().Before(b.retryAt[channel]) {
+			continue
+		}
+		err := b.updateChannel(ctx, channel, embed)
+		var rateLimit *discordgo.RateLimitError
+		if errors.As(err, &rateLimit) {
+			b.retryAt[channel] = time.Now().Add(rateLimit.RetryAfter)
+		}
+		if err != nil {
+			b.logger.WarnContext(ctx, "failed to update status message", "error", err, "channel", channel)
+		}
+	}
+}
+
+// updateChannel edits the status message of the channel, it is looked up
+// among the pinned messages or created if there is none or it was deleted.
+func (b *statusBoard) updateChannel(ctx context.Context, channel string, embed *discordgo.MessageEmbed) error {
+	messageID, ok := b.messages[channel]
+	if !ok {
+		var err error
+		messageID, err = b.findMessage(ctx, channel)
+		if err != nil {
+			return err
+		}
+	}
+
+	if messageID != "" {
+		if reflect.DeepEqual(b.last[channel], embed) {
+			return nil
+		}
+		embeds := []*discordgo.MessageEmbed{embed}
+		_, err := b.session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:              messageID,
+			Channel:         channel,
+			Embeds:          &embeds,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		}, discordgo.WithContext(ctx), discordgo.WithRetryOnRatelimit(false))
+		if err == nil {
+			b.messages[channel] = messageID
+			b.last[channel] = embed
+			return nil
+		}
+		if !unknownMessage(err) {
+			return err
+		}
+		b.logger.InfoContext(ctx, "status message was deleted, recreating it", "channel", channel)
+	}
+
+	delete(b.messages, channel)
+	delete(b.last, channel)
+	message, err := b.session.ChannelMessageSendComplex(channel, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}, discordgo.WithContext(ctx), discordgo.WithRetryOnRatelimit(false))
+	if err != nil {
+		return err
+	}
+	b.messages[channel] = message.ID
+	b.last[channel] = embed
+
+	err = b.session.ChannelMessagePin(channel, message.ID, discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to pin status message: %w", err)
+	}
+	return nil
+}
+
+// findMessage returns the pinned status message of the bot, or an empty ID.
+func (b *statusBoard) findMessage(ctx context.Context, channel string) (string, error) {
+	pinned, err := b.session.ChannelMessagesPinned(channel, discordgo.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	for _, message := range pinned {
+		if message.Author == nil || message.Author.ID != b.userID {
+			continue
+		}
+		for _, embed := range message.Embeds {
+			if embed.Title == statusTitle {
+				return message.ID, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func unknownMessage(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) {
+		return false
+	}
+	if restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownMessage {
+		return true
+	}
+	return restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
+
+// statusEmbed renders a table with the status, population and watched players
+// of all servers.
+func (dn *DiscordNotifier) statusEmbed(ctx context.Context) (*discordgo.MessageEmbed, error) {
+	servers, err := dn.sortedServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{{"Server", "Status", "Players", "Watched"}}
+	for _, server := range servers {
+		rows = append(rows, []string{
+			server.Name,
+			statusText(server),
+			population(server),
+			strings.Join(dn.watchedOnline(ctx, server), ", "),
+		})
+	}
+	return &discordgo.MessageEmbed{
+		Title:       statusTitle,
+		URL:         baseURL(dn.cfg.Domain),
+		Description: "```\n" + truncate(table(rows), maxDescription-8) + "\n```",
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Ark Overseer · updated every " + dn.cfg.StatusInterval.String()},
+	}, nil
+}
+
+func statusText(server *model.Server) string {
+	if server.Status {
+		return "online"
+	}
+	return "offline"
+}
+
+func population(server *model.Server) string {
+	if !server.Status || server.ServerInfo == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d", server.ServerInfo.Players, server.ServerInfo.MaxPlayers)
+}
+
+// table aligns the columns of the rows, the last column isn't padded.
+func table(rows [][]string) string {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			line.WriteString(cell)
+			if i < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[i]-len([]rune(cell))+2))
+			}
+		}
+		lines = append(lines, strings.TrimRight(line.String(), " "))
+	}
+	return strings.Join(lines, "\n")
+}
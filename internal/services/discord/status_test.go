@@ -0,0 +1,125 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+type testSession struct {
+	messages  map[string]*discordgo.Message
+	pinned    []string
+	sent      int
+	edits     int
+	rateLimit time.Duration
+}
+
+func newTestSession() *testSession {
+	return &testSession{messages: make(map[string]*discordgo.Message)}
+}
+
+func (s *testSession) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	s.sent++
+	message := &discordgo.Message{
+		ID:        fmt.Sprint(s.sent),
+		ChannelID: channelID,
+		Author:    &discordgo.User{ID: "bot"},
+		Embeds:    data.Embeds,
+	}
+	s.messages[message.ID] = message
+	return message, nil
+}
+
+func (s *testSession) ChannelMessageEditComplex(m *discordgo.MessageEdit, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	if s.rateLimit > 0 {
+		return nil, &discordgo.RateLimitError{RateLimit: &discordgo.RateLimit{TooManyRequests: &discordgo.TooManyRequests{RetryAfter: s.rateLimit}}}
+	}
+	message, ok := s.messages[m.ID]
+	if !ok {
+		return nil, &discordgo.RESTError{
+			Response: &http.Response{StatusCode: http.StatusNotFound},
+			Message:  &discordgo.APIErrorMessage{Code: discordgo.ErrCodeUnknownMessage, Message: "Unknown Message"},
+		}
+	}
+	s.edits++
+	message.Embeds = *m.Embeds
+	return message, nil
+}
+
+func (s *testSession) ChannelMessagePin(channelID, messageID string, options ...discordgo.RequestOption) error {
+	s.pinned = append(s.pinned, messageID)
+	return nil
+}
+
+func (s *testSession) ChannelMessagesPinned(channelID string, options ...discordgo.RequestOption) ([]*discordgo.Message, error) {
+	var pinned []*discordgo.Message
+	for _, id := range s.pinned {
+		if message, ok := s.messages[id]; ok {
+			pinned = append(pinned, message)
+		}
+	}
+	return pinned, nil
+}
+
+func TestStatusBoard(t *testing.T) {
+	ctx := context.Background()
+	session := newTestSession()
+	population := "1/70"
+	render := func(context.Context) (*discordgo.MessageEmbed, error) {
+		return &discordgo.MessageEmbed{Title: statusTitle, Description: population}, nil
+	}
+	cfg := Config{StatusChannels: []string{"123"}, StatusInterval: time.Minute}
+
+	board := newStatusBoard(session, "bot", cfg, render)
+	board.update(ctx)
+	assert.Equal(t, 1, session.sent)
+	assert.Equal(t, []string{"1"}, session.pinned)
+
+	board.update(ctx)
+	assert.Equal(t, 0, session.edits, "unchanged status isn't edited")
+
+	population = "2/70"
+	board.update(ctx)
+	assert.Equal(t, 1, session.edits)
+	assert.Equal(t, "2/70", session.messages["1"].Embeds[0].Description)
+
+	// a restart reuses the pinned message
+	board = newStatusBoard(session, "bot", cfg, render)
+	population = "3/70"
+	board.update(ctx)
+	assert.Equal(t, 1, session.sent)
+	assert.Equal(t, 2, session.edits)
+
+	session.rateLimit = time.Hour
+	population = "4/70"
+	board.update(ctx)
+	session.rateLimit = 0
+	board.update(ctx)
+	assert.Equal(t, 2, session.edits, "rate limited channels wait")
+	board.retryAt["123"] = time.Time{}
+
+	delete(session.messages, "1")
+	board.update(ctx)
+	assert.Equal(t, 2, session.sent, "deleted message is recreated")
+	assert.Equal(t, []string{"1", "2"}, session.pinned)
+	assert.Equal(t, "4/70", session.messages["2"].Embeds[0].Description)
+}
+
+func TestStatusEmbed(t *testing.T) {
+	dn, _ := newTestCommands(t)
+	dn.cfg.StatusInterval = time.Minute
+
+	embed, err := dn.statusEmbed(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, statusTitle, embed.Title)
+	assert.Equal(t, "```\n"+
+		"Server    Status   Players  Watched\n"+
+		"island    online   2/70     Raider\n"+
+		"ragnarok  offline  -\n"+
+		"```", embed.Description)
+}
@@ -0,0 +1,123 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/led0nk/ark-overseer/internal/services/retry"
+)
+
+// sender delivers messages either through the bot session or a webhook.
+type sender interface {
+	send(ctx context.Context, msg *discordgo.MessageSend) error
+	close() error
+}
+
+type sessionSender struct {
+	session   *discordgo.Session
+	channelID string
+}
+
+func (s *sessionSender) send(ctx context.Context, msg *discordgo.MessageSend) error {
+	_, err := s.session.ChannelMessageSendComplex(s.channelID, msg, discordgo.WithContext(ctx))
+	return err
+}
+
+func (s *sessionSender) close() error {
+	return s.session.Close()
+}
+
+// webhookSender executes a channel webhook, it needs neither a bot account
+// nor a gateway session.
+type webhookSender struct {
+	client    *http.Client
+	url       string
+	username  string
+	avatarURL string
+}
+
+func newWebhookSender(cfg Config) (*webhookSender, error) {
+	u, err := url.Parse(cfg.WebhookURL)
+	if err != nil {
+		return nil, err
+	}
+	// wait for the message to be created, errors are reported otherwise
+	query := u.Query()
+	query.Set("wait", "true")
+	u.RawQuery = query.Encode()
+
+	return &webhookSender{
+		client:    &http.Client{Timeout: retry.Timeout},
+		url:       u.String(),
+		username:  cfg.Username,
+		avatarURL: cfg.AvatarURL,
+	}, nil
+}
+
+func (s *webhookSender) send(ctx context.Context, msg *discordgo.MessageSend) error {
+	body, err := json.Marshal(discordgo.WebhookParams{
+		Content:         msg.Content,
+		Username:        s.username,
+		AvatarURL:       s.avatarURL,
+		Embeds:          msg.Embeds,
+		AllowedMentions: msg.AllowedMentions,
+	})
+	if err != nil {
+		return err
+	}
+
+	return retry.Do(ctx, func() (time.Duration, error) {
+		return s.do(ctx, body)
+	})
+}
+
+// do executes the webhook once, retryAfter is set if it was rate limited.
+func (s *webhookSender) do(ctx context.Context, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		// the url contains the webhook token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNoContent {
+		return 0, nil
+	}
+
+	var result struct {
+		Message    string  `json:"message"`
+		RetryAfter float64 `json:"retry_after"`
+	}
+	_ = json.Unmarshal(data, &result)
+	err = fmt.Errorf("unexpected status %s: %s", resp.Status, result.Message)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return max(time.Duration(result.RetryAfter*float64(time.Second)), 100*time.Millisecond), err
+	}
+	return 0, err
+}
+
+func (s *webhookSender) close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
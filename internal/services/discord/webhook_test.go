@@ -0,0 +1,191 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/led0nk/ark-overseer/internal/storage"
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// testWebhook stands in for a Discord channel webhook.
+type testWebhook struct {
+	*httptest.Server
+	mu          sync.Mutex
+	messages    []discordgo.WebhookParams
+	rateLimited int
+}
+
+func newTestWebhook(t *testing.T) *testWebhook {
+	hook := &testWebhook{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/webhooks/123/token", func(w http.ResponseWriter, r *http.Request) {
+		hook.mu.Lock()
+		defer hook.mu.Unlock()
+		if r.URL.Query().Get("wait") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if hook.rateLimited > 0 {
+			hook.rateLimited--
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"message":"You are being rate limited.","retry_after":0.01,"global":false}`))
+			return
+		}
+		var params discordgo.WebhookParams
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		hook.messages = append(hook.messages, params)
+		_, _ = w.Write([]byte(`{"id":"1"}`))
+	})
+	hook.Server = httptest.NewServer(mux)
+	t.Cleanup(hook.Close)
+	return hook
+}
+
+func (h *testWebhook) received() []discordgo.WebhookParams {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]discordgo.WebhookParams(nil), h.messages...)
+}
+
+func TestDiscordWebhook(t *testing.T) {
+	ctx := context.Background()
+	hook := newTestWebhook(t)
+
+	cfg, err := ParseConfig(map[interface{}]interface{}{
+		"webhookURL": hook.URL + "/api/webhooks/123/token",
+		"username":   "Ark Overseer",
+		"avatarURL":  "https://example.com/overseer.png",
+	})
+	assert.NoError(t, err)
+	sStore, err := storage.NewServerStorage(ctx, filepath.Join(t.TempDir(), "cluster.json"))
+	assert.NoError(t, err)
+	notifier, err := NewDiscordNotifier(ctx, cfg, sStore, nil, nil)
+	assert.NoError(t, err)
+
+	hook.mu.Lock()
+	hook.rateLimited = 1
+	hook.mu.Unlock()
+	notifier.HandleEvent(ctx, events.EventMessage{
+		Type:      events.TypePlayerJoined,
+		Timestamp: time.Date(2024, 5, 1, 18, 30, 0, 0, time.UTC),
+		Payload:   events.PlayerEvent{Player: "@everyone", ServerName: "island"},
+	})
+	assert.NoError(t, notifier.Send(ctx, "hello"))
+
+	received := hook.received()
+	assert.Len(t, received, 2)
+	assert.Equal(t, "Ark Overseer", received[0].Username)
+	assert.Equal(t, "https://example.com/overseer.png", received[0].AvatarURL)
+	assert.Len(t, received[0].Embeds, 1)
+	assert.Equal(t, "@everyone joined the server island", received[0].Embeds[0].Title)
+	assert.Equal(t, "2024-05-01T18:30:00Z", received[0].Embeds[0].Timestamp)
+	assert.NotNil(t, received[0].AllowedMentions)
+	assert.Empty(t, received[0].AllowedMentions.Parse, "nobody is pinged")
+	assert.Equal(t, "hello", received[1].Content)
+	assert.NoError(t, notifier.Disconnect())
+
+	notifier, err = NewDiscordNotifier(ctx, Config{WebhookURL: hook.URL + "/api/webhooks/123/wrong"}, sStore, nil, nil)
+	assert.NoError(t, err)
+	assert.Error(t, notifier.Send(ctx, "hello"))
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		section   map[interface{}]interface{}
+		expected  Config
+		expectErr bool
+	}{
+		{
+			name:     "bot",
+			section:  map[interface{}]interface{}{"token": "bot-token", "channelID": "123"},
+			expected: Config{Token: "bot-token", ChannelID: "123", CommandRoles: map[string][]string{}, Commands: true, StatusInterval: time.Minute},
+		},
+		{
+			name:     "webhook",
+			section:  map[interface{}]interface{}{"webhookURL": "https://discord.com/api/webhooks/123/abc", "token": ""},
+			expected: Config{WebhookURL: "https://discord.com/api/webhooks/123/abc", CommandRoles: map[string][]string{}, Commands: true, StatusInterval: time.Minute},
+		},
+		{
+			name: "command roles",
+			section: map[interface{}]interface{}{
+				"token":        "bot-token",
+				"channelID":    "123",
+				"guildID":      "456",
+				"commandRoles": map[interface{}]interface{}{"watch": []interface{}{789, "1011"}, "status": "1213"},
+				"commands":     false,
+			},
+			expected: Config{
+				Token:          "bot-token",
+				ChannelID:      "123",
+				GuildID:        "456",
+				CommandRoles:   map[string][]string{"watch": {"789", "1011"}, "status": {"1213"}},
+				StatusInterval: time.Minute,
+			},
+		},
+		{
+			name: "status channels",
+			section: map[interface{}]interface{}{
+				"token":          "bot-token",
+				"statusChannels": []interface{}{"123", 456},
+				"statusInterval": "30s",
+			},
+			expected: Config{
+				Token:          "bot-token",
+				CommandRoles:   map[string][]string{},
+				Commands:       true,
+				StatusChannels: []string{"123", "456"},
+				StatusInterval: 30 * time.Second,
+			},
+		},
+		{
+			name:      "status interval too short",
+			section:   map[interface{}]interface{}{"token": "bot-token", "statusChannels": "123", "statusInterval": "1s"},
+			expectErr: true,
+		},
+		{
+			name:      "status with webhook",
+			section:   map[interface{}]interface{}{"webhookURL": "https://discord.com/api/webhooks/123/abc", "statusChannels": "123"},
+			expectErr: true,
+		},
+		{
+			name:      "token without channel",
+			section:   map[interface{}]interface{}{"token": "bot-token"},
+			expectErr: true,
+		},
+		{
+			name:      "empty",
+			section:   map[interface{}]interface{}{"token": "", "channelID": ""},
+			expectErr: true,
+		},
+		{
+			name:      "invalid webhook",
+			section:   map[interface{}]interface{}{"webhookURL": "discord.com/api/webhooks/123/abc"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig(tt.section)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, cfg)
+		})
+	}
+}
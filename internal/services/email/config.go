@@ -0,0 +1,167 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+)
+
+const (
+	SecurityStartTLS = "starttls"
+	SecurityTLS      = "tls"
+	SecurityNone     = "none"
+
+	defaultSubjectPrefix = "[ark-overseer]"
+)
+
+// Config of the email notifier, read from the notification-service section:
+//
+//	email:
+//	  host: smtp.example.com
+//	  port: 587
+//	  security: starttls   # tls for implicit TLS, usually port 465
+//	  username: overseer@example.com
+//	  password: secret
+//	  from: Ark Overseer <overseer@example.com>
+//	  to:
+//	    - admin@example.com
+//	  digest: 15m          # collect events, 0 mails every event
+type Config struct {
+	Host     string
+	Port     int
+	Security string
+	Username string
+	Password string
+	From     string
+	To       []string
+	// Digest is the interval summaries are sent at, events are mailed
+	// one by one if it is 0. Collected events are only kept in memory, a
+	// crash loses them.
+	Digest        time.Duration
+	SubjectPrefix string
+	// Insecure skips the verification of the server certificate.
+	Insecure bool
+}
+
+func ParseConfig(section map[interface{}]interface{}) (Config, error) {
+	cfg := Config{
+		Security:      SecurityStartTLS,
+		SubjectPrefix: defaultSubjectPrefix,
+	}
+
+	stringFields := map[string]*string{
+		"host":          &cfg.Host,
+		"security":      &cfg.Security,
+		"username":      &cfg.Username,
+		"password":      &cfg.Password,
+		"from":          &cfg.From,
+		"subjectPrefix": &cfg.SubjectPrefix,
+	}
+	for key, target := range stringFields {
+		value, ok := section[key]
+		if !ok || value == nil {
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			return Config{}, fmt.Errorf("invalid %s type", key)
+		}
+		if str != "" {
+			*target = str
+		}
+	}
+
+	if value, ok := section["port"]; ok && value != nil {
+		port, ok := value.(int)
+		if !ok {
+			return Config{}, errors.New("invalid port type")
+		}
+		cfg.Port = port
+	}
+	if cfg.Port == 0 {
+		cfg.Port = defaultPort(cfg.Security)
+	}
+
+	if value, ok := section["to"]; ok && value != nil {
+		switch to := value.(type) {
+		case string:
+			cfg.To = []string{to}
+		case []interface{}:
+			for _, item := range to {
+				address, ok := item.(string)
+				if !ok {
+					return Config{}, fmt.Errorf("invalid recipient %v", item)
+				}
+				cfg.To = append(cfg.To, address)
+			}
+		default:
+			return Config{}, errors.New("invalid to type")
+		}
+	}
+
+	if value, ok := section["digest"]; ok && value != nil {
+		str, ok := value.(string)
+		if !ok {
+			return Config{}, errors.New("invalid digest type")
+		}
+		digest, err := time.ParseDuration(str)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid digest: %w", err)
+		}
+		cfg.Digest = digest
+	}
+
+	if value, ok := section["insecure"]; ok && value != nil {
+		insecure, ok := value.(bool)
+		if !ok {
+			return Config{}, errors.New("invalid insecure type")
+		}
+		cfg.Insecure = insecure
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func defaultPort(security string) int {
+	switch security {
+	case SecurityTLS:
+		return 465
+	case SecurityNone:
+		return 25
+	default:
+		return 587
+	}
+}
+
+func (c Config) Validate() error {
+	if c.Host == "" {
+		return errors.New("host required")
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("invalid port %d", c.Port)
+	}
+	switch c.Security {
+	case SecurityStartTLS, SecurityTLS, SecurityNone:
+	default:
+		return fmt.Errorf("unsupported security %q", c.Security)
+	}
+	if c.Password != "" && c.Username == "" {
+		return errors.New("username required with password")
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+	if len(c.To) == 0 {
+		return errors.New("at least one recipient required")
+	}
+	for _, to := range c.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", to, err)
+		}
+	}
+	if c.Digest < 0 {
+		return errors.New("digest must not be negative")
+	}
+	return nil
+}
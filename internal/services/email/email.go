@@ -0,0 +1,251 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+const (
+	timeout = 30 * time.Second
+	// maxDigest bounds the events kept while the server is unreachable.
+	maxDigest = 1000
+)
+
+type EmailNotifier struct {
+	cfg    Config
+	logger *slog.Logger
+	mu     sync.Mutex
+	// collected holds the events of the next digest.
+	collected []events.EventMessage
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewEmailNotifier checks the connection to the SMTP server and, in digest
+// mode, starts sending summaries every cfg.Digest.
+func NewEmailNotifier(ctx context.Context, cfg Config) (*EmailNotifier, error) {
+	email := &EmailNotifier{
+		cfg:    cfg,
+		logger: slog.Default().WithGroup("email"),
+	}
+	err := email.Connect(ctx)
+	if err != nil {
+		email.logger.ErrorContext(ctx, "failed to connect email notification service", "error", err)
+		return nil, err
+	}
+	return email, nil
+}
+
+func (en *EmailNotifier) Topics() []string {
+	return events.AlertTopics
+}
+
+func (en *EmailNotifier) Connect(ctx context.Context) error {
+	if err := en.cfg.Validate(); err != nil {
+		return err
+	}
+	client, err := en.dial(ctx)
+	if err != nil {
+		return err
+	}
+	_ = client.Quit()
+
+	if en.cfg.Digest > 0 {
+		digestCtx, cancel := context.WithCancel(context.Background())
+		en.cancel = cancel
+		en.done = make(chan struct{})
+		go en.runDigest(digestCtx)
+	}
+	return nil
+}
+
+func (en *EmailNotifier) HandleEvent(ctx context.Context, event events.EventMessage) {
+	details, ok := formatEvent(event)
+	if !ok {
+		return
+	}
+
+	// the event is acknowledged once this returns, the digest isn't
+	// replayed after a crash
+	if en.cfg.Digest > 0 {
+		en.mu.Lock()
+		en.collected = append(en.collected, event)
+		if len(en.collected) > maxDigest {
+			en.collected = en.collected[len(en.collected)-maxDigest:]
+		}
+		en.mu.Unlock()
+		return
+	}
+
+	body := event.Summary() + "\n\n" + strings.Join(details, "\n") + "\n"
+	if err := en.send(ctx, event.Summary(), body); err != nil {
+		en.logger.ErrorContext(ctx, "failed to send mail", "error", err, "type", event.Type)
+	}
+}
+
+// Send mails the message right away, also in digest mode.
+func (en *EmailNotifier) Send(ctx context.Context, message string) error {
+	subject, _, _ := strings.Cut(message, "\n")
+	err := en.send(ctx, subject, message)
+	if err != nil {
+		en.logger.ErrorContext(ctx, "failed to send mail", "error", err)
+		return err
+	}
+	return nil
+}
+
+// Disconnect stops the digest and mails the events collected so far.
+func (en *EmailNotifier) Disconnect() error {
+	if en.cancel == nil {
+		return nil
+	}
+	en.cancel()
+	<-en.done
+	return nil
+}
+
+func (en *EmailNotifier) runDigest(ctx context.Context) {
+	defer close(en.done)
+	ticker := time.NewTicker(en.cfg.Digest)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			en.flush(context.Background())
+			return
+		case <-ticker.C:
+			en.flush(ctx)
+		}
+	}
+}
+
+// flush mails the collected events, they are kept for the next digest if
+// that fails.
+func (en *EmailNotifier) flush(ctx context.Context) {
+	en.mu.Lock()
+	collected := en.collected
+	en.collected = nil
+	en.mu.Unlock()
+	if len(collected) == 0 {
+		return
+	}
+
+	subject := fmt.Sprintf("Digest: %d events", len(collected))
+	if len(collected) == 1 {
+		subject = "Digest: 1 event"
+	}
+	err := en.send(ctx, subject, digest(collected))
+	if err == nil {
+		return
+	}
+	en.logger.ErrorContext(ctx, "failed to send digest", "error", err, "events", len(collected))
+
+	en.mu.Lock()
+	en.collected = append(collected, en.collected...)
+	if len(en.collected) > maxDigest {
+		en.collected = en.collected[len(en.collected)-maxDigest:]
+	}
+	en.mu.Unlock()
+}
+
+func (en *EmailNotifier) send(ctx context.Context, subject string, body string) error {
+	from, err := address(en.cfg.From)
+	if err != nil {
+		return err
+	}
+	if en.cfg.SubjectPrefix != "" {
+		subject = en.cfg.SubjectPrefix + " " + subject
+	}
+	msg, err := compose(en.cfg.From, en.cfg.To, subject, body, time.Now())
+	if err != nil {
+		return err
+	}
+
+	client, err := en.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("MAIL FROM: %w", err)
+	}
+	for _, to := range en.cfg.To {
+		rcpt, err := address(to)
+		if err != nil {
+			return err
+		}
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("RCPT TO %s: %w", rcpt, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("DATA: %w", err)
+	}
+	return client.Quit()
+}
+
+// dial connects to the server, secures the connection as configured and
+// authenticates.
+func (en *EmailNotifier) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(en.cfg.Host, strconv.Itoa(en.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: en.cfg.Host, InsecureSkipVerify: en.cfg.Insecure}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: timeout}
+	if en.cfg.Security == SecurityTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	client, err := smtp.NewClient(conn, en.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if en.cfg.Security == SecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("STARTTLS: %w", err)
+		}
+	}
+
+	if en.cfg.Username != "" {
+		auth := smtp.PlainAuth("", en.cfg.Username, en.cfg.Password, en.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("AUTH: %w", err)
+		}
+	}
+	return client, nil
+}
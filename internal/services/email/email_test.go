@@ -0,0 +1,335 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type receivedMail struct {
+	from    string
+	to      []string
+	tls     bool
+	subject string
+	body    string
+}
+
+// testSink is a local SMTP server that accepts AUTH PLAIN, STARTTLS and
+// keeps every mail.
+type testSink struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	startTLS  bool
+	username  string
+	password  string
+	mu        sync.Mutex
+	mails     []receivedMail
+}
+
+func newTestSink(t *testing.T, security string) *testSink {
+	// borrow the self-signed certificate of httptest
+	certServer := httptest.NewTLSServer(nil)
+	certServer.Close()
+	sink := &testSink{
+		tlsConfig: &tls.Config{Certificates: certServer.TLS.Certificates},
+		startTLS:  security == SecurityStartTLS,
+		username:  "overseer",
+		password:  "secret",
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	if security == SecurityTLS {
+		listener = tls.NewListener(listener, sink.tlsConfig)
+	}
+	sink.listener = listener
+	t.Cleanup(func() { _ = listener.Close() })
+	go sink.serve()
+	return sink
+}
+
+func (s *testSink) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *testSink) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *testSink) handle(conn net.Conn) {
+	defer conn.Close()
+	_, isTLS := conn.(*tls.Conn)
+	text := textproto.NewConn(conn)
+	_ = text.PrintfLine("220 localhost ESMTP sink")
+
+	var current receivedMail
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			extensions := []string{"localhost", "AUTH PLAIN"}
+			if s.startTLS && !isTLS {
+				extensions = append(extensions, "STARTTLS")
+			}
+			for i, extension := range extensions {
+				separator := "-"
+				if i == len(extensions)-1 {
+					separator = " "
+				}
+				_ = text.PrintfLine("250%s%s", separator, extension)
+			}
+		case "STARTTLS":
+			_ = text.PrintfLine("220 ready")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn, isTLS = tlsConn, true
+			text = textproto.NewConn(conn)
+		case "AUTH":
+			_, encoded, _ := strings.Cut(arg, " ")
+			credentials, _ := base64.StdEncoding.DecodeString(encoded)
+			if string(credentials) != "\x00"+s.username+"\x00"+s.password {
+				_ = text.PrintfLine("535 authentication failed")
+				continue
+			}
+			_ = text.PrintfLine("235 authenticated")
+		case "MAIL":
+			current = receivedMail{from: strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>"), tls: isTLS}
+			_ = text.PrintfLine("250 ok")
+		case "RCPT":
+			current.to = append(current.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			_ = text.PrintfLine("250 ok")
+		case "DATA":
+			_ = text.PrintfLine("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(string(data))))
+			if err != nil {
+				_ = text.PrintfLine("554 invalid message")
+				continue
+			}
+			current.subject, _ = new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+			body, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+			current.body = strings.ReplaceAll(string(body), "\r\n", "\n")
+			s.mu.Lock()
+			s.mails = append(s.mails, current)
+			s.mu.Unlock()
+			_ = text.PrintfLine("250 queued")
+		case "QUIT":
+			_ = text.PrintfLine("221 bye")
+			return
+		default:
+			_ = text.PrintfLine("250 ok")
+		}
+	}
+}
+
+func (s *testSink) received() []receivedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMail(nil), s.mails...)
+}
+
+var joined = events.EventMessage{
+	Type:      events.TypePlayerJoined,
+	Timestamp: time.Date(2024, 5, 1, 18, 30, 0, 0, time.UTC),
+	Payload:   events.PlayerEvent{Player: "Raider", ServerName: "island", ServerAddr: "127.0.0.1:27015"},
+}
+
+func TestEmailNotifier(t *testing.T) {
+	for _, security := range []string{SecurityNone, SecurityStartTLS, SecurityTLS} {
+		t.Run(security, func(t *testing.T) {
+			ctx := context.Background()
+			sink := newTestSink(t, security)
+
+			cfg, err := ParseConfig(map[interface{}]interface{}{
+				"host":     "127.0.0.1",
+				"port":     sink.port(),
+				"security": security,
+				"insecure": true,
+				"username": "overseer",
+				"password": "secret",
+				"from":     "Ark Overseer <overseer@example.com>",
+				"to":       []interface{}{"admin@example.com", "Defender <defender@example.com>"},
+			})
+			assert.NoError(t, err)
+			notifier, err := NewEmailNotifier(ctx, cfg)
+			assert.NoError(t, err)
+
+			notifier.HandleEvent(ctx, joined)
+			notifier.HandleEvent(ctx, events.EventMessage{Type: events.TypeServerAdded})
+
+			received := sink.received()
+			assert.Len(t, received, 1)
+			assert.Equal(t, security != SecurityNone, received[0].tls)
+			assert.Equal(t, "overseer@example.com", received[0].from)
+			assert.Equal(t, []string{"admin@example.com", "defender@example.com"}, received[0].to)
+			assert.Equal(t, "[ark-overseer] Raider joined island", received[0].subject)
+			assert.Contains(t, received[0].body, "Server: island (127.0.0.1:27015)")
+			assert.NoError(t, notifier.Disconnect())
+		})
+	}
+}
+
+func TestEmailAuthFailure(t *testing.T) {
+	sink := newTestSink(t, SecurityNone)
+	_, err := NewEmailNotifier(context.Background(), Config{
+		Host:     "127.0.0.1",
+		Port:     sink.port(),
+		Security: SecurityNone,
+		Username: "overseer",
+		Password: "wrong",
+		From:     "overseer@example.com",
+		To:       []string{"admin@example.com"},
+	})
+	assert.ErrorContains(t, err, "AUTH")
+}
+
+func TestEmailDigest(t *testing.T) {
+	ctx := context.Background()
+	sink := newTestSink(t, SecurityNone)
+
+	notifier, err := NewEmailNotifier(ctx, Config{
+		Host:          "127.0.0.1",
+		Port:          sink.port(),
+		Security:      SecurityNone,
+		From:          "overseer@example.com",
+		To:            []string{"admin@example.com"},
+		Digest:        100 * time.Millisecond,
+		SubjectPrefix: defaultSubjectPrefix,
+	})
+	assert.NoError(t, err)
+
+	notifier.HandleEvent(ctx, joined)
+	notifier.HandleEvent(ctx, events.EventMessage{
+		Type:      events.TypeServerOffline,
+		Timestamp: time.Date(2024, 5, 1, 18, 31, 0, 0, time.UTC),
+		Payload:   events.ServerEvent{ServerName: "island"},
+	})
+	assert.Empty(t, sink.received(), "events are collected")
+
+	assert.Eventually(t, func() bool { return len(sink.received()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	received := sink.received()
+	assert.Equal(t, "[ark-overseer] Digest: 2 events", received[0].subject)
+	assert.Equal(t, "2024-05-01 18:30:00 UTC  Raider joined island\n2024-05-01 18:31:00 UTC  island is offline\n", received[0].body)
+
+	notifier.HandleEvent(ctx, joined)
+	assert.NoError(t, notifier.Disconnect())
+	received = sink.received()
+	assert.Len(t, received, 2, "disconnect sends the remaining events")
+	assert.Equal(t, "[ark-overseer] Digest: 1 event", received[1].subject)
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		section   map[interface{}]interface{}
+		expected  Config
+		expectErr bool
+	}{
+		{
+			name: "defaults",
+			section: map[interface{}]interface{}{
+				"host": "smtp.example.com",
+				"from": "overseer@example.com",
+				"to":   "admin@example.com",
+			},
+			expected: Config{
+				Host:          "smtp.example.com",
+				Port:          587,
+				Security:      SecurityStartTLS,
+				From:          "overseer@example.com",
+				To:            []string{"admin@example.com"},
+				SubjectPrefix: defaultSubjectPrefix,
+			},
+		},
+		{
+			name: "implicit tls with digest",
+			section: map[interface{}]interface{}{
+				"host":     "smtp.example.com",
+				"security": "tls",
+				"username": "overseer",
+				"password": "secret",
+				"from":     "overseer@example.com",
+				"to":       []interface{}{"admin@example.com", "defender@example.com"},
+				"digest":   "15m",
+			},
+			expected: Config{
+				Host:          "smtp.example.com",
+				Port:          465,
+				Security:      SecurityTLS,
+				Username:      "overseer",
+				Password:      "secret",
+				From:          "overseer@example.com",
+				To:            []string{"admin@example.com", "defender@example.com"},
+				Digest:        15 * time.Minute,
+				SubjectPrefix: defaultSubjectPrefix,
+			},
+		},
+		{
+			name:      "missing host",
+			section:   map[interface{}]interface{}{"from": "overseer@example.com", "to": "admin@example.com"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid recipient",
+			section:   map[interface{}]interface{}{"host": "smtp.example.com", "from": "overseer@example.com", "to": "admin"},
+			expectErr: true,
+		},
+		{
+			name:      "missing recipients",
+			section:   map[interface{}]interface{}{"host": "smtp.example.com", "from": "overseer@example.com"},
+			expectErr: true,
+		},
+		{
+			name:      "unsupported security",
+			section:   map[interface{}]interface{}{"host": "smtp.example.com", "security": "ssl", "from": "overseer@example.com", "to": "admin@example.com"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid digest",
+			section:   map[interface{}]interface{}{"host": "smtp.example.com", "digest": "hourly", "from": "overseer@example.com", "to": "admin@example.com"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig(tt.section)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, cfg)
+		})
+	}
+}
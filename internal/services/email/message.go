@@ -0,0 +1,101 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+// compose builds a plain text mail with a quoted-printable body.
+func compose(from string, to []string, subject string, body string, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	headers := [][2]string{
+		{"From", from},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", header[0], header[1])
+	}
+	buf.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// address returns the bare address of a "Name <address>" string.
+func address(s string) (string, error) {
+	addr, err := mail.ParseAddress(s)
+	if err != nil {
+		return "", err
+	}
+	return addr.Address, nil
+}
+
+// formatEvent returns the details of the event, ok is false for events that
+// aren't mailed.
+func formatEvent(event events.EventMessage) ([]string, bool) {
+	var details []string
+	switch event.Type {
+	case events.TypePlayerJoined, events.TypePlayerLeft:
+		player, ok := event.PlayerEvent()
+		if !ok {
+			return nil, false
+		}
+		details = append(details, "Player: "+player.Player, "Server: "+serverName(player.ServerName, player.ServerAddr))
+		if event.Type == events.TypePlayerLeft && player.Duration > 0 {
+			details = append(details, "Session: "+player.Duration.Round(time.Second).String())
+		}
+	case events.TypeServerOnline, events.TypeServerOffline:
+		server, ok := event.ServerEvent()
+		if !ok {
+			return nil, false
+		}
+		details = append(details, "Server: "+serverName(server.ServerName, server.ServerAddr))
+		if server.Online {
+			details = append(details, fmt.Sprintf("Players: %d/%d", server.Players, server.MaxPlayers))
+		}
+	default:
+		return nil, false
+	}
+	return append(details, "Time: "+eventTime(event).Format(time.RFC1123)), true
+}
+
+func serverName(name string, addr string) string {
+	if addr == "" {
+		return name
+	}
+	return name + " (" + addr + ")"
+}
+
+func eventTime(event events.EventMessage) time.Time {
+	if event.Timestamp.IsZero() {
+		return time.Now()
+	}
+	return event.Timestamp
+}
+
+// digest summarizes the events one per line, oldest first.
+func digest(collected []events.EventMessage) string {
+	var b strings.Builder
+	for _, event := range collected {
+		fmt.Fprintf(&b, "%s  %s\n", eventTime(event).Format("2006-01-02 15:04:05 MST"), event.Summary())
+	}
+	return b.String()
+}
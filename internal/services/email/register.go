@@ -0,0 +1,31 @@
+package email
+
+import (
+	"context"
+
+	"github.com/led0nk/ark-overseer/internal/services/registry"
+)
+
+func init() {
+	registry.Register("email", registry.Definition[Config]{
+		Label: "Email",
+		Fields: []registry.Field{
+			{Key: "host", Label: "Host", Placeholder: "smtp.example.com", Type: registry.FieldText},
+			{Key: "port", Label: "Port", Placeholder: "587", Type: registry.FieldNumber},
+			{Key: "security", Label: "Security", Placeholder: "starttls, tls or none", Type: registry.FieldText},
+			{Key: "username", Label: "Username", Placeholder: "overseer@example.com", Type: registry.FieldText},
+			{Key: "password", Label: "Password", Placeholder: "Password...", Type: registry.FieldSecret},
+			{Key: "from", Label: "From", Placeholder: "Ark Overseer <overseer@example.com>", Type: registry.FieldText},
+			{Key: "to", Label: "To", Placeholder: "admin@example.com, mod@example.com", Type: registry.FieldList},
+			{Key: "digest", Label: "Digest", Placeholder: "15m (empty mails every event, lost on a crash)", Type: registry.FieldText},
+		},
+		Parse: ParseConfig,
+		New: func(ctx context.Context, cfg Config, deps registry.Deps) (registry.Notification, error) {
+			notifier, err := NewEmailNotifier(ctx, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return notifier, nil
+		},
+	})
+}
@@ -0,0 +1,66 @@
+package gotify
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/led0nk/ark-overseer/internal/services/push"
+)
+
+// Config of the gotify notifier, read from the notification-service
+// section. Tags are shown in front of the title, e.g. emojis:
+//
+//	gotify:
+//	  server: https://gotify.example.com
+//	  token: AbCdEf123   # application token
+//	  priorities:
+//	    server.online: min
+//	  tags:
+//	    player.joined: "⚠️"
+type Config struct {
+	Server string
+	Token  string
+	Rules  push.Rules
+}
+
+func ParseConfig(section map[interface{}]interface{}) (Config, error) {
+	var cfg Config
+
+	for key, target := range map[string]*string{"server": &cfg.Server, "token": &cfg.Token} {
+		value, ok := section[key]
+		if !ok || value == nil {
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			return Config{}, fmt.Errorf("invalid %s type", key)
+		}
+		*target = str
+	}
+
+	rules, err := push.ParseRules(section)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.Rules = rules
+
+	return cfg, cfg.Validate()
+}
+
+func (c Config) Validate() error {
+	if c.Server == "" {
+		return errors.New("server required")
+	}
+	u, err := url.Parse(c.Server)
+	if err != nil {
+		return fmt.Errorf("invalid server: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid server: unsupported scheme %q", u.Scheme)
+	}
+	if c.Token == "" {
+		return errors.New("token required")
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package gotify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/led0nk/ark-overseer/internal/services/push"
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+const timeout = 10 * time.Second
+
+// priorities maps the push priorities to the 0-10 scale of Gotify, the
+// Android app notifies from 4 on and pops up from 8 on.
+var priorities = map[push.Priority]int{
+	push.PriorityMin:     0,
+	push.PriorityLow:     2,
+	push.PriorityDefault: 5,
+	push.PriorityHigh:    8,
+	push.PriorityUrgent:  10,
+}
+
+type GotifyNotifier struct {
+	cfg    Config
+	client *http.Client
+	logger *slog.Logger
+}
+
+type message struct {
+	Title    string `json:"title,omitempty"`
+	Message  string `json:"message"`
+	Priority int    `json:"priority"`
+}
+
+func NewGotifyNotifier(ctx context.Context, cfg Config, client *http.Client) (*GotifyNotifier, error) {
+	if client == nil {
+		client = &http.Client{Timeout: timeout}
+	}
+	gotify := &GotifyNotifier{
+		cfg:    cfg,
+		client: client,
+		logger: slog.Default().WithGroup("gotify"),
+	}
+	err := gotify.Connect(ctx)
+	if err != nil {
+		gotify.logger.ErrorContext(ctx, "failed to connect gotify notification service", "error", err)
+		return nil, err
+	}
+	return gotify, nil
+}
+
+func (gn *GotifyNotifier) Topics() []string {
+	return push.Topics
+}
+
+// Connect validates the configuration, Gotify is only called per message.
+func (gn *GotifyNotifier) Connect(ctx context.Context) error {
+	return gn.cfg.Validate()
+}
+
+func (gn *GotifyNotifier) HandleEvent(ctx context.Context, event events.EventMessage) {
+	title, body, ok := push.Format(event)
+	if !ok {
+		return
+	}
+	if tags := gn.cfg.Rules.TagsFor(event.Type); len(tags) > 0 {
+		title = strings.Join(tags, " ") + " " + title
+	}
+	err := gn.post(ctx, message{
+		Title:    title,
+		Message:  body,
+		Priority: priorities[gn.cfg.Rules.Priority(event.Type)],
+	})
+	if err != nil {
+		gn.logger.ErrorContext(ctx, "failed to post message", "error", err, "type", event.Type)
+	}
+}
+
+func (gn *GotifyNotifier) Send(ctx context.Context, text string) error {
+	err := gn.post(ctx, message{Message: text, Priority: priorities[push.PriorityDefault]})
+	if err != nil {
+		gn.logger.ErrorContext(ctx, "failed to send gotify message", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (gn *GotifyNotifier) Disconnect() error {
+	gn.client.CloseIdleConnections()
+	return nil
+}
+
+func (gn *GotifyNotifier) post(ctx context.Context, msg message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(gn.cfg.Server, "/")+"/message", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", gn.cfg.Token)
+
+	resp, err := gn.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	// errors are JSON like {"error":"Unauthorized","errorCode":401,"errorDescription":"..."}
+	var result struct {
+		ErrorDescription string `json:"errorDescription"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if json.Unmarshal(data, &result) == nil && result.ErrorDescription != "" {
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, result.ErrorDescription)
+	}
+	return fmt.Errorf("unexpected status %s", resp.Status)
+}
@@ -0,0 +1,97 @@
+package gotify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type testGotify struct {
+	*httptest.Server
+	mu       sync.Mutex
+	messages []message
+}
+
+func newTestGotify(t *testing.T, token string) *testGotify {
+	stub := &testGotify{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /message", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Gotify-Key") != token {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"Unauthorized","errorCode":401,"errorDescription":"you need to provide a valid access token"}`))
+			return
+		}
+		var msg message
+		if json.NewDecoder(r.Body).Decode(&msg) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		stub.mu.Lock()
+		stub.messages = append(stub.messages, msg)
+		stub.mu.Unlock()
+		_, _ = w.Write([]byte(`{"id":1}`))
+	})
+	stub.Server = httptest.NewServer(mux)
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+func (s *testGotify) received() []message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]message(nil), s.messages...)
+}
+
+func TestGotifyNotifier(t *testing.T) {
+	ctx := context.Background()
+	stub := newTestGotify(t, "AbCdEf123")
+
+	cfg, err := ParseConfig(map[interface{}]interface{}{
+		"server":     stub.URL + "/",
+		"token":      "AbCdEf123",
+		"priorities": map[interface{}]interface{}{"server.online": "min"},
+		"tags":       map[interface{}]interface{}{"player.joined": "⚠️"},
+	})
+	assert.NoError(t, err)
+	notifier, err := NewGotifyNotifier(ctx, cfg, nil)
+	assert.NoError(t, err)
+
+	notifier.HandleEvent(ctx, events.EventMessage{
+		Type:    events.TypePlayerJoined,
+		Payload: events.PlayerEvent{Player: "Raider", ServerName: "island"},
+	})
+	notifier.HandleEvent(ctx, events.EventMessage{
+		Type:    events.TypeServerOffline,
+		Payload: events.ServerEvent{ServerName: "island"},
+	})
+	notifier.HandleEvent(ctx, events.EventMessage{
+		Type:    events.TypeServerOnline,
+		Payload: events.ServerEvent{ServerName: "island", Online: true},
+	})
+	assert.NoError(t, notifier.Send(ctx, "hello"))
+
+	received := stub.received()
+	assert.Len(t, received, 4)
+	assert.Equal(t, message{Title: "⚠️ Raider joined island", Message: "Server: island", Priority: 8}, received[0])
+	assert.Equal(t, 10, received[1].Priority)
+	assert.Equal(t, 0, received[2].Priority)
+	assert.Equal(t, message{Message: "hello", Priority: 5}, received[3])
+
+	notifier.cfg.Token = "wrong"
+	assert.ErrorContains(t, notifier.Send(ctx, "hello"), "valid access token")
+}
+
+func TestParseConfig(t *testing.T) {
+	_, err := ParseConfig(map[interface{}]interface{}{"server": "https://gotify.example.com", "token": "AbCdEf123"})
+	assert.NoError(t, err)
+	_, err = ParseConfig(map[interface{}]interface{}{"server": "https://gotify.example.com"})
+	assert.Error(t, err, "token required")
+	_, err = ParseConfig(map[interface{}]interface{}{"server": "gotify.example.com", "token": "AbCdEf123"})
+	assert.Error(t, err, "scheme required")
+}
@@ -0,0 +1,25 @@
+package gotify
+
+import (
+	"context"
+
+	"github.com/led0nk/ark-overseer/internal/services/registry"
+)
+
+func init() {
+	registry.Register("gotify", registry.Definition[Config]{
+		Label: "Gotify",
+		Fields: []registry.Field{
+			{Key: "server", Label: "Server", Placeholder: "https://gotify.example.com", Type: registry.FieldText},
+			{Key: "token", Label: "Application-Token", Placeholder: "Application token...", Type: registry.FieldSecret},
+		},
+		Parse: ParseConfig,
+		New: func(ctx context.Context, cfg Config, deps registry.Deps) (registry.Notification, error) {
+			notifier, err := NewGotifyNotifier(ctx, cfg, nil)
+			if err != nil {
+				return nil, err
+			}
+			return notifier, nil
+		},
+	})
+}
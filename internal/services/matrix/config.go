@@ -0,0 +1,64 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Config of the matrix notifier, read from the notification-service section:
+//
+//	matrix:
+//	  homeserver: https://matrix.org
+//	  accessToken: syt_...
+//	  roomID: "!abcdefghijklmnop:matrix.org"
+type Config struct {
+	Homeserver  string
+	AccessToken string
+	RoomID      string
+}
+
+func ParseConfig(section map[interface{}]interface{}) (Config, error) {
+	var cfg Config
+
+	stringFields := map[string]*string{
+		"homeserver":  &cfg.Homeserver,
+		"accessToken": &cfg.AccessToken,
+		"roomID":      &cfg.RoomID,
+	}
+	for key, target := range stringFields {
+		value, ok := section[key]
+		if !ok || value == nil {
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			return Config{}, fmt.Errorf("invalid %s type", key)
+		}
+		*target = str
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func (c Config) Validate() error {
+	if c.Homeserver == "" {
+		return errors.New("homeserver required")
+	}
+	u, err := url.Parse(c.Homeserver)
+	if err != nil {
+		return fmt.Errorf("invalid homeserver: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid homeserver: unsupported scheme %q", u.Scheme)
+	}
+	if c.AccessToken == "" {
+		return errors.New("accessToken required")
+	}
+	// room aliases (#room:server) would have to be resolved first
+	if !strings.HasPrefix(c.RoomID, "!") || !strings.Contains(c.RoomID, ":") {
+		return fmt.Errorf("invalid roomID %q, expected !id:server", c.RoomID)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package matrix
+
+import (
+	"fmt"
+	"html"
+	"time"
+
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+// formatEvent returns the plain text and HTML body of the event, ok is
+// false for events that aren't sent to Matrix.
+func formatEvent(event events.EventMessage) (string, string, bool) {
+	switch event.Type {
+	case events.TypePlayerJoined, events.TypePlayerLeft:
+		player, ok := event.PlayerEvent()
+		if !ok {
+			return "", "", false
+		}
+		name, server := html.EscapeString(player.Player), html.EscapeString(player.ServerName)
+		if event.Type == events.TypePlayerJoined {
+			return event.Summary(), fmt.Sprintf("➡️ <b>%s</b> joined <b>%s</b>", name, server), true
+		}
+		formatted := fmt.Sprintf("⬅️ <b>%s</b> left <b>%s</b>", name, server)
+		if player.Duration > 0 {
+			formatted += "<br><i>Session: " + player.Duration.Round(time.Second).String() + "</i>"
+		}
+		return event.Summary(), formatted, true
+
+	case events.TypeServerOnline, events.TypeServerOffline:
+		server, ok := event.ServerEvent()
+		if !ok {
+			return "", "", false
+		}
+		name := html.EscapeString(server.ServerName)
+		if server.Online {
+			return event.Summary(), fmt.Sprintf("🟢 <b>%s</b> is online (%d/%d)", name, server.Players, server.MaxPlayers), true
+		}
+		return event.Summary(), fmt.Sprintf("🔴 <b>%s</b> is offline", name), true
+	}
+	return "", "", false
+}
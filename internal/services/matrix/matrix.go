@@ -0,0 +1,165 @@
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/led0nk/ark-overseer/internal/services/retry"
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+type MatrixNotifier struct {
+	cfg    Config
+	client *http.Client
+	logger *slog.Logger
+	// txnPrefix and txnCounter build transaction IDs that are unique for
+	// the access token, retries reuse the ID so messages aren't duplicated.
+	txnPrefix  string
+	txnCounter atomic.Uint64
+}
+
+type roomMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format,omitempty"`
+	FormattedBody string `json:"formatted_body,omitempty"`
+}
+
+// matrixError is the error body of the client-server API.
+type matrixError struct {
+	ErrCode      string `json:"errcode"`
+	Error        string `json:"error"`
+	RetryAfterMs int    `json:"retry_after_ms"`
+}
+
+func NewMatrixNotifier(ctx context.Context, cfg Config, client *http.Client) (*MatrixNotifier, error) {
+	if client == nil {
+		client = &http.Client{Timeout: retry.Timeout}
+	}
+	matrix := &MatrixNotifier{
+		cfg:       cfg,
+		client:    client,
+		logger:    slog.Default().WithGroup("matrix"),
+		txnPrefix: strconv.FormatInt(time.Now().UnixNano(), 36),
+	}
+	err := matrix.Connect(ctx)
+	if err != nil {
+		matrix.logger.ErrorContext(ctx, "failed to connect matrix notification service", "error", err)
+		return nil, err
+	}
+	return matrix, nil
+}
+
+func (mn *MatrixNotifier) Topics() []string {
+	return events.AlertTopics
+}
+
+// Connect checks the access token with whoami.
+func (mn *MatrixNotifier) Connect(ctx context.Context) error {
+	if err := mn.cfg.Validate(); err != nil {
+		return err
+	}
+	return mn.call(ctx, http.MethodGet, "/_matrix/client/v3/account/whoami", nil)
+}
+
+func (mn *MatrixNotifier) HandleEvent(ctx context.Context, event events.EventMessage) {
+	body, formatted, ok := formatEvent(event)
+	if !ok {
+		return
+	}
+	err := mn.send(ctx, roomMessage{
+		MsgType:       "m.text",
+		Body:          body,
+		Format:        "org.matrix.custom.html",
+		FormattedBody: formatted,
+	})
+	if err != nil {
+		mn.logger.ErrorContext(ctx, "failed to send message", "error", err, "type", event.Type)
+	}
+}
+
+func (mn *MatrixNotifier) Send(ctx context.Context, message string) error {
+	err := mn.send(ctx, roomMessage{MsgType: "m.text", Body: message})
+	if err != nil {
+		mn.logger.ErrorContext(ctx, "failed to send matrix message", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (mn *MatrixNotifier) Disconnect() error {
+	mn.client.CloseIdleConnections()
+	return nil
+}
+
+func (mn *MatrixNotifier) send(ctx context.Context, msg roomMessage) error {
+	txnID := mn.txnPrefix + "-" + strconv.FormatUint(mn.txnCounter.Add(1), 10)
+	path := "/_matrix/client/v3/rooms/" + url.PathEscape(mn.cfg.RoomID) + "/send/m.room.message/" + txnID
+	return mn.call(ctx, http.MethodPut, path, msg)
+}
+
+// call sends a request to the homeserver and waits for retry_after_ms if
+// it is rate limited.
+func (mn *MatrixNotifier) call(ctx context.Context, method string, path string, params interface{}) error {
+	var body []byte
+	if params != nil {
+		var err error
+		body, err = json.Marshal(params)
+		if err != nil {
+			return err
+		}
+	}
+	endpoint := strings.TrimSuffix(mn.cfg.Homeserver, "/") + path
+
+	return retry.Do(ctx, func() (time.Duration, error) {
+		return mn.do(ctx, method, endpoint, body)
+	})
+}
+
+func (mn *MatrixNotifier) do(ctx context.Context, method string, endpoint string, body []byte) (time.Duration, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+mn.cfg.AccessToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := mn.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return 0, nil
+	}
+
+	var result matrixError
+	if err := json.Unmarshal(data, &result); err != nil || result.ErrCode == "" {
+		return 0, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	err = fmt.Errorf("%s: %s", result.ErrCode, result.Error)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return time.Duration(max(result.RetryAfterMs, 100)) * time.Millisecond, err
+	}
+	return 0, err
+}
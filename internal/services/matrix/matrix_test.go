@@ -0,0 +1,213 @@
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRoom = "!room:localhost"
+
+// testHomeserver implements whoami and sending room messages, transaction
+// IDs are deduplicated like a homeserver does.
+type testHomeserver struct {
+	*httptest.Server
+	mu          sync.Mutex
+	messages    []roomMessage
+	txnIDs      map[string]bool
+	rateLimited int
+}
+
+func newTestHomeserver(t *testing.T, token string) *testHomeserver {
+	hs := &testHomeserver{txnIDs: make(map[string]bool)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /_matrix/client/v3/account/whoami", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"user_id":"@overseer:localhost"}`))
+	})
+	mux.HandleFunc("PUT /_matrix/client/v3/rooms/{room}/send/m.room.message/{txnID}", func(w http.ResponseWriter, r *http.Request) {
+		hs.mu.Lock()
+		defer hs.mu.Unlock()
+		if r.PathValue("room") != testRoom {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errcode":"M_FORBIDDEN","error":"not in room"}`))
+			return
+		}
+		if hs.rateLimited > 0 {
+			hs.rateLimited--
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"errcode":"M_LIMIT_EXCEEDED","error":"Too many requests","retry_after_ms":10}`))
+			return
+		}
+		var msg roomMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errcode":"M_NOT_JSON","error":"invalid body"}`))
+			return
+		}
+		if txnID := r.PathValue("txnID"); !hs.txnIDs[txnID] {
+			hs.txnIDs[txnID] = true
+			hs.messages = append(hs.messages, msg)
+		}
+		_, _ = w.Write([]byte(`{"event_id":"$event"}`))
+	})
+	hs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"errcode":"M_UNKNOWN_TOKEN","error":"Invalid access token"}`))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(hs.Close)
+	return hs
+}
+
+func (hs *testHomeserver) received() []roomMessage {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	return append([]roomMessage(nil), hs.messages...)
+}
+
+func TestMatrixNotifier(t *testing.T) {
+	ctx := context.Background()
+	hs := newTestHomeserver(t, "syt_token")
+
+	cfg, err := ParseConfig(map[interface{}]interface{}{
+		"homeserver":  hs.URL,
+		"accessToken": "syt_token",
+		"roomID":      testRoom,
+	})
+	assert.NoError(t, err)
+	notifier, err := NewMatrixNotifier(ctx, cfg, nil)
+	assert.NoError(t, err)
+
+	hs.mu.Lock()
+	hs.rateLimited = 1
+	hs.mu.Unlock()
+	notifier.HandleEvent(ctx, events.EventMessage{
+		Type:    events.TypePlayerJoined,
+		Payload: events.PlayerEvent{Player: "<Raider>", ServerName: "island"},
+	})
+	notifier.HandleEvent(ctx, events.EventMessage{Type: events.TypeServerAdded})
+	assert.NoError(t, notifier.Send(ctx, "hello"))
+
+	received := hs.received()
+	assert.Len(t, received, 2)
+	assert.Equal(t, "m.text", received[0].MsgType)
+	assert.Equal(t, "<Raider> joined island", received[0].Body)
+	assert.Equal(t, "org.matrix.custom.html", received[0].Format)
+	assert.Equal(t, "➡️ <b>&lt;Raider&gt;</b> joined <b>island</b>", received[0].FormattedBody)
+	assert.Equal(t, "hello", received[1].Body)
+	assert.Empty(t, received[1].Format)
+	assert.NoError(t, notifier.Disconnect())
+
+	notifier.cfg.RoomID = "!other:localhost"
+	assert.ErrorContains(t, notifier.Send(ctx, "hello"), "M_FORBIDDEN")
+}
+
+func TestMatrixInvalidToken(t *testing.T) {
+	hs := newTestHomeserver(t, "syt_token")
+
+	_, err := NewMatrixNotifier(context.Background(), Config{
+		Homeserver:  hs.URL,
+		AccessToken: "syt_wrong",
+		RoomID:      testRoom,
+	}, nil)
+	assert.ErrorContains(t, err, "M_UNKNOWN_TOKEN")
+}
+
+func TestFormatEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		event     events.EventMessage
+		formatted string
+		expectOK  bool
+	}{
+		{
+			name: "player left",
+			event: events.EventMessage{
+				Type:    events.TypePlayerLeft,
+				Payload: events.PlayerEvent{Player: "Raider", ServerName: "island", Duration: 90 * time.Minute},
+			},
+			formatted: "⬅️ <b>Raider</b> left <b>island</b><br><i>Session: 1h30m0s</i>",
+			expectOK:  true,
+		},
+		{
+			name: "server online",
+			event: events.EventMessage{
+				Type:    events.TypeServerOnline,
+				Payload: events.ServerEvent{ServerName: "island", Online: true, Players: 3, MaxPlayers: 70},
+			},
+			formatted: "🟢 <b>island</b> is online (3/70)",
+			expectOK:  true,
+		},
+		{
+			name: "server offline",
+			event: events.EventMessage{
+				Type:    events.TypeServerOffline,
+				Payload: events.ServerEvent{ServerName: "island"},
+			},
+			formatted: "🔴 <b>island</b> is offline",
+			expectOK:  true,
+		},
+		{
+			name:  "unsupported type",
+			event: events.EventMessage{Type: events.TypeInit},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, formatted, ok := formatEvent(tt.event)
+			assert.Equal(t, tt.expectOK, ok)
+			assert.Equal(t, tt.formatted, formatted)
+			assert.Equal(t, tt.event.Summary(), body)
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		section   map[interface{}]interface{}
+		expectErr bool
+	}{
+		{
+			name:    "valid",
+			section: map[interface{}]interface{}{"homeserver": "https://matrix.org", "accessToken": "syt_token", "roomID": "!room:matrix.org"},
+		},
+		{
+			name:      "missing homeserver",
+			section:   map[interface{}]interface{}{"accessToken": "syt_token", "roomID": "!room:matrix.org"},
+			expectErr: true,
+		},
+		{
+			name:      "missing token",
+			section:   map[interface{}]interface{}{"homeserver": "https://matrix.org", "roomID": "!room:matrix.org"},
+			expectErr: true,
+		},
+		{
+			name:      "room alias",
+			section:   map[interface{}]interface{}{"homeserver": "https://matrix.org", "accessToken": "syt_token", "roomID": "#room:matrix.org"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig(tt.section)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package matrix
+
+import (
+	"context"
+
+	"github.com/led0nk/ark-overseer/internal/services/registry"
+)
+
+func init() {
+	registry.Register("matrix", registry.Definition[Config]{
+		Label: "Matrix",
+		Fields: []registry.Field{
+			{Key: "homeserver", Label: "Homeserver", Placeholder: "https://matrix.org", Type: registry.FieldText},
+			{Key: "accessToken", Label: "Access-Token", Placeholder: "Access token...", Type: registry.FieldSecret},
+			{Key: "roomID", Label: "Room-ID", Placeholder: "!room:matrix.org", Type: registry.FieldText},
+		},
+		Parse: ParseConfig,
+		New: func(ctx context.Context, cfg Config, deps registry.Deps) (registry.Notification, error) {
+			notifier, err := NewMatrixNotifier(ctx, cfg, nil)
+			if err != nil {
+				return nil, err
+			}
+			return notifier, nil
+		},
+	})
+}
@@ -0,0 +1,191 @@
+package mqtt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	defaultTopicPrefix     = "ark-overseer"
+	defaultDiscoveryPrefix = "homeassistant"
+	defaultClientID        = "ark-overseer"
+)
+
+// Config of the mqtt notifier, read from the notification-service section:
+//
+//	mqtt:
+//	  broker: ssl://localhost:8883
+//	  username: overseer
+//	  password: secret
+//	  topicPrefix: ark-overseer
+//	  qos: 1
+//	  discovery: true
+//	  caFile: /etc/mosquitto/ca.crt
+//	  topics:
+//	    player.*: ark/alerts
+type Config struct {
+	Broker   string
+	ClientID string
+	Username string
+	Password string
+	// TopicPrefix is prepended to all topics that aren't configured
+	// explicitly in Topics.
+	TopicPrefix string
+	QoS         byte
+	// Topics maps event type patterns, see events.MatchTopic, to the topic
+	// the events are published on.
+	Topics          map[string]string
+	Discovery       bool
+	DiscoveryPrefix string
+	CAFile          string
+	CertFile        string
+	KeyFile         string
+	Insecure        bool
+}
+
+func ParseConfig(section map[interface{}]interface{}) (Config, error) {
+	cfg := Config{
+		ClientID:        defaultClientID,
+		TopicPrefix:     defaultTopicPrefix,
+		DiscoveryPrefix: defaultDiscoveryPrefix,
+		Topics:          make(map[string]string),
+	}
+
+	stringFields := map[string]*string{
+		"broker":          &cfg.Broker,
+		"clientID":        &cfg.ClientID,
+		"username":        &cfg.Username,
+		"password":        &cfg.Password,
+		"topicPrefix":     &cfg.TopicPrefix,
+		"discoveryPrefix": &cfg.DiscoveryPrefix,
+		"caFile":          &cfg.CAFile,
+		"certFile":        &cfg.CertFile,
+		"keyFile":         &cfg.KeyFile,
+	}
+	for key, target := range stringFields {
+		value, ok := section[key]
+		if !ok || value == nil {
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			return Config{}, fmt.Errorf("invalid %s type", key)
+		}
+		if str != "" {
+			*target = str
+		}
+	}
+
+	if value, ok := section["qos"]; ok && value != nil {
+		qos, ok := value.(int)
+		if !ok {
+			return Config{}, errors.New("invalid qos type")
+		}
+		cfg.QoS = byte(qos)
+	}
+	for key, target := range map[string]*bool{"discovery": &cfg.Discovery, "insecure": &cfg.Insecure} {
+		value, ok := section[key]
+		if !ok || value == nil {
+			continue
+		}
+		b, ok := value.(bool)
+		if !ok {
+			return Config{}, fmt.Errorf("invalid %s type", key)
+		}
+		*target = b
+	}
+
+	if value, ok := section["topics"]; ok && value != nil {
+		topics, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return Config{}, errors.New("invalid topics type")
+		}
+		for pattern, topic := range topics {
+			p, ok := pattern.(string)
+			if !ok {
+				return Config{}, fmt.Errorf("invalid topic pattern %v", pattern)
+			}
+			t, ok := topic.(string)
+			if !ok {
+				return Config{}, fmt.Errorf("invalid topic for %s", p)
+			}
+			cfg.Topics[p] = t
+		}
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func (c Config) Validate() error {
+	if c.Broker == "" {
+		return errors.New("broker required")
+	}
+	broker, err := url.Parse(c.Broker)
+	if err != nil {
+		return fmt.Errorf("invalid broker: %w", err)
+	}
+	switch broker.Scheme {
+	case "tcp", "mqtt", "ssl", "tls", "mqtts", "ws", "wss":
+	default:
+		return fmt.Errorf("unsupported broker scheme %q", broker.Scheme)
+	}
+	if c.QoS > 2 {
+		return fmt.Errorf("invalid qos %d", c.QoS)
+	}
+	if c.TopicPrefix == "" || strings.ContainsAny(c.TopicPrefix, "#+") {
+		return fmt.Errorf("invalid topic prefix %q", c.TopicPrefix)
+	}
+	for pattern, topic := range c.Topics {
+		if topic == "" || strings.ContainsAny(topic, "#+") {
+			return fmt.Errorf("invalid topic %q for %s", topic, pattern)
+		}
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("certFile and keyFile have to be set together")
+	}
+	return nil
+}
+
+// tlsConfig returns nil if the broker doesn't use TLS.
+func (c Config) tlsConfig() (*tls.Config, error) {
+	broker, err := url.Parse(c.Broker)
+	if err != nil {
+		return nil, err
+	}
+	switch broker.Scheme {
+	case "ssl", "tls", "mqtts", "wss":
+	default:
+		if c.CAFile == "" && c.CertFile == "" {
+			return nil, nil
+		}
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.Insecure,
+	}
+	if c.CAFile != "" {
+		ca, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no certificates found in ca file")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
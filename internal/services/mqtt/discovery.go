@@ -0,0 +1,109 @@
+package mqtt
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/led0nk/ark-overseer/internal/model"
+)
+
+// entity is a Home Assistant entity derived from the server state.
+type entity struct {
+	component     string
+	objectID      string
+	name          string
+	valueTemplate string
+	deviceClass   string
+	unit          string
+	icon          string
+}
+
+var discoveryEntities = []entity{
+	{
+		component:     "binary_sensor",
+		objectID:      "online",
+		name:          "Online",
+		valueTemplate: "{{ 'ON' if value_json.online else 'OFF' }}",
+		deviceClass:   "connectivity",
+	},
+	{
+		component:     "sensor",
+		objectID:      "players",
+		name:          "Players",
+		valueTemplate: "{{ value_json.players }}",
+		unit:          "players",
+		icon:          "mdi:account-group",
+	},
+	{
+		component:     "sensor",
+		objectID:      "watched_online",
+		name:          "Watched players online",
+		valueTemplate: "{{ value_json.watchedOnline | count }}",
+		unit:          "players",
+		icon:          "mdi:account-alert",
+	},
+	{
+		component:     "binary_sensor",
+		objectID:      "watched_present",
+		name:          "Watched player present",
+		valueTemplate: "{{ 'ON' if value_json.watchedOnline | count > 0 else 'OFF' }}",
+		deviceClass:   "occupancy",
+	},
+}
+
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+}
+
+type discoveryConfig struct {
+	Name                string          `json:"name"`
+	UniqueID            string          `json:"unique_id"`
+	StateTopic          string          `json:"state_topic"`
+	ValueTemplate       string          `json:"value_template"`
+	JSONAttributesTopic string          `json:"json_attributes_topic"`
+	AvailabilityTopic   string          `json:"availability_topic"`
+	DeviceClass         string          `json:"device_class,omitempty"`
+	UnitOfMeasurement   string          `json:"unit_of_measurement,omitempty"`
+	Icon                string          `json:"icon,omitempty"`
+	Device              discoveryDevice `json:"device"`
+}
+
+func (mn *MQTTNotifier) discoveryTopic(e entity, id uuid.UUID) string {
+	return mn.cfg.DiscoveryPrefix + "/" + e.component + "/ark_overseer_" + id.String() + "/" + e.objectID + "/config"
+}
+
+// publishDiscovery announces the entities of the server to Home Assistant.
+func (mn *MQTTNotifier) publishDiscovery(server *model.Server) {
+	if !mn.cfg.Discovery {
+		return
+	}
+
+	for _, e := range discoveryEntities {
+		config := discoveryConfig{
+			Name:                e.name,
+			UniqueID:            "ark_overseer_" + server.ID.String() + "_" + e.objectID,
+			StateTopic:          mn.stateTopic(server.ID),
+			ValueTemplate:       e.valueTemplate,
+			JSONAttributesTopic: mn.stateTopic(server.ID),
+			AvailabilityTopic:   mn.statusTopic(),
+			DeviceClass:         e.deviceClass,
+			UnitOfMeasurement:   e.unit,
+			Icon:                e.icon,
+			Device: discoveryDevice{
+				Identifiers:  []string{"ark_overseer_" + server.ID.String()},
+				Name:         server.Name,
+				Manufacturer: "ark-overseer",
+				Model:        server.Addr,
+			},
+		}
+		data, err := json.Marshal(config)
+		if err != nil {
+			mn.logger.Error("failed to encode discovery config", "error", err)
+			continue
+		}
+		mn.publishRetained(mn.discoveryTopic(e, server.ID), data)
+	}
+}
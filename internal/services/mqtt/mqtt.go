@@ -0,0 +1,384 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+	"github.com/led0nk/ark-overseer/internal/blacklist"
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/led0nk/ark-overseer/internal/storage"
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+const (
+	statusOnline  = "online"
+	statusOffline = "offline"
+	timeout       = 10 * time.Second
+)
+
+// ServerState is published retained on <prefix>/servers/<id>/state whenever
+// it changes.
+type ServerState struct {
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	Addr          string    `json:"addr"`
+	Cluster       string    `json:"cluster,omitempty"`
+	Online        bool      `json:"online"`
+	Map           string    `json:"map,omitempty"`
+	Players       int       `json:"players"`
+	MaxPlayers    int       `json:"maxPlayers"`
+	PlayerNames   []string  `json:"playerNames"`
+	WatchedOnline []string  `json:"watchedOnline"`
+}
+
+type MQTTNotifier struct {
+	cfg       Config
+	client    paho.Client
+	sStore    storage.Database
+	blacklist blacklist.Blacklister
+	logger    *slog.Logger
+	mu        sync.Mutex
+	ctx       context.Context
+	cancel    context.CancelFunc
+	watchers  map[uuid.UUID]context.CancelFunc
+	servers   map[uuid.UUID]*model.Server
+	published map[string]string
+}
+
+// NewMQTTNotifier connects to the broker and keeps the retained state of all
+// servers in sStore up to date until Disconnect is called.
+func NewMQTTNotifier(
+	ctx context.Context,
+	cfg Config,
+	sStore storage.Database,
+	blacklist blacklist.Blacklister,
+) (*MQTTNotifier, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	notifier := &MQTTNotifier{
+		cfg:       cfg,
+		sStore:    sStore,
+		blacklist: blacklist,
+		logger:    slog.Default().WithGroup("mqtt"),
+		ctx:       watchCtx,
+		cancel:    cancel,
+		watchers:  make(map[uuid.UUID]context.CancelFunc),
+		servers:   make(map[uuid.UUID]*model.Server),
+		published: make(map[string]string),
+	}
+
+	err := notifier.Connect(ctx)
+	if err != nil {
+		cancel()
+		notifier.logger.ErrorContext(ctx, "failed to connect mqtt notification service", "error", err)
+		return nil, err
+	}
+
+	servers, err := sStore.List(ctx)
+	if err != nil {
+		notifier.logger.ErrorContext(ctx, "failed to list servers", "error", err)
+	}
+	for _, server := range servers {
+		notifier.watch(server)
+	}
+	return notifier, nil
+}
+
+// Topics limits the notifier to events that change the published state
+// or are published themselves.
+func (mn *MQTTNotifier) Topics() []string {
+	return []string{"player.*", "server.*"}
+}
+
+func (mn *MQTTNotifier) Connect(ctx context.Context) error {
+	tlsConfig, err := mn.cfg.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	opts := paho.NewClientOptions().
+		AddBroker(mn.cfg.Broker).
+		SetClientID(mn.cfg.ClientID).
+		SetUsername(mn.cfg.Username).
+		SetPassword(mn.cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectTimeout(timeout).
+		SetWill(mn.statusTopic(), statusOffline, mn.cfg.QoS, true).
+		SetOnConnectHandler(mn.onConnect)
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	client := paho.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(timeout) {
+		return fmt.Errorf("timed out connecting to %s", mn.cfg.Broker)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", mn.cfg.Broker, err)
+	}
+
+	mn.mu.Lock()
+	mn.client = client
+	mn.mu.Unlock()
+	return nil
+}
+
+// onConnect announces availability and publishes discovery and state again
+// after every (re)connect, the broker may have lost retained messages.
+func (mn *MQTTNotifier) onConnect(client paho.Client) {
+	mn.mu.Lock()
+	mn.client = client
+	mn.published = make(map[string]string)
+	servers := make([]*model.Server, 0, len(mn.servers))
+	for _, server := range mn.servers {
+		servers = append(servers, server)
+	}
+	mn.mu.Unlock()
+
+	if err := mn.publish(mn.statusTopic(), true, []byte(statusOnline)); err != nil {
+		mn.logger.Error("failed to publish status", "error", err)
+	}
+	for _, server := range servers {
+		mn.publishDiscovery(server)
+		mn.publishState(mn.ctx, server)
+	}
+}
+
+// Send publishes a plain text message on <prefix>/message.
+func (mn *MQTTNotifier) Send(ctx context.Context, message string) error {
+	err := mn.publish(mn.cfg.TopicPrefix+"/message", false, []byte(message))
+	if err != nil {
+		mn.logger.ErrorContext(ctx, "failed to send mqtt message", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (mn *MQTTNotifier) HandleEvent(ctx context.Context, event events.EventMessage) {
+	data, err := events.MarshalEvent(event)
+	if err != nil {
+		mn.logger.ErrorContext(ctx, "failed to encode event", "error", err, "type", event.Type)
+		return
+	}
+	err = mn.publish(mn.eventTopic(event.Type), false, data)
+	if err != nil {
+		mn.logger.ErrorContext(ctx, "failed to publish event", "error", err, "type", event.Type)
+	}
+
+	switch event.Type {
+	case events.TypeServerAdded:
+		if server, ok := event.Server(); ok {
+			mn.watch(server)
+		}
+	case events.TypeServerDeleted:
+		if id, ok := event.ServerID(); ok {
+			mn.forget(id)
+		}
+	case events.TypePlayerJoined, events.TypePlayerLeft:
+		// the blacklist may have changed, watched players are derived from it
+		if playerEvent, ok := event.PlayerEvent(); ok {
+			mn.mu.Lock()
+			server := mn.servers[playerEvent.ServerID]
+			mn.mu.Unlock()
+			if server != nil {
+				mn.publishState(ctx, server)
+			}
+		}
+	}
+}
+
+func (mn *MQTTNotifier) Disconnect() error {
+	mn.cancel()
+
+	mn.mu.Lock()
+	client := mn.client
+	mn.mu.Unlock()
+	if client == nil {
+		return nil
+	}
+
+	if client.IsConnected() {
+		token := client.Publish(mn.statusTopic(), mn.cfg.QoS, true, statusOffline)
+		token.WaitTimeout(timeout)
+	}
+	client.Disconnect(250)
+	return nil
+}
+
+// eventTopic returns the configured topic of the first matching pattern, or
+// <prefix>/events/<type> with dots replaced by slashes.
+func (mn *MQTTNotifier) eventTopic(eventType string) string {
+	if topic, ok := mn.cfg.Topics[eventType]; ok {
+		return topic
+	}
+	patterns := make([]string, 0, len(mn.cfg.Topics))
+	for pattern := range mn.cfg.Topics {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if events.MatchTopic(pattern, eventType) {
+			return mn.cfg.Topics[pattern]
+		}
+	}
+	return mn.cfg.TopicPrefix + "/events/" + strings.ReplaceAll(eventType, ".", "/")
+}
+
+func (mn *MQTTNotifier) statusTopic() string {
+	return mn.cfg.TopicPrefix + "/status"
+}
+
+func (mn *MQTTNotifier) stateTopic(id uuid.UUID) string {
+	return mn.cfg.TopicPrefix + "/servers/" + id.String() + "/state"
+}
+
+func (mn *MQTTNotifier) publish(topic string, retained bool, payload []byte) error {
+	mn.mu.Lock()
+	client := mn.client
+	mn.mu.Unlock()
+	if client == nil {
+		return errors.New("not connected")
+	}
+
+	token := client.Publish(topic, mn.cfg.QoS, retained, payload)
+	if !token.WaitTimeout(timeout) {
+		return fmt.Errorf("timed out publishing to %s", topic)
+	}
+	return token.Error()
+}
+
+// watch publishes the state of the server whenever it changes.
+func (mn *MQTTNotifier) watch(server *model.Server) {
+	mn.mu.Lock()
+	if _, exists := mn.watchers[server.ID]; exists {
+		mn.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(mn.ctx)
+	mn.watchers[server.ID] = cancel
+	mn.servers[server.ID] = server
+	mn.mu.Unlock()
+
+	mn.publishDiscovery(server)
+
+	updates, err := mn.sStore.Watch(ctx, server.ID)
+	if err != nil {
+		mn.logger.ErrorContext(ctx, "failed to watch server", "error", err, "server", server.ID)
+		mn.publishState(ctx, server)
+		return
+	}
+	go func() {
+		for update := range updates {
+			mn.mu.Lock()
+			mn.servers[update.ID] = update
+			mn.mu.Unlock()
+			mn.publishState(ctx, update)
+		}
+	}()
+}
+
+// forget stops watching the server and removes its retained messages.
+func (mn *MQTTNotifier) forget(id uuid.UUID) {
+	mn.mu.Lock()
+	if cancel, ok := mn.watchers[id]; ok {
+		cancel()
+	}
+	delete(mn.watchers, id)
+	delete(mn.servers, id)
+	mn.mu.Unlock()
+
+	topics := []string{mn.stateTopic(id)}
+	if mn.cfg.Discovery {
+		for _, entity := range discoveryEntities {
+			topics = append(topics, mn.discoveryTopic(entity, id))
+		}
+	}
+	for _, topic := range topics {
+		if err := mn.publish(topic, true, nil); err != nil {
+			mn.logger.Error("failed to clear retained message", "error", err, "topic", topic)
+		}
+		mn.mu.Lock()
+		delete(mn.published, topic)
+		mn.mu.Unlock()
+	}
+}
+
+func (mn *MQTTNotifier) publishState(ctx context.Context, server *model.Server) {
+	state := mn.state(ctx, server)
+	data, err := json.Marshal(state)
+	if err != nil {
+		mn.logger.ErrorContext(ctx, "failed to encode server state", "error", err)
+		return
+	}
+	mn.publishRetained(mn.stateTopic(server.ID), data)
+}
+
+// publishRetained skips messages that equal the last one on the topic.
+func (mn *MQTTNotifier) publishRetained(topic string, data []byte) {
+	mn.mu.Lock()
+	unchanged := mn.published[topic] == string(data)
+	mn.mu.Unlock()
+	if unchanged {
+		return
+	}
+
+	if err := mn.publish(topic, true, data); err != nil {
+		mn.logger.Error("failed to publish retained message", "error", err, "topic", topic)
+		return
+	}
+	mn.mu.Lock()
+	mn.published[topic] = string(data)
+	mn.mu.Unlock()
+}
+
+func (mn *MQTTNotifier) state(ctx context.Context, server *model.Server) ServerState {
+	state := ServerState{
+		ID:            server.ID,
+		Name:          server.Name,
+		Addr:          server.Addr,
+		Cluster:       server.Cluster,
+		Online:        server.Status,
+		PlayerNames:   []string{},
+		WatchedOnline: []string{},
+	}
+	if server.ServerInfo != nil {
+		state.Map = server.ServerInfo.Map
+		state.Players = server.ServerInfo.Players
+		state.MaxPlayers = server.ServerInfo.MaxPlayers
+	}
+	if server.PlayersInfo == nil {
+		return state
+	}
+
+	watched := make(map[string]bool)
+	for _, player := range mn.blacklist.List(ctx) {
+		if player.Name != "" {
+			watched[player.Name] = true
+		}
+	}
+	for _, player := range server.PlayersInfo.Players {
+		if player == nil || player.Name == "" {
+			continue
+		}
+		state.PlayerNames = append(state.PlayerNames, player.Name)
+		if watched[player.Name] {
+			state.WatchedOnline = append(state.WatchedOnline, player.Name)
+		}
+	}
+	sort.Strings(state.PlayerNames)
+	sort.Strings(state.WatchedOnline)
+	return state
+}
@@ -0,0 +1,319 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+	"github.com/google/uuid"
+	"github.com/led0nk/ark-overseer/internal/blacklist"
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/led0nk/ark-overseer/internal/storage"
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// testBroker speaks just enough MQTT 3.1.1 to accept a client, acknowledge
+// its publishes and keep retained messages.
+type testBroker struct {
+	listener net.Listener
+	mu       sync.Mutex
+	connect  *packets.ConnectPacket
+	retained map[string][]byte
+	messages []*packets.PublishPacket
+}
+
+func newTestBroker(t *testing.T) *testBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	b := &testBroker{listener: listener, retained: make(map[string][]byte)}
+	t.Cleanup(func() { _ = listener.Close() })
+	go b.serve()
+	return b
+}
+
+func (b *testBroker) url() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+func (b *testBroker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *testBroker) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+
+		var response packets.ControlPacket
+		switch p := packet.(type) {
+		case *packets.ConnectPacket:
+			b.mu.Lock()
+			b.connect = p
+			b.mu.Unlock()
+			response = packets.NewControlPacket(packets.Connack)
+		case *packets.PublishPacket:
+			b.mu.Lock()
+			b.messages = append(b.messages, p)
+			if p.Retain {
+				if len(p.Payload) == 0 {
+					delete(b.retained, p.TopicName)
+				} else {
+					b.retained[p.TopicName] = p.Payload
+				}
+			}
+			b.mu.Unlock()
+			if p.Qos == 1 {
+				puback := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				puback.MessageID = p.MessageID
+				response = puback
+			}
+		case *packets.PingreqPacket:
+			response = packets.NewControlPacket(packets.Pingresp)
+		case *packets.DisconnectPacket:
+			return
+		}
+
+		if response != nil {
+			if err := response.Write(conn); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (b *testBroker) retainedMessage(topic string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	payload, ok := b.retained[topic]
+	return payload, ok
+}
+
+func (b *testBroker) published(topic string) []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, message := range b.messages {
+		if message.TopicName == topic {
+			return message.Payload
+		}
+	}
+	return nil
+}
+
+func TestMQTTNotifier(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+	broker := newTestBroker(t)
+
+	sStore, err := storage.NewServerStorage(ctx, filepath.Join(dir, "cluster.json"))
+	assert.NoError(t, err)
+	bl, err := blacklist.NewBlacklist(filepath.Join(dir, "blacklist.json"))
+	assert.NoError(t, err)
+	_, err = bl.Create(ctx, &model.BlacklistPlayers{Name: "Raider"})
+	assert.NoError(t, err)
+
+	server, err := sStore.Create(ctx, &model.Server{
+		ID:         uuid.MustParse("0b4c6a1e-24d1-4f34-9fd1-6a3c1a0b1f5e"),
+		Name:       "island",
+		Addr:       "127.0.0.1:27015",
+		Status:     true,
+		ServerInfo: &model.ServerInfo{Map: "TheIsland", Players: 2, MaxPlayers: 70},
+		PlayersInfo: &model.PlayersInfo{Players: []*model.Players{
+			{Name: "Raider"},
+			{Name: "Builder"},
+		}},
+	})
+	assert.NoError(t, err)
+
+	cfg, err := ParseConfig(map[interface{}]interface{}{
+		"broker":    broker.url(),
+		"username":  "overseer",
+		"password":  "secret",
+		"qos":       1,
+		"discovery": true,
+		"topics":    map[interface{}]interface{}{"server.*": "ark/servers"},
+	})
+	assert.NoError(t, err)
+
+	notifier, err := NewMQTTNotifier(ctx, cfg, sStore, bl)
+	assert.NoError(t, err)
+
+	broker.mu.Lock()
+	assert.Equal(t, "overseer", broker.connect.Username)
+	assert.Equal(t, "secret", string(broker.connect.Password))
+	assert.Equal(t, "ark-overseer/status", broker.connect.WillTopic)
+	assert.Equal(t, statusOffline, string(broker.connect.WillMessage))
+	broker.mu.Unlock()
+
+	stateTopic := "ark-overseer/servers/" + server.ID.String() + "/state"
+	assert.Eventually(t, func() bool {
+		_, ok := broker.retainedMessage(stateTopic)
+		return ok
+	}, 2*time.Second, 10*time.Millisecond)
+
+	payload, _ := broker.retainedMessage(stateTopic)
+	var state ServerState
+	assert.NoError(t, json.Unmarshal(payload, &state))
+	assert.True(t, state.Online)
+	assert.Equal(t, 2, state.Players)
+	assert.Equal(t, "TheIsland", state.Map)
+	assert.Equal(t, []string{"Builder", "Raider"}, state.PlayerNames)
+	assert.Equal(t, []string{"Raider"}, state.WatchedOnline)
+
+	status, _ := broker.retainedMessage("ark-overseer/status")
+	assert.Equal(t, statusOnline, string(status))
+
+	discoveryTopic := "homeassistant/binary_sensor/ark_overseer_" + server.ID.String() + "/watched_present/config"
+	payload, ok := broker.retainedMessage(discoveryTopic)
+	assert.True(t, ok)
+	var discovery map[string]interface{}
+	assert.NoError(t, json.Unmarshal(payload, &discovery))
+	assert.Equal(t, stateTopic, discovery["state_topic"])
+	assert.Equal(t, "ark-overseer/status", discovery["availability_topic"])
+
+	notifier.HandleEvent(ctx, events.EventMessage{
+		ID:      7,
+		Type:    events.TypePlayerJoined,
+		Payload: events.PlayerEvent{Player: "Raider", ServerID: server.ID, ServerName: "island"},
+	})
+	payload = broker.published("ark-overseer/events/player/joined")
+	event, err := events.UnmarshalEvent(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), event.ID)
+	playerEvent, ok := event.PlayerEvent()
+	assert.True(t, ok)
+	assert.Equal(t, "Raider", playerEvent.Player)
+
+	notifier.HandleEvent(ctx, events.EventMessage{Type: events.TypeServerDeleted, Payload: server.ID})
+	assert.NotNil(t, broker.published("ark/servers"), "configured topic should be used")
+	_, ok = broker.retainedMessage(stateTopic)
+	assert.False(t, ok, "retained state should be cleared")
+	_, ok = broker.retainedMessage(discoveryTopic)
+	assert.False(t, ok, "discovery should be cleared")
+
+	assert.NoError(t, notifier.Disconnect())
+	status, _ = broker.retainedMessage("ark-overseer/status")
+	assert.Equal(t, statusOffline, string(status))
+}
+
+// TestMQTTBroker runs against a real broker, e.g. a local mosquitto, if
+// MQTT_BROKER is set to its url.
+func TestMQTTBroker(t *testing.T) {
+	url := os.Getenv("MQTT_BROKER")
+	if url == "" {
+		t.Skip("MQTT_BROKER not set")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	sStore, err := storage.NewServerStorage(ctx, filepath.Join(dir, "cluster.json"))
+	assert.NoError(t, err)
+	bl, err := blacklist.NewBlacklist(filepath.Join(dir, "blacklist.json"))
+	assert.NoError(t, err)
+
+	cfg, err := ParseConfig(map[interface{}]interface{}{
+		"broker":      url,
+		"username":    os.Getenv("MQTT_USERNAME"),
+		"password":    os.Getenv("MQTT_PASSWORD"),
+		"topicPrefix": "ark-overseer-test",
+	})
+	assert.NoError(t, err)
+
+	notifier, err := NewMQTTNotifier(ctx, cfg, sStore, bl)
+	assert.NoError(t, err)
+	assert.NoError(t, notifier.Send(ctx, "test message"))
+	assert.NoError(t, notifier.Disconnect())
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		section   map[interface{}]interface{}
+		expected  Config
+		expectErr bool
+	}{
+		{
+			name:    "defaults",
+			section: map[interface{}]interface{}{"broker": "tcp://localhost:1883"},
+			expected: Config{
+				Broker:          "tcp://localhost:1883",
+				ClientID:        defaultClientID,
+				TopicPrefix:     defaultTopicPrefix,
+				DiscoveryPrefix: defaultDiscoveryPrefix,
+				Topics:          map[string]string{},
+			},
+		},
+		{
+			name: "tls and topics",
+			section: map[interface{}]interface{}{
+				"broker":      "ssl://broker:8883",
+				"topicPrefix": "ark",
+				"qos":         2,
+				"insecure":    true,
+				"topics":      map[interface{}]interface{}{"player.*": "ark/alerts"},
+			},
+			expected: Config{
+				Broker:          "ssl://broker:8883",
+				ClientID:        defaultClientID,
+				TopicPrefix:     "ark",
+				QoS:             2,
+				Insecure:        true,
+				DiscoveryPrefix: defaultDiscoveryPrefix,
+				Topics:          map[string]string{"player.*": "ark/alerts"},
+			},
+		},
+		{
+			name:      "missing broker",
+			section:   map[interface{}]interface{}{},
+			expectErr: true,
+		},
+		{
+			name:      "unsupported scheme",
+			section:   map[interface{}]interface{}{"broker": "http://localhost"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid qos",
+			section:   map[interface{}]interface{}{"broker": "tcp://localhost:1883", "qos": 3},
+			expectErr: true,
+		},
+		{
+			name:      "wildcard topic",
+			section:   map[interface{}]interface{}{"broker": "tcp://localhost:1883", "topics": map[interface{}]interface{}{"player.*": "ark/#"}},
+			expectErr: true,
+		},
+		{
+			name:      "cert without key",
+			section:   map[interface{}]interface{}{"broker": "ssl://localhost:8883", "certFile": "client.crt"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig(tt.section)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, cfg)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package mqtt
+
+import (
+	"context"
+
+	"github.com/led0nk/ark-overseer/internal/services/registry"
+)
+
+func init() {
+	registry.Register("mqtt", registry.Definition[Config]{
+		Label: "MQTT",
+		Fields: []registry.Field{
+			{Key: "broker", Label: "Broker", Placeholder: "tcp://localhost:1883", Type: registry.FieldText},
+			{Key: "username", Label: "Username", Placeholder: "overseer", Type: registry.FieldText},
+			{Key: "password", Label: "Password", Placeholder: "Password...", Type: registry.FieldSecret},
+			{Key: "clientID", Label: "Client-ID", Placeholder: "ark-overseer", Type: registry.FieldText},
+			{Key: "topicPrefix", Label: "Topic-Prefix", Placeholder: "ark-overseer", Type: registry.FieldText},
+			{Key: "qos", Label: "QoS", Placeholder: "0, 1 or 2", Type: registry.FieldNumber},
+			{Key: "discovery", Label: "Home Assistant discovery", Placeholder: "", Type: registry.FieldCheckbox},
+		},
+		Parse: ParseConfig,
+		New: func(ctx context.Context, cfg Config, deps registry.Deps) (registry.Notification, error) {
+			notifier, err := NewMQTTNotifier(ctx, cfg, deps.Storage, deps.Blacklist)
+			if err != nil {
+				return nil, err
+			}
+			return notifier, nil
+		},
+	})
+}
@@ -0,0 +1,15 @@
+package services
+
+// The notifier types register themselves with the registry, see
+// registry.Register.
+import (
+	_ "github.com/led0nk/ark-overseer/internal/services/discord"
+	_ "github.com/led0nk/ark-overseer/internal/services/email"
+	_ "github.com/led0nk/ark-overseer/internal/services/gotify"
+	_ "github.com/led0nk/ark-overseer/internal/services/matrix"
+	_ "github.com/led0nk/ark-overseer/internal/services/mqtt"
+	_ "github.com/led0nk/ark-overseer/internal/services/ntfy"
+	_ "github.com/led0nk/ark-overseer/internal/services/slack"
+	_ "github.com/led0nk/ark-overseer/internal/services/telegram"
+	_ "github.com/led0nk/ark-overseer/internal/services/webhook"
+)
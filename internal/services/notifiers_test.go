@@ -0,0 +1,16 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/led0nk/ark-overseer/internal/services/registry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifierTypes(t *testing.T) {
+	var names []string
+	for _, typ := range registry.Types() {
+		names = append(names, typ.Name)
+	}
+	assert.Equal(t, []string{"discord", "email", "gotify", "matrix", "mqtt", "ntfy", "slack", "telegram", "webhook"}, names)
+}
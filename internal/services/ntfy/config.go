@@ -0,0 +1,84 @@
+package ntfy
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/led0nk/ark-overseer/internal/services/push"
+)
+
+const defaultServer = "https://ntfy.sh"
+
+// Config of the ntfy notifier, read from the notification-service section:
+//
+//	ntfy:
+//	  server: https://ntfy.sh
+//	  topic: ark-alerts
+//	  token: tk_...        # or username and password
+//	  icon: https://example.com/overseer.png
+//	  priorities:
+//	    server.online: min
+//	  tags:
+//	    player.joined: [warning, skull]
+type Config struct {
+	Server   string
+	Topic    string
+	Token    string
+	Username string
+	Password string
+	Icon     string
+	Rules    push.Rules
+}
+
+func ParseConfig(section map[interface{}]interface{}) (Config, error) {
+	cfg := Config{Server: defaultServer}
+
+	stringFields := map[string]*string{
+		"server":   &cfg.Server,
+		"topic":    &cfg.Topic,
+		"token":    &cfg.Token,
+		"username": &cfg.Username,
+		"password": &cfg.Password,
+		"icon":     &cfg.Icon,
+	}
+	for key, target := range stringFields {
+		value, ok := section[key]
+		if !ok || value == nil {
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			return Config{}, fmt.Errorf("invalid %s type", key)
+		}
+		if str != "" {
+			*target = str
+		}
+	}
+
+	rules, err := push.ParseRules(section)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.Rules = rules
+
+	return cfg, cfg.Validate()
+}
+
+func (c Config) Validate() error {
+	u, err := url.Parse(c.Server)
+	if err != nil {
+		return fmt.Errorf("invalid server: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid server: unsupported scheme %q", u.Scheme)
+	}
+	if c.Topic == "" || strings.ContainsAny(c.Topic, "/?#") {
+		return fmt.Errorf("invalid topic %q", c.Topic)
+	}
+	if c.Token != "" && c.Username != "" {
+		return errors.New("either token or username can be set")
+	}
+	return nil
+}
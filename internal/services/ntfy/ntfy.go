@@ -0,0 +1,130 @@
+package ntfy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/led0nk/ark-overseer/internal/services/push"
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+const timeout = 10 * time.Second
+
+type NtfyNotifier struct {
+	cfg    Config
+	client *http.Client
+	logger *slog.Logger
+}
+
+// message is the body of a JSON publish request.
+type message struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title,omitempty"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Icon     string   `json:"icon,omitempty"`
+}
+
+func NewNtfyNotifier(ctx context.Context, cfg Config, client *http.Client) (*NtfyNotifier, error) {
+	if client == nil {
+		client = &http.Client{Timeout: timeout}
+	}
+	ntfy := &NtfyNotifier{
+		cfg:    cfg,
+		client: client,
+		logger: slog.Default().WithGroup("ntfy"),
+	}
+	err := ntfy.Connect(ctx)
+	if err != nil {
+		ntfy.logger.ErrorContext(ctx, "failed to connect ntfy notification service", "error", err)
+		return nil, err
+	}
+	return ntfy, nil
+}
+
+func (nn *NtfyNotifier) Topics() []string {
+	return push.Topics
+}
+
+// Connect validates the configuration, ntfy is only called per message.
+func (nn *NtfyNotifier) Connect(ctx context.Context) error {
+	return nn.cfg.Validate()
+}
+
+func (nn *NtfyNotifier) HandleEvent(ctx context.Context, event events.EventMessage) {
+	title, body, ok := push.Format(event)
+	if !ok {
+		return
+	}
+	err := nn.publish(ctx, message{
+		Title:    title,
+		Message:  body,
+		Priority: int(nn.cfg.Rules.Priority(event.Type)),
+		Tags:     nn.cfg.Rules.TagsFor(event.Type),
+	})
+	if err != nil {
+		nn.logger.ErrorContext(ctx, "failed to publish message", "error", err, "type", event.Type)
+	}
+}
+
+func (nn *NtfyNotifier) Send(ctx context.Context, text string) error {
+	err := nn.publish(ctx, message{Message: text})
+	if err != nil {
+		nn.logger.ErrorContext(ctx, "failed to send ntfy message", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (nn *NtfyNotifier) Disconnect() error {
+	nn.client.CloseIdleConnections()
+	return nil
+}
+
+func (nn *NtfyNotifier) publish(ctx context.Context, msg message) error {
+	msg.Topic = nn.cfg.Topic
+	msg.Icon = nn.cfg.Icon
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(nn.cfg.Server, "/"), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	switch {
+	case nn.cfg.Token != "":
+		req.Header.Set("Authorization", "Bearer "+nn.cfg.Token)
+	case nn.cfg.Username != "":
+		req.SetBasicAuth(nn.cfg.Username, nn.cfg.Password)
+	}
+
+	resp, err := nn.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	// errors are JSON like {"code":40101,"http":401,"error":"unauthorized"}
+	var result struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if json.Unmarshal(data, &result) == nil && result.Error != "" {
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, result.Error)
+	}
+	return fmt.Errorf("unexpected status %s", resp.Status)
+}
@@ -0,0 +1,137 @@
+package ntfy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/led0nk/ark-overseer/internal/services/push"
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type testNtfy struct {
+	*httptest.Server
+	mu       sync.Mutex
+	messages []message
+}
+
+func newTestNtfy(t *testing.T, token string) *testNtfy {
+	stub := &testNtfy{}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"code":40101,"http":401,"error":"unauthorized"}`))
+			return
+		}
+		var msg message
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&msg) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		stub.mu.Lock()
+		stub.messages = append(stub.messages, msg)
+		stub.mu.Unlock()
+		_, _ = w.Write([]byte(`{"id":"abc","event":"message"}`))
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+func (s *testNtfy) received() []message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]message(nil), s.messages...)
+}
+
+func TestNtfyNotifier(t *testing.T) {
+	ctx := context.Background()
+	stub := newTestNtfy(t, "tk_token")
+
+	cfg, err := ParseConfig(map[interface{}]interface{}{
+		"server": stub.URL,
+		"topic":  "ark-alerts",
+		"token":  "tk_token",
+		"icon":   "https://example.com/overseer.png",
+		"tags":   map[interface{}]interface{}{"player.joined": []interface{}{"warning", "skull"}},
+	})
+	assert.NoError(t, err)
+	notifier, err := NewNtfyNotifier(ctx, cfg, nil)
+	assert.NoError(t, err)
+
+	notifier.HandleEvent(ctx, events.EventMessage{
+		Type: events.TypePlayerJoined,
+		Payload: events.PlayerEvent{
+			Player:     "Raider",
+			ServerName: "island",
+			Entry:      &model.BlacklistPlayers{Name: "Raider", ThreatLevel: "high"},
+		},
+	})
+	notifier.HandleEvent(ctx, events.EventMessage{
+		Type:    events.TypeServerOffline,
+		Payload: events.ServerEvent{ServerName: "island"},
+	})
+	notifier.HandleEvent(ctx, events.EventMessage{Type: events.TypeServerAdded})
+	assert.NoError(t, notifier.Send(ctx, "hello"))
+
+	received := stub.received()
+	assert.Len(t, received, 3)
+	assert.Equal(t, message{
+		Topic:    "ark-alerts",
+		Title:    "Raider joined island",
+		Message:  "Server: island\nThreat: high",
+		Priority: int(push.PriorityHigh),
+		Tags:     []string{"warning", "skull"},
+		Icon:     "https://example.com/overseer.png",
+	}, received[0])
+	assert.Equal(t, int(push.PriorityUrgent), received[1].Priority)
+	assert.Empty(t, received[1].Tags)
+	assert.Equal(t, "hello", received[2].Message)
+
+	notifier.cfg.Token = "tk_wrong"
+	assert.ErrorContains(t, notifier.Send(ctx, "hello"), "unauthorized")
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		section   map[interface{}]interface{}
+		expectErr bool
+	}{
+		{
+			name:    "defaults",
+			section: map[interface{}]interface{}{"topic": "ark-alerts"},
+		},
+		{
+			name:      "missing topic",
+			section:   map[interface{}]interface{}{"server": "https://ntfy.example.com"},
+			expectErr: true,
+		},
+		{
+			name:      "token and username",
+			section:   map[interface{}]interface{}{"topic": "ark-alerts", "token": "tk_token", "username": "overseer"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid priority",
+			section:   map[interface{}]interface{}{"topic": "ark-alerts", "priorities": map[interface{}]interface{}{"player.*": "loud"}},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig(tt.section)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, defaultServer, cfg.Server)
+		})
+	}
+}
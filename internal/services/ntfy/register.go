@@ -0,0 +1,28 @@
+package ntfy
+
+import (
+	"context"
+
+	"github.com/led0nk/ark-overseer/internal/services/registry"
+)
+
+func init() {
+	registry.Register("ntfy", registry.Definition[Config]{
+		Label: "ntfy",
+		Fields: []registry.Field{
+			{Key: "server", Label: "Server", Placeholder: "https://ntfy.sh", Type: registry.FieldText},
+			{Key: "topic", Label: "Topic", Placeholder: "ark-alerts", Type: registry.FieldText},
+			{Key: "token", Label: "Access-Token", Placeholder: "tk_... (or username and password)", Type: registry.FieldSecret},
+			{Key: "username", Label: "Username", Placeholder: "Username...", Type: registry.FieldText},
+			{Key: "password", Label: "Password", Placeholder: "Password...", Type: registry.FieldSecret},
+		},
+		Parse: ParseConfig,
+		New: func(ctx context.Context, cfg Config, deps registry.Deps) (registry.Notification, error) {
+			notifier, err := NewNtfyNotifier(ctx, cfg, nil)
+			if err != nil {
+				return nil, err
+			}
+			return notifier, nil
+		},
+	})
+}
@@ -0,0 +1,206 @@
+package push
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+type Priority int
+
+const (
+	PriorityMin Priority = iota + 1
+	PriorityLow
+	PriorityDefault
+	PriorityHigh
+	PriorityUrgent
+)
+
+var priorityNames = map[Priority]string{
+	PriorityMin:     "min",
+	PriorityLow:     "low",
+	PriorityDefault: "default",
+	PriorityHigh:    "high",
+	PriorityUrgent:  "urgent",
+}
+
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("Priority(%d)", int(p))
+}
+
+// ParsePriority accepts the names min, low, default, high and urgent or
+// their numbers 1 to 5, like ntfy does.
+func ParsePriority(value interface{}) (Priority, error) {
+	switch v := value.(type) {
+	case int:
+		if v >= int(PriorityMin) && v <= int(PriorityUrgent) {
+			return Priority(v), nil
+		}
+	case string:
+		for priority, name := range priorityNames {
+			if strings.EqualFold(v, name) {
+				return priority, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid priority %v", value)
+}
+
+// DefaultPriorities apply to event types without a configured priority.
+// Player events are only published for watched players.
+var DefaultPriorities = map[string]Priority{
+	events.TypePlayerJoined:  PriorityHigh,
+	events.TypePlayerLeft:    PriorityDefault,
+	events.TypeServerOnline:  PriorityLow,
+	events.TypeServerOffline: PriorityUrgent,
+}
+
+// Topics are the event types Format handles.
+var Topics = events.AlertTopics
+
+// Rules map event type patterns, see events.MatchTopic, to the priority and
+// tags of the push.
+type Rules struct {
+	Priorities map[string]Priority
+	Tags       map[string][]string
+}
+
+// ParseRules reads the priorities and tags of a notifier section:
+//
+//	priorities:
+//	  player.joined: urgent
+//	  server.*: low
+//	tags:
+//	  player.joined: [warning, skull]
+func ParseRules(section map[interface{}]interface{}) (Rules, error) {
+	rules := Rules{
+		Priorities: make(map[string]Priority),
+		Tags:       make(map[string][]string),
+	}
+
+	if value, ok := section["priorities"]; ok && value != nil {
+		priorities, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return Rules{}, errors.New("invalid priorities type")
+		}
+		for key, value := range priorities {
+			pattern, ok := key.(string)
+			if !ok {
+				return Rules{}, fmt.Errorf("invalid priority pattern %v", key)
+			}
+			priority, err := ParsePriority(value)
+			if err != nil {
+				return Rules{}, fmt.Errorf("%s: %w", pattern, err)
+			}
+			rules.Priorities[pattern] = priority
+		}
+	}
+
+	if value, ok := section["tags"]; ok && value != nil {
+		tags, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return Rules{}, errors.New("invalid tags type")
+		}
+		for key, value := range tags {
+			pattern, ok := key.(string)
+			if !ok {
+				return Rules{}, fmt.Errorf("invalid tag pattern %v", key)
+			}
+			switch v := value.(type) {
+			case string:
+				rules.Tags[pattern] = []string{v}
+			case []interface{}:
+				for _, item := range v {
+					tag, ok := item.(string)
+					if !ok {
+						return Rules{}, fmt.Errorf("%s: invalid tag %v", pattern, item)
+					}
+					rules.Tags[pattern] = append(rules.Tags[pattern], tag)
+				}
+			default:
+				return Rules{}, fmt.Errorf("%s: invalid tags type", pattern)
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+// Priority returns the priority of the first matching pattern, exact event
+// types win over wildcards.
+func (r Rules) Priority(eventType string) Priority {
+	if priority, ok := match(r.Priorities, eventType); ok {
+		return priority
+	}
+	if priority, ok := DefaultPriorities[eventType]; ok {
+		return priority
+	}
+	return PriorityDefault
+}
+
+func (r Rules) TagsFor(eventType string) []string {
+	tags, _ := match(r.Tags, eventType)
+	return tags
+}
+
+func match[T any](rules map[string]T, eventType string) (T, bool) {
+	if value, ok := rules[eventType]; ok {
+		return value, true
+	}
+	patterns := make([]string, 0, len(rules))
+	for pattern := range rules {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if events.MatchTopic(pattern, eventType) {
+			return rules[pattern], true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Format returns the title and message of the push, ok is false for events
+// that aren't pushed.
+func Format(event events.EventMessage) (title string, message string, ok bool) {
+	var lines []string
+	switch event.Type {
+	case events.TypePlayerJoined, events.TypePlayerLeft:
+		player, ok := event.PlayerEvent()
+		if !ok {
+			return "", "", false
+		}
+		lines = append(lines, "Server: "+player.ServerName)
+		if event.Type == events.TypePlayerLeft && player.Duration > 0 {
+			lines = append(lines, "Session: "+player.Duration.Round(time.Second).String())
+		}
+		if player.Entry != nil {
+			if player.Entry.ThreatLevel != "" {
+				lines = append(lines, "Threat: "+player.Entry.ThreatLevel)
+			}
+			if player.Entry.Notes != "" {
+				lines = append(lines, "Notes: "+player.Entry.Notes)
+			}
+		}
+	case events.TypeServerOnline, events.TypeServerOffline:
+		server, ok := event.ServerEvent()
+		if !ok {
+			return "", "", false
+		}
+		lines = append(lines, "Address: "+server.ServerAddr)
+		if server.Online {
+			lines = append(lines, fmt.Sprintf("Players: %d/%d", server.Players, server.MaxPlayers))
+		}
+	default:
+		return "", "", false
+	}
+	return event.Summary(), strings.Join(lines, "\n"), true
+}
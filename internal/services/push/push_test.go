@@ -0,0 +1,79 @@
+package push
+
+import (
+	"testing"
+	"time"
+
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules(t *testing.T) {
+	rules, err := ParseRules(map[interface{}]interface{}{
+		"priorities": map[interface{}]interface{}{
+			"player.*":    "min",
+			"player.left": 2,
+		},
+		"tags": map[interface{}]interface{}{
+			"player.*":       []interface{}{"warning", "skull"},
+			"server.offline": "rotating_light",
+		},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		eventType string
+		priority  Priority
+		tags      []string
+	}{
+		{eventType: events.TypePlayerJoined, priority: PriorityMin, tags: []string{"warning", "skull"}},
+		{eventType: events.TypePlayerLeft, priority: PriorityLow, tags: []string{"warning", "skull"}},
+		{eventType: events.TypeServerOffline, priority: PriorityUrgent, tags: []string{"rotating_light"}},
+		{eventType: events.TypeServerOnline, priority: PriorityLow},
+		{eventType: events.TypeServerAdded, priority: PriorityDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			assert.Equal(t, tt.priority, rules.Priority(tt.eventType))
+			assert.Equal(t, tt.tags, rules.TagsFor(tt.eventType))
+		})
+	}
+
+	_, err = ParseRules(map[interface{}]interface{}{"priorities": map[interface{}]interface{}{"player.*": "critical"}})
+	assert.Error(t, err)
+	_, err = ParseRules(map[interface{}]interface{}{"priorities": map[interface{}]interface{}{"player.*": 6}})
+	assert.Error(t, err)
+}
+
+func TestDefaultPriorities(t *testing.T) {
+	var rules Rules
+	assert.Equal(t, PriorityHigh, rules.Priority(events.TypePlayerJoined), "watched players joining is high")
+	assert.Equal(t, PriorityUrgent, rules.Priority(events.TypeServerOffline))
+}
+
+func TestFormat(t *testing.T) {
+	title, message, ok := Format(events.EventMessage{
+		Type: events.TypePlayerLeft,
+		Payload: events.PlayerEvent{
+			Player:     "Raider",
+			ServerName: "island",
+			Duration:   90 * time.Minute,
+			Entry:      &model.BlacklistPlayers{Name: "Raider", ThreatLevel: "high", Notes: "main base"},
+		},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, "Raider left island", title)
+	assert.Equal(t, "Server: island\nSession: 1h30m0s\nThreat: high\nNotes: main base", message)
+
+	title, message, ok = Format(events.EventMessage{
+		Type:    events.TypeServerOnline,
+		Payload: events.ServerEvent{ServerName: "island", ServerAddr: "127.0.0.1:27015", Online: true, Players: 3, MaxPlayers: 70},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, "island is online (3/70)", title)
+	assert.Equal(t, "Address: 127.0.0.1:27015\nPlayers: 3/70", message)
+
+	_, _, ok = Format(events.EventMessage{Type: events.TypeServerAdded})
+	assert.False(t, ok)
+}
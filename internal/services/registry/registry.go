@@ -0,0 +1,141 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/led0nk/ark-overseer/internal/blacklist"
+	"github.com/led0nk/ark-overseer/internal/storage"
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+type Notification interface {
+	Connect(context.Context) error
+	Send(context.Context, string) error
+	HandleEvent(context.Context, events.EventMessage)
+	Disconnect() error
+}
+
+// Deps are shared by the notifiers, a notifier uses what it needs.
+type Deps struct {
+	Storage   storage.Database
+	Blacklist blacklist.Blacklister
+	Events    *events.EventManager
+	// Domain of the overseer UI notifications link to.
+	Domain string
+}
+
+type FieldType string
+
+const (
+	FieldText   FieldType = "text"
+	FieldNumber FieldType = "number"
+	// FieldList is entered comma separated and stored as a list.
+	FieldList     FieldType = "list"
+	FieldCheckbox FieldType = "checkbox"
+	// FieldSecret is never shown on the settings page, leaving it empty
+	// keeps the current value.
+	FieldSecret FieldType = "secret"
+)
+
+// Field is a config key that can be edited on the settings page, any other
+// key is only read from config.yaml.
+type Field struct {
+	Key         string
+	Label       string
+	Placeholder string
+	Type        FieldType
+}
+
+// Definition of a notifier type with its config C.
+type Definition[C any] struct {
+	Label  string
+	Fields []Field
+	// Parse reads and validates the config section of an instance.
+	Parse func(section map[interface{}]interface{}) (C, error)
+	New   func(ctx context.Context, cfg C, deps Deps) (Notification, error)
+}
+
+// Type is a registered notifier type.
+type Type struct {
+	Name   string
+	Label  string
+	Fields []Field
+	parse  func(section map[interface{}]interface{}) (interface{}, error)
+	create func(ctx context.Context, cfg interface{}, deps Deps) (Notification, error)
+}
+
+var (
+	mu    sync.RWMutex
+	types = make(map[string]*Type)
+)
+
+// Register makes a notifier type available to the config and the settings
+// page, it is usually called from the init function of the notifier package.
+// Registering a name twice panics.
+func Register[C any](name string, def Definition[C]) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := types[name]; ok {
+		panic("registry: notifier type " + name + " registered twice")
+	}
+	if def.Parse == nil || def.New == nil {
+		panic("registry: notifier type " + name + " needs Parse and New")
+	}
+	types[name] = &Type{
+		Name:   name,
+		Label:  def.Label,
+		Fields: def.Fields,
+		parse: func(section map[interface{}]interface{}) (interface{}, error) {
+			return def.Parse(section)
+		},
+		create: func(ctx context.Context, cfg interface{}, deps Deps) (Notification, error) {
+			return def.New(ctx, cfg.(C), deps)
+		},
+	}
+}
+
+func Lookup(name string) (*Type, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	t, ok := types[name]
+	return t, ok
+}
+
+// Types returns the registered types sorted by name.
+func Types() []*Type {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	list := make([]*Type, 0, len(types))
+	for _, t := range types {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func (t *Type) Validate(section map[interface{}]interface{}) error {
+	_, err := t.parse(section)
+	if err != nil {
+		return fmt.Errorf("invalid %s config: %w", t.Name, err)
+	}
+	return nil
+}
+
+// New parses the config section and creates a notifier of the type.
+func (t *Type) New(ctx context.Context, section map[interface{}]interface{}, deps Deps) (Notification, error) {
+	cfg, err := t.parse(section)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s config: %w", t.Name, err)
+	}
+	notifier, err := t.create(ctx, cfg, deps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s notifier: %w", t.Name, err)
+	}
+	return notifier, nil
+}
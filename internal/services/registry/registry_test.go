@@ -0,0 +1,67 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfig struct {
+	URL string
+}
+
+type testNotifier struct {
+	cfg  testConfig
+	deps Deps
+}
+
+func (n *testNotifier) Connect(context.Context) error                    { return nil }
+func (n *testNotifier) Send(context.Context, string) error               { return nil }
+func (n *testNotifier) HandleEvent(context.Context, events.EventMessage) {}
+func (n *testNotifier) Disconnect() error                                { return nil }
+
+func parseTestConfig(section map[interface{}]interface{}) (testConfig, error) {
+	url, _ := section["url"].(string)
+	if url == "" {
+		return testConfig{}, errors.New("url required")
+	}
+	return testConfig{URL: url}, nil
+}
+
+func TestRegistry(t *testing.T) {
+	Register("registry-test", Definition[testConfig]{
+		Label:  "Test",
+		Fields: []Field{{Key: "url", Label: "URL", Type: FieldText}},
+		Parse:  parseTestConfig,
+		New: func(ctx context.Context, cfg testConfig, deps Deps) (Notification, error) {
+			if cfg.URL == "fail" {
+				return nil, errors.New("unreachable")
+			}
+			return &testNotifier{cfg: cfg, deps: deps}, nil
+		},
+	})
+
+	typ, ok := Lookup("registry-test")
+	assert.True(t, ok)
+	assert.Equal(t, "Test", typ.Label)
+	assert.Contains(t, Types(), typ)
+	_, ok = Lookup("missing")
+	assert.False(t, ok)
+
+	assert.NoError(t, typ.Validate(map[interface{}]interface{}{"url": "https://example.com"}))
+	assert.EqualError(t, typ.Validate(map[interface{}]interface{}{}), "invalid registry-test config: url required")
+
+	notifier, err := typ.New(context.Background(), map[interface{}]interface{}{"url": "https://example.com"}, Deps{Domain: "example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, &testNotifier{cfg: testConfig{URL: "https://example.com"}, deps: Deps{Domain: "example.com"}}, notifier)
+
+	_, err = typ.New(context.Background(), map[interface{}]interface{}{"url": "fail"}, Deps{})
+	assert.EqualError(t, err, "failed to create registry-test notifier: unreachable")
+
+	assert.Panics(t, func() {
+		Register("registry-test", Definition[testConfig]{Parse: parseTestConfig, New: func(context.Context, testConfig, Deps) (Notification, error) { return nil, nil }})
+	})
+}
@@ -0,0 +1,45 @@
+// Package retry sends requests of notifiers again when the service rate
+// limits them and asks to wait.
+package retry
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+const (
+	// Timeout of a single request.
+	Timeout = 10 * time.Second
+	// MaxAttempts bounds retries of rate limited requests.
+	MaxAttempts = 3
+	// MaxWait caps how long the service may ask to wait.
+	MaxWait = 30 * time.Second
+)
+
+// Do calls attempt until it succeeds, fails without asking to wait or
+// MaxAttempts are used up. attempt returns how long the service asked to
+// wait before the next attempt, at most MaxWait is waited.
+func Do(ctx context.Context, attempt func() (time.Duration, error)) error {
+	for i := 1; ; i++ {
+		wait, err := attempt()
+		if err == nil || wait <= 0 || i == MaxAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(min(wait, MaxWait)):
+		}
+	}
+}
+
+// After reads a Retry-After header in seconds, a second is assumed if it's
+// missing or invalid.
+func After(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 1 {
+		return time.Second
+	}
+	return time.Duration(seconds) * time.Second
+}
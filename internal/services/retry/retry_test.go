@@ -0,0 +1,55 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDo(t *testing.T) {
+	limited := errors.New("rate limited")
+	tests := []struct {
+		name     string
+		wait     time.Duration
+		err      error
+		attempts int
+	}{
+		{name: "success", attempts: 1},
+		{name: "error without wait", err: errors.New("bad request"), attempts: 1},
+		{name: "rate limited", wait: time.Millisecond, err: limited, attempts: MaxAttempts},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := Do(context.Background(), func() (time.Duration, error) {
+				attempts++
+				return tt.wait, tt.err
+			})
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.attempts, attempts)
+		})
+	}
+}
+
+func TestDoCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	attempts := 0
+	err := Do(ctx, func() (time.Duration, error) {
+		attempts++
+		return time.Hour, errors.New("rate limited")
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestAfter(t *testing.T) {
+	assert.Equal(t, 5*time.Second, After("5"))
+	assert.Equal(t, time.Second, After(""))
+	assert.Equal(t, time.Second, After("soon"))
+	assert.Equal(t, 120*time.Second, After("120"), "the wait is capped by Do")
+}
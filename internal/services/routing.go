@@ -0,0 +1,237 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/led0nk/ark-overseer/internal/services/push"
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+// RoutesKey is the entry of the notification-service section holding the
+// routing table, every other entry is a notifier instance.
+const RoutesKey = "routes"
+
+// Route sends matching events to the target instances. Empty conditions
+// match everything, a route matches if all of its conditions do.
+type Route struct {
+	// Events are event type patterns, see events.MatchTopic.
+	Events []string
+	// Servers match the name or cluster of the server.
+	Servers []string
+	// Severity is the lowest severity routed, see Severity.
+	Severity push.Priority
+	Targets  []string
+}
+
+// Routes decide which instances handle an event. Instances that aren't the
+// target of any route handle all events, so adding a route only narrows down
+// its targets.
+type Routes []Route
+
+// ParseRoutes reads the routing table:
+//
+//	routes:
+//	  - events: [player.*]
+//	    servers: [pvp-cluster]
+//	    severity: high
+//	    targets: [raid-alerts]
+//	  - events: [server.*]
+//	    targets: [admins, webhook]
+func ParseRoutes(value interface{}) (Routes, error) {
+	if value == nil {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("invalid routes type")
+	}
+	routes := make(Routes, 0, len(list))
+	for i, item := range list {
+		section, ok := item.(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("route %d: invalid type", i+1)
+		}
+		var route Route
+		fields := map[string]*[]string{
+			"events":  &route.Events,
+			"servers": &route.Servers,
+			"targets": &route.Targets,
+		}
+		for key, target := range fields {
+			values, err := stringList(section[key])
+			if err != nil {
+				return nil, fmt.Errorf("route %d: %s: %w", i+1, key, err)
+			}
+			*target = values
+		}
+		if value, ok := section["severity"]; ok && value != nil && value != "" {
+			severity, err := push.ParsePriority(value)
+			if err != nil {
+				return nil, fmt.Errorf("route %d: %w", i+1, err)
+			}
+			route.Severity = severity
+		}
+		if len(route.Targets) == 0 {
+			return nil, fmt.Errorf("route %d: targets required", i+1)
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// Section turns the routes back into their config representation.
+func (r Routes) Section() []interface{} {
+	list := make([]interface{}, 0, len(r))
+	for _, route := range r {
+		section := map[interface{}]interface{}{"targets": toInterfaces(route.Targets)}
+		if len(route.Events) > 0 {
+			section["events"] = toInterfaces(route.Events)
+		}
+		if len(route.Servers) > 0 {
+			section["servers"] = toInterfaces(route.Servers)
+		}
+		if route.Severity != 0 {
+			section["severity"] = route.Severity.String()
+		}
+		list = append(list, section)
+	}
+	return list
+}
+
+// lifecycleEvents keep the state of notifiers up to date, e.g. the servers
+// MQTT publishes, so they aren't routed.
+var lifecycleEvents = []string{events.TypeServerAdded, events.TypeServerDeleted, events.TypeConfigChanged}
+
+// Allows reports whether the instance handles the event. Lifecycle events
+// reach every instance.
+func (r Routes) Allows(instance string, event events.EventMessage) bool {
+	if slices.Contains(lifecycleEvents, event.Type) {
+		return true
+	}
+	routed := false
+	for _, route := range r {
+		if !slices.Contains(route.Targets, instance) {
+			continue
+		}
+		if route.matches(event) {
+			return true
+		}
+		routed = true
+	}
+	return !routed
+}
+
+func (r Route) matches(event events.EventMessage) bool {
+	if len(r.Events) > 0 && !slices.ContainsFunc(r.Events, func(pattern string) bool {
+		return events.MatchTopic(pattern, event.Type)
+	}) {
+		return false
+	}
+	if len(r.Servers) > 0 {
+		name, cluster := eventServer(event)
+		if !slices.ContainsFunc(r.Servers, func(server string) bool {
+			return server != "" && (strings.EqualFold(server, name) || strings.EqualFold(server, cluster))
+		}) {
+			return false
+		}
+	}
+	return Severity(event) >= r.Severity
+}
+
+func eventServer(event events.EventMessage) (string, string) {
+	if player, ok := event.PlayerEvent(); ok {
+		return player.ServerName, player.Cluster
+	}
+	if server, ok := event.ServerEvent(); ok {
+		return server.ServerName, server.Cluster
+	}
+	if server, ok := event.Server(); ok {
+		return server.Name, server.Cluster
+	}
+	return "", ""
+}
+
+// Severity is the default priority of the event type, see
+// push.DefaultPriorities. Watched players with a high threat level are urgent,
+// those with a low one default.
+func Severity(event events.EventMessage) push.Priority {
+	severity, ok := push.DefaultPriorities[event.Type]
+	if !ok {
+		severity = push.PriorityDefault
+	}
+	if player, ok := event.PlayerEvent(); ok && event.Type == events.TypePlayerJoined && player.Entry != nil {
+		switch strings.ToLower(player.Entry.ThreatLevel) {
+		case "high":
+			severity = push.PriorityUrgent
+		case "low":
+			severity = push.PriorityDefault
+		}
+	}
+	return severity
+}
+
+// Instances returns the names of the notifier instances of the
+// notification-service section.
+func Instances(section map[interface{}]interface{}) []string {
+	var names []string
+	for key := range section {
+		name, ok := key.(string)
+		if ok && name != RoutesKey {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// InstanceType returns the notifier type of an instance, the name is the type
+// unless the instance sets one:
+//
+//	discord:
+//	  token: ...
+//	raid-alerts:
+//	  type: discord
+//	  token: ...
+func InstanceType(name string, section map[interface{}]interface{}) string {
+	if typ, ok := section["type"].(string); ok && typ != "" {
+		return typ
+	}
+	return name
+}
+
+// stringList reads a single string or a list of them.
+func stringList(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		return []string{v}, nil
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid value %v", item)
+			}
+			list = append(list, str)
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("invalid type %T", value)
+	}
+}
+
+func toInterfaces(list []string) []interface{} {
+	values := make([]interface{}, 0, len(list))
+	for _, item := range list {
+		values = append(values, item)
+	}
+	return values
+}
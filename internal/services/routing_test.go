@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/led0nk/ark-overseer/internal/services/push"
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutes(t *testing.T) {
+	routes, err := ParseRoutes([]interface{}{
+		map[interface{}]interface{}{
+			"events":   []interface{}{"player.*"},
+			"servers":  "pvp-cluster",
+			"severity": "urgent",
+			"targets":  []interface{}{"raid-alerts"},
+		},
+		map[interface{}]interface{}{
+			"events":  "server.*",
+			"targets": []interface{}{"admins", "webhook"},
+		},
+		map[interface{}]interface{}{
+			"events":  "player.joined",
+			"targets": "webhook",
+		},
+	})
+	assert.NoError(t, err)
+
+	player := func(eventType, cluster, threatLevel string) events.EventMessage {
+		return events.EventMessage{Type: eventType, Payload: events.PlayerEvent{
+			Player:     "Raider",
+			ServerName: "island",
+			Cluster:    cluster,
+			Entry:      &model.BlacklistPlayers{Name: "Raider", ThreatLevel: threatLevel},
+		}}
+	}
+	server := events.EventMessage{Type: events.TypeServerOffline, Payload: events.ServerEvent{ServerName: "island"}}
+
+	tests := []struct {
+		name     string
+		instance string
+		event    events.EventMessage
+		expected bool
+	}{
+		{name: "high threat on cluster", instance: "raid-alerts", event: player(events.TypePlayerJoined, "pvp-cluster", "high"), expected: true},
+		{name: "medium threat on cluster", instance: "raid-alerts", event: player(events.TypePlayerJoined, "pvp-cluster", "medium")},
+		{name: "other cluster", instance: "raid-alerts", event: player(events.TypePlayerJoined, "pve-cluster", "high")},
+		{name: "server event", instance: "raid-alerts", event: server},
+		{name: "admins get server events", instance: "admins", event: server, expected: true},
+		{name: "admins don't get player events", instance: "admins", event: player(events.TypePlayerJoined, "pvp-cluster", "high")},
+		{name: "webhook gets joins", instance: "webhook", event: player(events.TypePlayerJoined, "pve-cluster", ""), expected: true},
+		{name: "webhook doesn't get leaves", instance: "webhook", event: player(events.TypePlayerLeft, "pve-cluster", "")},
+		{name: "raid-alerts get added servers", instance: "raid-alerts", event: events.EventMessage{Type: events.TypeServerAdded, Payload: &model.Server{Name: "island"}}, expected: true},
+		{name: "raid-alerts get deleted servers", instance: "raid-alerts", event: events.EventMessage{Type: events.TypeServerDeleted}, expected: true},
+		{name: "raid-alerts get config changes", instance: "raid-alerts", event: events.EventMessage{Type: events.TypeConfigChanged}, expected: true},
+		{name: "unrouted instance gets everything", instance: "discord", event: player(events.TypePlayerLeft, "", ""), expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, routes.Allows(tt.instance, tt.event))
+		})
+	}
+
+	parsed, err := ParseRoutes(routes.Section())
+	assert.NoError(t, err)
+	assert.Equal(t, routes, parsed)
+}
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := ParseRoutes(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, routes)
+
+	_, err = ParseRoutes([]interface{}{map[interface{}]interface{}{"events": "player.*"}})
+	assert.EqualError(t, err, "route 1: targets required")
+
+	_, err = ParseRoutes([]interface{}{map[interface{}]interface{}{"targets": "discord", "severity": "loud"}})
+	assert.EqualError(t, err, "route 1: invalid priority loud")
+
+	_, err = ParseRoutes(map[interface{}]interface{}{"targets": "discord"})
+	assert.Error(t, err)
+}
+
+func TestSeverity(t *testing.T) {
+	joined := func(threatLevel string) events.EventMessage {
+		return events.EventMessage{Type: events.TypePlayerJoined, Payload: events.PlayerEvent{
+			Entry: &model.BlacklistPlayers{ThreatLevel: threatLevel},
+		}}
+	}
+	assert.Equal(t, push.PriorityUrgent, Severity(joined("High")))
+	assert.Equal(t, push.PriorityHigh, Severity(joined("medium")))
+	assert.Equal(t, push.PriorityDefault, Severity(joined("low")))
+	assert.Equal(t, push.PriorityLow, Severity(events.EventMessage{Type: events.TypeServerOnline}))
+	assert.Equal(t, push.PriorityDefault, Severity(events.EventMessage{Type: events.TypeServerAdded}))
+}
+
+func TestInstances(t *testing.T) {
+	section := map[interface{}]interface{}{
+		"discord":     map[interface{}]interface{}{"token": "a"},
+		"raid-alerts": map[interface{}]interface{}{"type": "discord", "token": "b"},
+		RoutesKey:     []interface{}{},
+	}
+	assert.Equal(t, []string{"discord", "raid-alerts"}, Instances(section))
+	assert.Equal(t, "discord", InstanceType("raid-alerts", section["raid-alerts"].(map[interface{}]interface{})))
+	assert.Equal(t, "discord", InstanceType("discord", section["discord"].(map[interface{}]interface{})))
+}
+
+func TestInvalidRoutesKeepPrevious(t *testing.T) {
+	sm := NewServiceManager(events.NewEventManager(), &sync.WaitGroup{}, nil, nil, "")
+	invalid := map[interface{}]interface{}{RoutesKey: []interface{}{map[interface{}]interface{}{"events": "player.*"}}}
+
+	// invalid from the start, nothing is routed
+	sm.parseRoutes(context.Background(), invalid)
+	assert.False(t, sm.routesParsed)
+	assert.Empty(t, sm.routes)
+
+	sm.parseRoutes(context.Background(), map[interface{}]interface{}{
+		RoutesKey: []interface{}{map[interface{}]interface{}{"events": "server.*", "targets": "mqtt"}},
+	})
+	assert.True(t, sm.routesParsed)
+	expected := Routes{{Events: []string{"server.*"}, Targets: []string{"mqtt"}}}
+	assert.Equal(t, expected, sm.routes)
+
+	sm.parseRoutes(context.Background(), invalid)
+	assert.Equal(t, expected, sm.routes)
+}
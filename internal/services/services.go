@@ -2,41 +2,70 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
+	"time"
 
-	"github.com/led0nk/ark-overseer/internal/services/discord"
+	"github.com/led0nk/ark-overseer/internal/blacklist"
+	"github.com/led0nk/ark-overseer/internal/services/registry"
+	"github.com/led0nk/ark-overseer/internal/storage"
 	"github.com/led0nk/ark-overseer/pkg/config"
 	"github.com/led0nk/ark-overseer/pkg/events"
 )
 
-type Notification interface {
-	Connect(context.Context) error
-	Send(context.Context, string) error
-	HandleEvent(context.Context, events.EventMessage)
-	Disconnect() error
+// notifierBuffer is the channel size of notifiers, they never delay
+// publishing. Whatever doesn't fit is replayed from the journal.
+const notifierBuffer = 64
+
+// defaultReplayAge is how old events replayed after a downtime may be, older
+// ones are outdated, e.g. players that have long left again.
+const defaultReplayAge = 5 * time.Minute
+
+type Notification = registry.Notification
+
+// TopicFilter is implemented by notifications that only handle some event
+// types, they aren't woken up for any other event.
+type TopicFilter interface {
+	Topics() []string
 }
 
 type ServiceManager struct {
 	services   map[string]Notification
+	replayAge  map[string]time.Duration
 	cancelFunc map[string]context.CancelFunc
 	mu         sync.Mutex
 	initWg     *sync.WaitGroup
 	logger     *slog.Logger
 	em         *events.EventManager
+	sStore     storage.Database
+	blacklist  blacklist.Blacklister
+	domain     string
+	routes     Routes
+	// routesParsed is set once a valid routing table was read, before that an
+	// invalid one blocks all events but lifecycle events.
+	routesParsed bool
 }
 
 func NewServiceManager(
 	em *events.EventManager,
 	initWg *sync.WaitGroup,
+	sStore storage.Database,
+	blacklist blacklist.Blacklister,
+	domain string,
 ) *ServiceManager {
 	return &ServiceManager{
 		services:   make(map[string]Notification),
+		replayAge:  make(map[string]time.Duration),
 		cancelFunc: make(map[string]context.CancelFunc),
 		logger:     slog.Default().WithGroup("serviceManager"),
 		em:         em,
 		initWg:     initWg,
+		sStore:     sStore,
+		blacklist:  blacklist,
+		domain:     domain,
 	}
 }
 
@@ -45,7 +74,7 @@ func (sm *ServiceManager) HandleEvent(ctx context.Context, event events.EventMes
 	defer sm.mu.Unlock()
 
 	switch event.Type {
-	case "init.services":
+	case events.TypeInitServices:
 		defer sm.initWg.Done()
 
 		cfg, ok := event.Payload.(*config.Config)
@@ -59,20 +88,14 @@ func (sm *ServiceManager) HandleEvent(ctx context.Context, event events.EventMes
 			sm.logger.Error("failed to get section from config", "error", err)
 			return
 		}
+		sm.parseRoutes(ctx, nService)
 		for key, value := range nService {
-			switch key {
-			case "discord":
-				err := sm.createDiscordService(ctx, value)
-				if err != nil {
-					sm.logger.ErrorContext(ctx, "failed to create discord service", "error", err)
-					continue
-				}
-			}
+			sm.createService(ctx, key, value)
 		}
 		sm.createServices()
 
-	case "config.changed":
-		sectionMap, ok := event.Payload.(map[interface{}]interface{})
+	case events.TypeConfigChanged:
+		sectionMap, ok := event.Section()
 		if !ok {
 			sm.logger.ErrorContext(ctx, "invalid payload type", "error", event.Type)
 			return
@@ -92,52 +115,106 @@ func (sm *ServiceManager) HandleEvent(ctx context.Context, event events.EventMes
 		}
 		sm.deleteServices()
 
+		sm.parseRoutes(ctx, sectionMap)
 		//NOTE: range over sectionMap for notification services
 		for k, v := range sectionMap {
-			switch k {
-			case "discord":
-				err := sm.createDiscordService(ctx, v)
-				if err != nil {
-					sm.logger.ErrorContext(ctx, "failed to create discord service", "error", err)
-					continue
-				}
-			}
+			sm.createService(ctx, k, v)
 		}
 		sm.createServices()
 	}
 }
 
-func (sm *ServiceManager) createDiscordService(ctx context.Context, v interface{}) error {
-
-	newConfig, ok := v.(map[interface{}]interface{})
+// createService creates the notifier instance, its type is taken from the
+// name or the type entry, see InstanceType.
+func (sm *ServiceManager) createService(ctx context.Context, key interface{}, value interface{}) {
+	name, ok := key.(string)
+	if !ok || name == RoutesKey {
+		return
+	}
+	section, ok := value.(map[interface{}]interface{})
 	if !ok {
-		return fmt.Errorf("invalid payload type")
+		sm.logger.ErrorContext(ctx, "failed to create notification service", "error", "invalid payload type", "service", name)
+		return
 	}
 
-	token, ok := newConfig["token"].(string)
+	typ, ok := registry.Lookup(InstanceType(name, section))
 	if !ok {
-		return fmt.Errorf("invalid token type")
+		sm.logger.WarnContext(ctx, "unknown notification service type", "service", name, "type", InstanceType(name, section))
+		return
 	}
+	replayAge, err := parseReplayAge(section)
+	if err != nil {
+		sm.logger.ErrorContext(ctx, "failed to create notification service", "error", err, "service", name)
+		return
+	}
+	notifier, err := typ.New(ctx, section, registry.Deps{
+		Storage:   sm.sStore,
+		Blacklist: sm.blacklist,
+		Events:    sm.em,
+		Domain:    sm.domain,
+	})
+	if err != nil {
+		sm.logger.ErrorContext(ctx, "failed to create notification service", "error", err, "service", name)
+		return
+	}
+	sm.services[name] = notifier
+	sm.replayAge[name] = replayAge
+}
 
-	channelID, ok := newConfig["channelID"].(string)
+// parseReplayAge reads the replayAge of an instance, the max age of events
+// it handles after a downtime. "0" handles all of them.
+func parseReplayAge(section map[interface{}]interface{}) (time.Duration, error) {
+	value, ok := section["replayAge"]
+	if !ok || value == nil {
+		return defaultReplayAge, nil
+	}
+	str, ok := value.(string)
 	if !ok {
-		return fmt.Errorf("invalid channelID type")
+		return 0, errors.New("invalid replayAge type")
+	}
+	age, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid replayAge: %w", err)
 	}
+	return age, nil
+}
 
-	var err error
-	newDiscord, err := discord.NewDiscordNotifier(ctx, token, channelID)
+// parseRoutes reads the routing table. An invalid one keeps the previous
+// routes, so events don't reach notifiers they weren't routed to.
+func (sm *ServiceManager) parseRoutes(ctx context.Context, section map[interface{}]interface{}) {
+	routes, err := ParseRoutes(section[RoutesKey])
 	if err != nil {
-		return fmt.Errorf("failed to create discord notifier: %w", err)
+		sm.logger.ErrorContext(ctx, "invalid routes, keeping the previous ones", "error", err)
+		return
+	}
+	sm.routes = routes
+	sm.routesParsed = true
+	for _, route := range routes {
+		for _, target := range route.Targets {
+			if _, ok := section[target]; !ok {
+				sm.logger.WarnContext(ctx, "route targets unknown notifier", "target", target)
+			}
+		}
 	}
-	sm.services["discord"] = newDiscord
-	return nil
 }
 
 func (sm *ServiceManager) createServices() {
 	for serviceName, service := range sm.services {
 		ctx, cancel := context.WithCancel(context.Background())
 		sm.cancelFunc[serviceName] = cancel
-		go sm.em.StartListening(ctx, service, serviceName, func() {})
+		opts := []events.SubscribeOption{
+			events.Durable(),
+			events.MaxReplayAge(sm.replayAge[serviceName]),
+			events.Buffer(notifierBuffer),
+		}
+		if filter, ok := service.(TopicFilter); ok {
+			opts = append(opts, events.Topics(filter.Topics()...))
+		}
+		var handler events.EventHandler = service
+		if len(sm.routes) > 0 || !sm.routesParsed {
+			handler = &routedNotification{Notification: service, name: serviceName, routes: sm.routes, blocked: !sm.routesParsed}
+		}
+		go sm.em.StartListening(ctx, handler, serviceName, func() {}, opts...)
 	}
 }
 
@@ -146,5 +223,25 @@ func (sm *ServiceManager) deleteServices() {
 		cancel()
 		delete(sm.cancelFunc, serviceName)
 		delete(sm.services, serviceName)
+		delete(sm.replayAge, serviceName)
+	}
+}
+
+// routedNotification only passes the events routed to the instance on.
+type routedNotification struct {
+	Notification
+	name   string
+	routes Routes
+	// blocked only passes lifecycle events on, the routes were invalid from
+	// the start.
+	blocked bool
+}
+
+func (n *routedNotification) HandleEvent(ctx context.Context, event events.EventMessage) {
+	if n.blocked && !slices.Contains(lifecycleEvents, event.Type) {
+		return
+	}
+	if n.routes.Allows(n.name, event) {
+		n.Notification.HandleEvent(ctx, event)
 	}
 }
@@ -0,0 +1,101 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+// block is a Block Kit layout block, only the fields used here are set.
+type block struct {
+	Type     string  `json:"type"`
+	Text     *text   `json:"text,omitempty"`
+	Elements []*text `json:"elements,omitempty"`
+}
+
+type text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// message is the body of an incoming webhook or chat.postMessage request.
+// Text is the fallback shown in notifications.
+type message struct {
+	Channel string  `json:"channel,omitempty"`
+	Text    string  `json:"text"`
+	Blocks  []block `json:"blocks,omitempty"`
+}
+
+func sectionBlock(markdown string) block {
+	return block{Type: "section", Text: &text{Type: "mrkdwn", Text: markdown}}
+}
+
+func contextBlock(parts ...string) block {
+	b := block{Type: "context"}
+	for _, part := range parts {
+		b.Elements = append(b.Elements, &text{Type: "mrkdwn", Text: part})
+	}
+	return b
+}
+
+// escape replaces the characters Slack uses for links and mentions.
+func escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// timestamp renders in the local time of the reader.
+func timestamp(event events.EventMessage) string {
+	if event.Timestamp.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("<!date^%d^{date_short_pretty} {time_secs}|%s>",
+		event.Timestamp.Unix(), event.Timestamp.UTC().Format("2006-01-02 15:04:05 UTC"))
+}
+
+// formatEvent returns the message for the event, ok is false for events
+// that aren't sent to Slack.
+func formatEvent(event events.EventMessage) (message, bool) {
+	var blocks []block
+	var details []string
+
+	switch event.Type {
+	case events.TypePlayerJoined, events.TypePlayerLeft:
+		player, ok := event.PlayerEvent()
+		if !ok {
+			return message{}, false
+		}
+		if event.Type == events.TypePlayerJoined {
+			blocks = append(blocks, sectionBlock(fmt.Sprintf(":arrow_right: *%s* joined *%s*", escape(player.Player), escape(player.ServerName))))
+		} else {
+			blocks = append(blocks, sectionBlock(fmt.Sprintf(":arrow_left: *%s* left *%s*", escape(player.Player), escape(player.ServerName))))
+			if player.Duration > 0 {
+				details = append(details, "Session: "+player.Duration.Round(time.Second).String())
+			}
+		}
+
+	case events.TypeServerOnline, events.TypeServerOffline:
+		server, ok := event.ServerEvent()
+		if !ok {
+			return message{}, false
+		}
+		if server.Online {
+			blocks = append(blocks, sectionBlock(fmt.Sprintf(":large_green_circle: *%s* is online", escape(server.ServerName))))
+			details = append(details, fmt.Sprintf("Players: %d/%d", server.Players, server.MaxPlayers))
+		} else {
+			blocks = append(blocks, sectionBlock(fmt.Sprintf(":red_circle: *%s* is offline", escape(server.ServerName))))
+		}
+
+	default:
+		return message{}, false
+	}
+
+	if ts := timestamp(event); ts != "" {
+		details = append(details, ts)
+	}
+	if len(details) > 0 {
+		blocks = append(blocks, contextBlock(details...))
+	}
+	return message{Text: escape(event.Summary()), Blocks: blocks}, true
+}
@@ -0,0 +1,90 @@
+package slack
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+const defaultAPIURL = "https://slack.com/api"
+
+// Config of the slack notifier, read from the notification-service section.
+// Either an incoming webhook or a bot token with a channel is required:
+//
+//	slack:
+//	  webhookURL: https://hooks.slack.com/services/T000/B000/XXXX
+//
+//	slack:
+//	  token: xoxb-...
+//	  channel: C0123456789
+type Config struct {
+	WebhookURL string
+	Token      string
+	Channel    string
+	// APIURL is the base of the Web API, chat.postMessage is appended.
+	APIURL string
+}
+
+func ParseConfig(section map[interface{}]interface{}) (Config, error) {
+	cfg := Config{APIURL: defaultAPIURL}
+
+	stringFields := map[string]*string{
+		"webhookURL": &cfg.WebhookURL,
+		"token":      &cfg.Token,
+		"channel":    &cfg.Channel,
+		"apiURL":     &cfg.APIURL,
+	}
+	for key, target := range stringFields {
+		value, ok := section[key]
+		if !ok || value == nil {
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			return Config{}, fmt.Errorf("invalid %s type", key)
+		}
+		if str != "" {
+			*target = str
+		}
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func (c Config) Validate() error {
+	switch {
+	case c.WebhookURL == "" && c.Token == "":
+		return errors.New("webhookURL or token required")
+	case c.Token != "" && c.Channel == "":
+		return errors.New("channel required with token")
+	}
+
+	if c.WebhookURL != "" {
+		if err := validateURL(c.WebhookURL); err != nil {
+			return fmt.Errorf("invalid webhookURL: %w", err)
+		}
+	}
+	if c.Token != "" {
+		if err := validateURL(c.APIURL); err != nil {
+			return fmt.Errorf("invalid apiURL: %w", err)
+		}
+	}
+	return nil
+}
+
+// usesAPI reports whether messages are sent with chat.postMessage instead
+// of the incoming webhook.
+func (c Config) usesAPI() bool {
+	return c.Token != ""
+}
+
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	return nil
+}
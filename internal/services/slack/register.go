@@ -0,0 +1,26 @@
+package slack
+
+import (
+	"context"
+
+	"github.com/led0nk/ark-overseer/internal/services/registry"
+)
+
+func init() {
+	registry.Register("slack", registry.Definition[Config]{
+		Label: "Slack",
+		Fields: []registry.Field{
+			{Key: "webhookURL", Label: "Webhook-URL", Placeholder: "https://hooks.slack.com/services/...", Type: registry.FieldSecret},
+			{Key: "token", Label: "Bot-Token", Placeholder: "xoxb-... (instead of a webhook)", Type: registry.FieldSecret},
+			{Key: "channel", Label: "Channel", Placeholder: "Channel-ID for the bot token...", Type: registry.FieldText},
+		},
+		Parse: ParseConfig,
+		New: func(ctx context.Context, cfg Config, deps registry.Deps) (registry.Notification, error) {
+			notifier, err := NewSlackNotifier(ctx, cfg, nil)
+			if err != nil {
+				return nil, err
+			}
+			return notifier, nil
+		},
+	})
+}
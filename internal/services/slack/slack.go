@@ -0,0 +1,138 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/led0nk/ark-overseer/internal/services/retry"
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+type SlackNotifier struct {
+	cfg    Config
+	client *http.Client
+	logger *slog.Logger
+}
+
+// NewSlackNotifier sends messages through the incoming webhook or, if a
+// token is configured, chat.postMessage.
+func NewSlackNotifier(ctx context.Context, cfg Config, client *http.Client) (*SlackNotifier, error) {
+	if client == nil {
+		client = &http.Client{Timeout: retry.Timeout}
+	}
+	slack := &SlackNotifier{
+		cfg:    cfg,
+		client: client,
+		logger: slog.Default().WithGroup("slack"),
+	}
+	err := slack.Connect(ctx)
+	if err != nil {
+		slack.logger.ErrorContext(ctx, "failed to connect slack notification service", "error", err)
+		return nil, err
+	}
+	return slack, nil
+}
+
+func (sn *SlackNotifier) Topics() []string {
+	return events.AlertTopics
+}
+
+// Connect validates the configuration, Slack is only called per message.
+func (sn *SlackNotifier) Connect(ctx context.Context) error {
+	return sn.cfg.Validate()
+}
+
+func (sn *SlackNotifier) HandleEvent(ctx context.Context, event events.EventMessage) {
+	msg, ok := formatEvent(event)
+	if !ok {
+		return
+	}
+	err := sn.post(ctx, msg)
+	if err != nil {
+		sn.logger.ErrorContext(ctx, "failed to send message", "error", err, "type", event.Type)
+	}
+}
+
+func (sn *SlackNotifier) Send(ctx context.Context, text string) error {
+	err := sn.post(ctx, message{Text: text})
+	if err != nil {
+		sn.logger.ErrorContext(ctx, "failed to send slack message", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (sn *SlackNotifier) Disconnect() error {
+	sn.client.CloseIdleConnections()
+	return nil
+}
+
+// post sends the message and waits for Retry-After if Slack rate limits it.
+func (sn *SlackNotifier) post(ctx context.Context, msg message) error {
+	url := sn.cfg.WebhookURL
+	if sn.cfg.usesAPI() {
+		url = strings.TrimSuffix(sn.cfg.APIURL, "/") + "/chat.postMessage"
+		msg.Channel = sn.cfg.Channel
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return retry.Do(ctx, func() (time.Duration, error) {
+		return sn.do(ctx, url, body)
+	})
+}
+
+// do sends a single request, retryAfter is set if it was rate limited.
+func (sn *SlackNotifier) do(ctx context.Context, url string, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if sn.cfg.usesAPI() {
+		req.Header.Set("Authorization", "Bearer "+sn.cfg.Token)
+	}
+
+	resp, err := sn.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return retry.After(resp.Header.Get("Retry-After")), errors.New("rate limited")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if !sn.cfg.usesAPI() {
+		return 0, nil
+	}
+
+	// the Web API answers errors with 200 and ok set to false
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return 0, fmt.Errorf("invalid response: %w", err)
+	}
+	if !result.OK {
+		return 0, fmt.Errorf("chat.postMessage failed: %s", result.Error)
+	}
+	return 0, nil
+}
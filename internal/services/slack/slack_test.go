@@ -0,0 +1,250 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// testSlack stands in for incoming webhooks and the Web API.
+type testSlack struct {
+	*httptest.Server
+	mu          sync.Mutex
+	messages    []message
+	auth        []string
+	rateLimited int
+}
+
+func newTestSlack(t *testing.T) *testSlack {
+	s := &testSlack{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /services/hook", func(w http.ResponseWriter, r *http.Request) {
+		if !s.receive(w, r) {
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("POST /api/chat.postMessage", func(w http.ResponseWriter, r *http.Request) {
+		if !s.receive(w, r) {
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer xoxb-token" {
+			_, _ = w.Write([]byte(`{"ok":false,"error":"invalid_auth"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *testSlack) receive(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rateLimited > 0 {
+		s.rateLimited--
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return false
+	}
+	var msg message
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return false
+	}
+	s.messages = append(s.messages, msg)
+	s.auth = append(s.auth, r.Header.Get("Authorization"))
+	return true
+}
+
+func (s *testSlack) received() []message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]message(nil), s.messages...)
+}
+
+func TestSlackWebhook(t *testing.T) {
+	ctx := context.Background()
+	stub := newTestSlack(t)
+
+	cfg, err := ParseConfig(map[interface{}]interface{}{"webhookURL": stub.URL + "/services/hook"})
+	assert.NoError(t, err)
+	notifier, err := NewSlackNotifier(ctx, cfg, nil)
+	assert.NoError(t, err)
+
+	notifier.HandleEvent(ctx, events.EventMessage{
+		Type:      events.TypePlayerJoined,
+		Timestamp: time.Unix(1700000000, 0),
+		Payload:   events.PlayerEvent{Player: "Raider", ServerName: "island"},
+	})
+	notifier.HandleEvent(ctx, events.EventMessage{Type: events.TypeServerAdded})
+	assert.NoError(t, notifier.Send(ctx, "hello"))
+
+	received := stub.received()
+	assert.Len(t, received, 2, "unsupported events are skipped")
+	assert.Equal(t, "Raider joined island", received[0].Text)
+	assert.Equal(t, ":arrow_right: *Raider* joined *island*", received[0].Blocks[0].Text.Text)
+	assert.Contains(t, received[0].Blocks[1].Elements[0].Text, "<!date^1700000000^")
+	assert.Empty(t, received[0].Channel)
+	assert.Equal(t, "hello", received[1].Text)
+	assert.Empty(t, stub.auth[0])
+	assert.NoError(t, notifier.Disconnect())
+}
+
+func TestSlackPostMessage(t *testing.T) {
+	ctx := context.Background()
+	stub := newTestSlack(t)
+
+	cfg, err := ParseConfig(map[interface{}]interface{}{
+		"token":   "xoxb-token",
+		"channel": "C0123456789",
+		"apiURL":  stub.URL + "/api",
+	})
+	assert.NoError(t, err)
+	notifier, err := NewSlackNotifier(ctx, cfg, nil)
+	assert.NoError(t, err)
+
+	stub.mu.Lock()
+	stub.rateLimited = 1
+	stub.mu.Unlock()
+	assert.NoError(t, notifier.Send(ctx, "hello"), "rate limited requests are retried")
+	received := stub.received()
+	assert.Len(t, received, 1)
+	assert.Equal(t, "C0123456789", received[0].Channel)
+	assert.Equal(t, "Bearer xoxb-token", stub.auth[0])
+
+	notifier.cfg.Token = "xoxb-invalid"
+	err = notifier.Send(ctx, "hello")
+	assert.ErrorContains(t, err, "invalid_auth")
+}
+
+func TestFormatEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    events.EventMessage
+		text     string
+		section  string
+		details  []string
+		expectOK bool
+	}{
+		{
+			name: "player left",
+			event: events.EventMessage{
+				Type:    events.TypePlayerLeft,
+				Payload: events.PlayerEvent{Player: "<Raider>", ServerName: "island", Duration: 90*time.Minute + 300*time.Millisecond},
+			},
+			text:     "&lt;Raider&gt; left island",
+			section:  ":arrow_left: *&lt;Raider&gt;* left *island*",
+			details:  []string{"Session: 1h30m0s"},
+			expectOK: true,
+		},
+		{
+			name: "server online",
+			event: events.EventMessage{
+				Type:    events.TypeServerOnline,
+				Payload: events.ServerEvent{ServerName: "island", Online: true, Players: 3, MaxPlayers: 70},
+			},
+			text:     "island is online (3/70)",
+			section:  ":large_green_circle: *island* is online",
+			details:  []string{"Players: 3/70"},
+			expectOK: true,
+		},
+		{
+			name: "server offline",
+			event: events.EventMessage{
+				Type:    events.TypeServerOffline,
+				Payload: events.ServerEvent{ServerName: "island"},
+			},
+			text:     "island is offline",
+			section:  ":red_circle: *island* is offline",
+			expectOK: true,
+		},
+		{
+			name:  "invalid payload",
+			event: events.EventMessage{Type: events.TypePlayerJoined, Payload: "Raider"},
+		},
+		{
+			name:  "unsupported type",
+			event: events.EventMessage{Type: events.TypeInit},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, ok := formatEvent(tt.event)
+			assert.Equal(t, tt.expectOK, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, tt.text, msg.Text)
+			assert.Equal(t, tt.section, msg.Blocks[0].Text.Text)
+			var details []string
+			if len(msg.Blocks) > 1 {
+				for _, element := range msg.Blocks[1].Elements {
+					details = append(details, element.Text)
+				}
+			}
+			assert.Equal(t, tt.details, details)
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		section   map[interface{}]interface{}
+		expected  Config
+		expectErr bool
+	}{
+		{
+			name:     "webhook",
+			section:  map[interface{}]interface{}{"webhookURL": "https://hooks.slack.com/services/T/B/X", "token": ""},
+			expected: Config{WebhookURL: "https://hooks.slack.com/services/T/B/X", APIURL: defaultAPIURL},
+		},
+		{
+			name:     "token",
+			section:  map[interface{}]interface{}{"token": "xoxb-token", "channel": "C0123456789"},
+			expected: Config{Token: "xoxb-token", Channel: "C0123456789", APIURL: defaultAPIURL},
+		},
+		{
+			name:      "empty",
+			section:   map[interface{}]interface{}{},
+			expectErr: true,
+		},
+		{
+			name:      "token without channel",
+			section:   map[interface{}]interface{}{"token": "xoxb-token"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid webhook url",
+			section:   map[interface{}]interface{}{"webhookURL": "hooks.slack.com"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid type",
+			section:   map[interface{}]interface{}{"webhookURL": 42},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig(tt.section)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, cfg)
+		})
+	}
+}
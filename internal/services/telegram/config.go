@@ -0,0 +1,124 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const defaultBaseURL = "https://api.telegram.org"
+
+// Chat is a chat messages are sent to, ThreadID selects a topic in forum
+// supergroups.
+type Chat struct {
+	ID       string
+	ThreadID int
+}
+
+// Config of the telegram notifier, read from the notification-service
+// section. Chats are either plain IDs or maps with a threadID:
+//
+//	telegram:
+//	  token: 123456:ABC-DEF
+//	  chats:
+//	    - -1001234567890
+//	    - chatID: -1009876543210
+//	      threadID: 42
+type Config struct {
+	Token string
+	Chats []Chat
+	// BaseURL of the Bot API, e.g. of a local Bot API server.
+	BaseURL string
+}
+
+func ParseConfig(section map[interface{}]interface{}) (Config, error) {
+	cfg := Config{BaseURL: defaultBaseURL}
+
+	for key, target := range map[string]*string{"token": &cfg.Token, "baseURL": &cfg.BaseURL} {
+		value, ok := section[key]
+		if !ok || value == nil {
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			return Config{}, fmt.Errorf("invalid %s type", key)
+		}
+		if str != "" {
+			*target = str
+		}
+	}
+
+	if value, ok := section["chats"]; ok && value != nil {
+		list, ok := value.([]interface{})
+		if !ok {
+			return Config{}, errors.New("invalid chats type")
+		}
+		for _, item := range list {
+			chat, err := parseChat(item)
+			if err != nil {
+				return Config{}, err
+			}
+			cfg.Chats = append(cfg.Chats, chat)
+		}
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func parseChat(item interface{}) (Chat, error) {
+	values, ok := item.(map[interface{}]interface{})
+	if !ok {
+		id, err := chatID(item)
+		return Chat{ID: id}, err
+	}
+
+	id, err := chatID(values["chatID"])
+	if err != nil {
+		return Chat{}, err
+	}
+	chat := Chat{ID: id}
+	if value, ok := values["threadID"]; ok && value != nil {
+		threadID, ok := value.(int)
+		if !ok {
+			return Chat{}, fmt.Errorf("chat %s: invalid threadID type", id)
+		}
+		chat.ThreadID = threadID
+	}
+	return chat, nil
+}
+
+// chatID accepts numeric IDs, which yaml decodes as int, and @channelnames.
+func chatID(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case int:
+		return strconv.Itoa(v), nil
+	case string:
+		if v != "" {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("invalid chat id %v", value)
+}
+
+func (c Config) Validate() error {
+	if c.Token == "" {
+		return errors.New("token required")
+	}
+	if len(c.Chats) == 0 {
+		return errors.New("at least one chat required")
+	}
+	for _, chat := range c.Chats {
+		if chat.ThreadID < 0 {
+			return fmt.Errorf("chat %s: invalid threadID", chat.ID)
+		}
+	}
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid baseURL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid baseURL: unsupported scheme %q", u.Scheme)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+// markdownEscaper escapes the characters reserved in MarkdownV2.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+	"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+	"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+func escape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// formatEvent returns the MarkdownV2 text for the event, ok is false for
+// events that aren't sent to Telegram.
+func formatEvent(event events.EventMessage) (string, bool) {
+	switch event.Type {
+	case events.TypePlayerJoined, events.TypePlayerLeft:
+		player, ok := event.PlayerEvent()
+		if !ok {
+			return "", false
+		}
+		if event.Type == events.TypePlayerJoined {
+			return fmt.Sprintf("➡️ *%s* joined *%s*", escape(player.Player), escape(player.ServerName)), true
+		}
+		text := fmt.Sprintf("⬅️ *%s* left *%s*", escape(player.Player), escape(player.ServerName))
+		if player.Duration > 0 {
+			text += "\n_" + escape("Session: "+player.Duration.Round(time.Second).String()) + "_"
+		}
+		return text, true
+
+	case events.TypeServerOnline, events.TypeServerOffline:
+		server, ok := event.ServerEvent()
+		if !ok {
+			return "", false
+		}
+		if server.Online {
+			return fmt.Sprintf("🟢 *%s* is online \\(%d/%d\\)", escape(server.ServerName), server.Players, server.MaxPlayers), true
+		}
+		return fmt.Sprintf("🔴 *%s* is offline", escape(server.ServerName)), true
+	}
+	return "", false
+}
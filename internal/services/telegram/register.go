@@ -0,0 +1,25 @@
+package telegram
+
+import (
+	"context"
+
+	"github.com/led0nk/ark-overseer/internal/services/registry"
+)
+
+func init() {
+	registry.Register("telegram", registry.Definition[Config]{
+		Label: "Telegram",
+		Fields: []registry.Field{
+			{Key: "token", Label: "Bot-Token", Placeholder: "123456:ABC-DEF...", Type: registry.FieldSecret},
+			{Key: "chats", Label: "Chats", Placeholder: "-1001234567890, @channel", Type: registry.FieldList},
+		},
+		Parse: ParseConfig,
+		New: func(ctx context.Context, cfg Config, deps registry.Deps) (registry.Notification, error) {
+			notifier, err := NewTelegramNotifier(ctx, cfg, nil)
+			if err != nil {
+				return nil, err
+			}
+			return notifier, nil
+		},
+	})
+}
@@ -0,0 +1,162 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/led0nk/ark-overseer/internal/services/retry"
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+type TelegramNotifier struct {
+	cfg    Config
+	client *http.Client
+	logger *slog.Logger
+}
+
+// response is the envelope of every Bot API response.
+type response struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+type sendMessage struct {
+	ChatID                string `json:"chat_id"`
+	MessageThreadID       int    `json:"message_thread_id,omitempty"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode,omitempty"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
+func NewTelegramNotifier(ctx context.Context, cfg Config, client *http.Client) (*TelegramNotifier, error) {
+	if client == nil {
+		client = &http.Client{Timeout: retry.Timeout}
+	}
+	telegram := &TelegramNotifier{
+		cfg:    cfg,
+		client: client,
+		logger: slog.Default().WithGroup("telegram"),
+	}
+	err := telegram.Connect(ctx)
+	if err != nil {
+		telegram.logger.ErrorContext(ctx, "failed to connect telegram notification service", "error", err)
+		return nil, err
+	}
+	return telegram, nil
+}
+
+func (tn *TelegramNotifier) Topics() []string {
+	return events.AlertTopics
+}
+
+// Connect checks the token with getMe.
+func (tn *TelegramNotifier) Connect(ctx context.Context) error {
+	if err := tn.cfg.Validate(); err != nil {
+		return err
+	}
+	return tn.call(ctx, "getMe", struct{}{})
+}
+
+func (tn *TelegramNotifier) HandleEvent(ctx context.Context, event events.EventMessage) {
+	text, ok := formatEvent(event)
+	if !ok {
+		return
+	}
+	err := tn.send(ctx, text, "MarkdownV2")
+	if err != nil {
+		tn.logger.ErrorContext(ctx, "failed to send message", "error", err, "type", event.Type)
+	}
+}
+
+// Send sends text as is, without Markdown.
+func (tn *TelegramNotifier) Send(ctx context.Context, text string) error {
+	err := tn.send(ctx, text, "")
+	if err != nil {
+		tn.logger.ErrorContext(ctx, "failed to send telegram message", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (tn *TelegramNotifier) Disconnect() error {
+	tn.client.CloseIdleConnections()
+	return nil
+}
+
+// send delivers text to every configured chat.
+func (tn *TelegramNotifier) send(ctx context.Context, text string, parseMode string) error {
+	var errs []error
+	for _, chat := range tn.cfg.Chats {
+		err := tn.call(ctx, "sendMessage", sendMessage{
+			ChatID:                chat.ID,
+			MessageThreadID:       chat.ThreadID,
+			Text:                  text,
+			ParseMode:             parseMode,
+			DisableWebPagePreview: true,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("chat %s: %w", chat.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// call invokes a Bot API method and waits for retry_after if the bot is
+// rate limited.
+func (tn *TelegramNotifier) call(ctx context.Context, method string, params interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimSuffix(tn.cfg.BaseURL, "/") + "/bot" + tn.cfg.Token + "/" + method
+
+	return retry.Do(ctx, func() (time.Duration, error) {
+		return tn.do(ctx, endpoint, body)
+	})
+}
+
+func (tn *TelegramNotifier) do(ctx context.Context, endpoint string, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := tn.client.Do(req)
+	if err != nil {
+		// the url contains the token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var result response
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return 0, fmt.Errorf("invalid response with status %s: %w", resp.Status, err)
+	}
+	if result.OK {
+		return 0, nil
+	}
+
+	err = fmt.Errorf("telegram api error: %s", result.Description)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return time.Duration(max(result.Parameters.RetryAfter, 1)) * time.Second, err
+	}
+	return 0, err
+}
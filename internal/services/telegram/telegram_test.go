@@ -0,0 +1,219 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// testBotAPI answers getMe and sendMessage like the Bot API does.
+type testBotAPI struct {
+	*httptest.Server
+	mu          sync.Mutex
+	messages    []sendMessage
+	rateLimited int
+}
+
+func newTestBotAPI(t *testing.T, token string) *testBotAPI {
+	api := &testBotAPI{}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, ok := strings.CutPrefix(r.URL.Path, "/bot"+token+"/")
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"ok":false,"error_code":401,"description":"Unauthorized"}`))
+			return
+		}
+
+		switch method {
+		case "getMe":
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"username":"overseer_bot"}}`))
+		case "sendMessage":
+			api.mu.Lock()
+			defer api.mu.Unlock()
+			if api.rateLimited > 0 {
+				api.rateLimited--
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte(`{"ok":false,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`))
+				return
+			}
+			var msg sendMessage
+			if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"ok":false,"description":"Bad Request"}`))
+				return
+			}
+			api.messages = append(api.messages, msg)
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"ok":false,"description":"Not Found"}`))
+		}
+	}))
+	t.Cleanup(api.Close)
+	return api
+}
+
+func (api *testBotAPI) received() []sendMessage {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return append([]sendMessage(nil), api.messages...)
+}
+
+func TestTelegramNotifier(t *testing.T) {
+	ctx := context.Background()
+	api := newTestBotAPI(t, "123:ABC")
+
+	cfg, err := ParseConfig(map[interface{}]interface{}{
+		"token":   "123:ABC",
+		"baseURL": api.URL,
+		"chats": []interface{}{
+			-1001234567890,
+			map[interface{}]interface{}{"chatID": "@overseer", "threadID": 42},
+		},
+	})
+	assert.NoError(t, err)
+
+	notifier, err := NewTelegramNotifier(ctx, cfg, nil)
+	assert.NoError(t, err)
+
+	notifier.HandleEvent(ctx, events.EventMessage{
+		Type:    events.TypePlayerJoined,
+		Payload: events.PlayerEvent{Player: "Raider_1", ServerName: "island"},
+	})
+	notifier.HandleEvent(ctx, events.EventMessage{Type: events.TypeInit})
+
+	api.mu.Lock()
+	api.rateLimited = 1
+	api.mu.Unlock()
+	assert.NoError(t, notifier.Send(ctx, "hello"), "rate limited requests are retried")
+
+	received := api.received()
+	assert.Len(t, received, 4)
+	assert.Equal(t, "-1001234567890", received[0].ChatID)
+	assert.Zero(t, received[0].MessageThreadID)
+	assert.Equal(t, "@overseer", received[1].ChatID)
+	assert.Equal(t, 42, received[1].MessageThreadID)
+	assert.Equal(t, `➡️ *Raider\_1* joined *island*`, received[0].Text)
+	assert.Equal(t, "MarkdownV2", received[0].ParseMode)
+	assert.Equal(t, "hello", received[2].Text)
+	assert.Empty(t, received[2].ParseMode)
+	assert.NoError(t, notifier.Disconnect())
+}
+
+func TestTelegramInvalidToken(t *testing.T) {
+	api := newTestBotAPI(t, "123:ABC")
+
+	_, err := NewTelegramNotifier(context.Background(), Config{
+		Token:   "123:WRONG",
+		Chats:   []Chat{{ID: "1"}},
+		BaseURL: api.URL,
+	}, nil)
+	assert.ErrorContains(t, err, "Unauthorized")
+	assert.NotContains(t, err.Error(), "123:WRONG", "the token must not leak into errors")
+}
+
+func TestFormatEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    events.EventMessage
+		expected string
+		expectOK bool
+	}{
+		{
+			name: "player left",
+			event: events.EventMessage{
+				Type:    events.TypePlayerLeft,
+				Payload: events.PlayerEvent{Player: "Mr. Raider", ServerName: "island", Duration: 90 * time.Minute},
+			},
+			expected: "⬅️ *Mr\\. Raider* left *island*\n_Session: 1h30m0s_",
+			expectOK: true,
+		},
+		{
+			name: "server online",
+			event: events.EventMessage{
+				Type:    events.TypeServerOnline,
+				Payload: events.ServerEvent{ServerName: "the-island", Online: true, Players: 3, MaxPlayers: 70},
+			},
+			expected: "🟢 *the\\-island* is online \\(3/70\\)",
+			expectOK: true,
+		},
+		{
+			name: "server offline",
+			event: events.EventMessage{
+				Type:    events.TypeServerOffline,
+				Payload: events.ServerEvent{ServerName: "island"},
+			},
+			expected: "🔴 *island* is offline",
+			expectOK: true,
+		},
+		{
+			name:  "unsupported type",
+			event: events.EventMessage{Type: events.TypeServerAdded},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, ok := formatEvent(tt.event)
+			assert.Equal(t, tt.expectOK, ok)
+			assert.Equal(t, tt.expected, text)
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		section   map[interface{}]interface{}
+		expected  Config
+		expectErr bool
+	}{
+		{
+			name:     "defaults",
+			section:  map[interface{}]interface{}{"token": "123:ABC", "chats": []interface{}{"42"}},
+			expected: Config{Token: "123:ABC", Chats: []Chat{{ID: "42"}}, BaseURL: defaultBaseURL},
+		},
+		{
+			name:      "missing token",
+			section:   map[interface{}]interface{}{"chats": []interface{}{42}},
+			expectErr: true,
+		},
+		{
+			name:      "missing chats",
+			section:   map[interface{}]interface{}{"token": "123:ABC"},
+			expectErr: true,
+		},
+		{
+			name: "invalid thread",
+			section: map[interface{}]interface{}{"token": "123:ABC", "chats": []interface{}{
+				map[interface{}]interface{}{"chatID": 42, "threadID": "general"},
+			}},
+			expectErr: true,
+		},
+		{
+			name:      "invalid base url",
+			section:   map[interface{}]interface{}{"token": "123:ABC", "chats": []interface{}{42}, "baseURL": "api.telegram.org"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig(tt.section)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, cfg)
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"text/template"
+	"time"
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+	defaultRetries = 2
+	defaultBackoff = 500 * time.Millisecond
+)
+
+// defaultTarget is the name of the target configured next to the targets.
+const defaultTarget = "default"
+
+// defaultEvents are sent to targets without an events filter.
+var defaultEvents = []string{"player.*", "server.*"}
+
+// Config holds the targets every event is posted to.
+type Config struct {
+	Targets []Target
+}
+
+// Target is a single webhook receiver.
+type Target struct {
+	Name string
+	URL  string
+	// Events are patterns of the event types sent to the target, see
+	// events.MatchTopic.
+	Events  []string
+	Headers map[string]string
+	// Secret signs the body with HMAC-SHA256, the signature is sent in the
+	// X-Ark-Overseer-Signature header.
+	Secret string
+	// Template renders the body instead of the event JSON.
+	Template string
+	Timeout  time.Duration
+	Retries  int
+	Backoff  time.Duration
+
+	template *template.Template
+}
+
+// ParseConfig reads the webhook config section of the form
+//
+//	targets:
+//	  scripts:
+//	    url: https://example.com/hook
+//	    events: ["player.*"]
+//	    headers:
+//	      Authorization: Bearer token
+//	    secret: hmac-secret
+//	    template: '{"text": "{{ .Summary }}"}'
+//	    timeout: 5s
+//	    retries: 2
+//	    backoff: 500ms
+//
+// A url next to the targets adds the target "default" with the keys of the
+// section, it is the one edited on the settings page.
+func ParseConfig(section map[interface{}]interface{}) (Config, error) {
+	targetsSection, ok := section["targets"].(map[interface{}]interface{})
+	if !ok && section["targets"] != nil {
+		return Config{}, errors.New("invalid targets type")
+	}
+
+	targets := make([]Target, 0, len(targetsSection)+1)
+	if url, ok := section["url"]; ok && url != nil && url != "" {
+		if _, ok := targetsSection[defaultTarget]; ok {
+			return Config{}, fmt.Errorf("target %s is set by url already", defaultTarget)
+		}
+		target, err := parseTarget(defaultTarget, section)
+		if err != nil {
+			return Config{}, fmt.Errorf("target %s: %w", defaultTarget, err)
+		}
+		targets = append(targets, target)
+	}
+	for key, value := range targetsSection {
+		name, ok := key.(string)
+		if !ok {
+			return Config{}, fmt.Errorf("invalid target name %v", key)
+		}
+		values, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return Config{}, fmt.Errorf("target %s: invalid type", name)
+		}
+		target, err := parseTarget(name, values)
+		if err != nil {
+			return Config{}, fmt.Errorf("target %s: %w", name, err)
+		}
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+
+	cfg := Config{Targets: targets}
+	return cfg, cfg.Validate()
+}
+
+func (c *Config) Validate() error {
+	if len(c.Targets) == 0 {
+		return errors.New("at least one target required")
+	}
+	for i := range c.Targets {
+		if err := c.Targets[i].Validate(); err != nil {
+			return fmt.Errorf("target %s: %w", c.Targets[i].Name, err)
+		}
+	}
+	return nil
+}
+
+func parseTarget(name string, values map[interface{}]interface{}) (Target, error) {
+	target := Target{
+		Name:    name,
+		Headers: make(map[string]string),
+		Timeout: defaultTimeout,
+		Retries: defaultRetries,
+		Backoff: defaultBackoff,
+	}
+
+	for key, field := range map[string]*string{"url": &target.URL, "secret": &target.Secret, "template": &target.Template} {
+		value, ok := values[key]
+		if !ok || value == nil {
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			return Target{}, fmt.Errorf("invalid %s type", key)
+		}
+		*field = str
+	}
+
+	for key, field := range map[string]*time.Duration{"timeout": &target.Timeout, "backoff": &target.Backoff} {
+		value, ok := values[key]
+		if !ok || value == nil {
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			return Target{}, fmt.Errorf("invalid %s type", key)
+		}
+		duration, err := time.ParseDuration(str)
+		if err != nil {
+			return Target{}, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		*field = duration
+	}
+
+	if value, ok := values["retries"]; ok && value != nil {
+		retries, ok := value.(int)
+		if !ok {
+			return Target{}, errors.New("invalid retries type")
+		}
+		target.Retries = retries
+	}
+
+	if value, ok := values["events"]; ok && value != nil {
+		list, ok := value.([]interface{})
+		if !ok {
+			return Target{}, errors.New("invalid events type")
+		}
+		for _, item := range list {
+			pattern, ok := item.(string)
+			if !ok {
+				return Target{}, fmt.Errorf("invalid event pattern %v", item)
+			}
+			target.Events = append(target.Events, pattern)
+		}
+	}
+
+	if value, ok := values["headers"]; ok && value != nil {
+		headers, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return Target{}, errors.New("invalid headers type")
+		}
+		for key, value := range headers {
+			header, ok := key.(string)
+			if !ok {
+				return Target{}, fmt.Errorf("invalid header %v", key)
+			}
+			target.Headers[header] = fmt.Sprint(value)
+		}
+	}
+
+	return target, nil
+}
+
+func (t *Target) Validate() error {
+	if t.URL == "" {
+		return errors.New("url required")
+	}
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported url scheme %q", u.Scheme)
+	}
+	if t.Timeout <= 0 {
+		return errors.New("timeout has to be positive")
+	}
+	if t.Retries < 0 {
+		return errors.New("retries must not be negative")
+	}
+	if t.Backoff < 0 {
+		return errors.New("backoff must not be negative")
+	}
+	if len(t.Events) == 0 {
+		t.Events = defaultEvents
+	}
+	if t.Headers == nil {
+		t.Headers = make(map[string]string)
+	}
+	if t.Template != "" {
+		tmpl, err := template.New(t.Name).Funcs(templateFuncs).Parse(t.Template)
+		if err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+		t.template = tmpl
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/led0nk/ark-overseer/internal/services/registry"
+)
+
+func init() {
+	registry.Register("webhook", registry.Definition[Config]{
+		Label: "Webhooks",
+		Fields: []registry.Field{
+			{Key: "url", Label: "URL", Placeholder: "https://example.com/hook (more targets in config.yaml)", Type: registry.FieldText},
+			{Key: "events", Label: "Events", Placeholder: "player.*, server.* (all if empty)", Type: registry.FieldList},
+			{Key: "secret", Label: "Secret", Placeholder: "HMAC secret to sign the body...", Type: registry.FieldSecret},
+		},
+		Parse: ParseConfig,
+		New: func(ctx context.Context, cfg Config, deps registry.Deps) (registry.Notification, error) {
+			notifier, err := NewWebhookNotifier(ctx, cfg, nil)
+			if err != nil {
+				return nil, err
+			}
+			return notifier, nil
+		},
+	})
+}
@@ -0,0 +1,220 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/led0nk/ark-overseer/pkg/events"
+)
+
+const (
+	// TypeMessage is the event type of plain messages passed to Send.
+	TypeMessage = "message"
+
+	signatureHeader = "X-Ark-Overseer-Signature"
+	eventHeader     = "X-Ark-Overseer-Event"
+	deliveryHeader  = "X-Ark-Overseer-Delivery"
+	maxBackoff      = time.Minute
+	// maxDelivery bounds the time an event takes to deliver to all targets,
+	// including retries, so a dead receiver doesn't hold up the next events.
+	maxDelivery = 15 * time.Second
+)
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+type WebhookNotifier struct {
+	cfg    Config
+	client *http.Client
+	logger *slog.Logger
+}
+
+// NewWebhookNotifier posts events to the targets in cfg. A nil client creates
+// one with its own connections, which Disconnect closes. The per target
+// timeout applies in any case.
+func NewWebhookNotifier(ctx context.Context, cfg Config, client *http.Client) (*WebhookNotifier, error) {
+	if client == nil {
+		client = &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+	}
+	notifier := &WebhookNotifier{
+		cfg:    cfg,
+		client: client,
+		logger: slog.Default().WithGroup("webhook"),
+	}
+	if err := notifier.Connect(ctx); err != nil {
+		notifier.logger.ErrorContext(ctx, "failed to create webhook notification service", "error", err)
+		return nil, err
+	}
+	return notifier, nil
+}
+
+// Topics is the union of the target filters.
+func (wn *WebhookNotifier) Topics() []string {
+	var topics []string
+	for _, target := range wn.cfg.Targets {
+		topics = append(topics, target.Events...)
+	}
+	return topics
+}
+
+// Connect validates the configuration, webhooks don't keep a connection.
+func (wn *WebhookNotifier) Connect(ctx context.Context) error {
+	return wn.cfg.Validate()
+}
+
+// Send posts a plain text message to all targets in parallel regardless of
+// their event filters.
+func (wn *WebhookNotifier) Send(ctx context.Context, message string) error {
+	event := events.EventMessage{Type: TypeMessage, Timestamp: time.Now(), Payload: message}
+	return wn.deliver(ctx, wn.cfg.Targets, event)
+}
+
+// HandleEvent posts the event to all matching targets in parallel and
+// returns once every delivery succeeded, ran out of retries or maxDelivery
+// passed.
+func (wn *WebhookNotifier) HandleEvent(ctx context.Context, event events.EventMessage) {
+	targets := make([]Target, 0, len(wn.cfg.Targets))
+	for _, target := range wn.cfg.Targets {
+		if target.matches(event.Type) {
+			targets = append(targets, target)
+		}
+	}
+	if err := wn.deliver(ctx, targets, event); err != nil {
+		wn.logger.ErrorContext(ctx, "failed to deliver webhook", "error", err, "type", event.Type)
+	}
+}
+
+// deliver posts the event to the targets in parallel, within maxDelivery.
+func (wn *WebhookNotifier) deliver(ctx context.Context, targets []Target, event events.EventMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, maxDelivery)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(targets))
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := wn.post(ctx, target, event); err != nil {
+				errs[i] = fmt.Errorf("target %s: %w", target.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (wn *WebhookNotifier) Disconnect() error {
+	wn.client.CloseIdleConnections()
+	return nil
+}
+
+func (t *Target) matches(eventType string) bool {
+	for _, pattern := range t.Events {
+		if events.MatchTopic(pattern, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// body renders the template of the target, or the event as JSON.
+func (t *Target) body(event events.EventMessage) ([]byte, error) {
+	if t.template == nil {
+		return events.MarshalEvent(event)
+	}
+	var buf bytes.Buffer
+	if err := t.template.Execute(&buf, event); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// sign returns the hex encoded HMAC-SHA256 of body, prefixed like GitHub
+// does so receivers can reuse their verification code.
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// post delivers the event and retries network errors, 429 and 5xx
+// responses with exponential backoff.
+func (wn *WebhookNotifier) post(ctx context.Context, target Target, event events.EventMessage) error {
+	body, err := target.body(event)
+	if err != nil {
+		return err
+	}
+
+	backoff := target.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := wn.do(ctx, target, event, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= target.Retries {
+			return err
+		}
+		wn.logger.WarnContext(ctx, "webhook delivery failed, retrying", "error", err, "target", target.Name, "attempt", attempt+1)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// do sends a single request and reports whether a failure is worth a retry.
+func (wn *WebhookNotifier) do(ctx context.Context, target Target, event events.EventMessage, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, target.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ark-overseer")
+	req.Header.Set(eventHeader, event.Type)
+	if event.ID != 0 {
+		req.Header.Set(deliveryHeader, strconv.FormatUint(event.ID, 10))
+	}
+	for key, value := range target.Headers {
+		req.Header.Set(key, value)
+	}
+	if target.Secret != "" {
+		req.Header.Set(signatureHeader, sign(target.Secret, body))
+	}
+
+	resp, err := wn.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("unexpected status %s", resp.Status)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
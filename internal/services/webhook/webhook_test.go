@@ -0,0 +1,338 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/led0nk/ark-overseer/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+type testReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []request
+}
+
+func newTestReceiver(t *testing.T, status func(attempt int) int) *testReceiver {
+	r := &testReceiver{}
+	var attempts int32
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.requests = append(r.requests, request{header: req.Header.Clone(), body: body})
+		r.mu.Unlock()
+		w.WriteHeader(status(int(atomic.AddInt32(&attempts, 1))))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *testReceiver) received() []request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]request(nil), r.requests...)
+}
+
+func ok(int) int { return http.StatusOK }
+
+func TestWebhookNotifier(t *testing.T) {
+	ctx := context.Background()
+	players := newTestReceiver(t, ok)
+	chat := newTestReceiver(t, ok)
+
+	cfg, err := ParseConfig(map[interface{}]interface{}{
+		"targets": map[interface{}]interface{}{
+			"players": map[interface{}]interface{}{
+				"url":     players.URL,
+				"events":  []interface{}{"player.joined"},
+				"secret":  "hmac-secret",
+				"headers": map[interface{}]interface{}{"Authorization": "Bearer token"},
+			},
+			"chat": map[interface{}]interface{}{
+				"url":      chat.URL,
+				"template": `{"text": {{ json .Summary }}}`,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	notifier, err := NewWebhookNotifier(ctx, cfg, nil)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"player.joined", "player.*", "server.*"}, notifier.Topics())
+
+	serverID := uuid.New()
+	notifier.HandleEvent(ctx, events.EventMessage{
+		ID:      3,
+		Type:    events.TypePlayerJoined,
+		Payload: events.PlayerEvent{Player: "Raider", ServerID: serverID, ServerName: "island"},
+	})
+	notifier.HandleEvent(ctx, events.EventMessage{
+		ID:      4,
+		Type:    events.TypeServerOffline,
+		Payload: events.ServerEvent{ServerID: serverID, ServerName: "island"},
+	})
+
+	received := players.received()
+	assert.Len(t, received, 1, "server events are filtered")
+	event, err := events.UnmarshalEvent(received[0].body)
+	assert.NoError(t, err)
+	player, isPlayer := event.PlayerEvent()
+	assert.True(t, isPlayer)
+	assert.Equal(t, "Raider", player.Player)
+	assert.Equal(t, "Bearer token", received[0].header.Get("Authorization"))
+	assert.Equal(t, "application/json", received[0].header.Get("Content-Type"))
+	assert.Equal(t, events.TypePlayerJoined, received[0].header.Get(eventHeader))
+	assert.Equal(t, "3", received[0].header.Get(deliveryHeader))
+	assert.Equal(t, sign("hmac-secret", received[0].body), received[0].header.Get(signatureHeader))
+
+	received = chat.received()
+	assert.Len(t, received, 2)
+	var body map[string]string
+	assert.NoError(t, json.Unmarshal(received[0].body, &body))
+	assert.Equal(t, "Raider joined island", body["text"])
+	assert.NoError(t, json.Unmarshal(received[1].body, &body))
+	assert.Equal(t, "island is offline", body["text"])
+	assert.Empty(t, received[0].header.Get(signatureHeader))
+
+	assert.NoError(t, notifier.Send(ctx, "hello"))
+	assert.Len(t, players.received(), 2, "messages ignore event filters")
+	assert.NoError(t, notifier.Disconnect())
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    func(attempt int) int
+		retries   int
+		attempts  int
+		expectErr bool
+	}{
+		{
+			name: "recovers after server errors",
+			status: func(attempt int) int {
+				if attempt < 3 {
+					return http.StatusServiceUnavailable
+				}
+				return http.StatusNoContent
+			},
+			retries:  3,
+			attempts: 3,
+		},
+		{
+			name:      "gives up after retries",
+			status:    func(int) int { return http.StatusTooManyRequests },
+			retries:   2,
+			attempts:  3,
+			expectErr: true,
+		},
+		{
+			name:      "client errors are not retried",
+			status:    func(int) int { return http.StatusBadRequest },
+			retries:   3,
+			attempts:  1,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := newTestReceiver(t, tt.status)
+			notifier, err := NewWebhookNotifier(context.Background(), Config{Targets: []Target{{
+				Name:    "test",
+				URL:     receiver.URL,
+				Timeout: time.Second,
+				Retries: tt.retries,
+				Backoff: time.Millisecond,
+			}}}, nil)
+			assert.NoError(t, err)
+
+			err = notifier.Send(context.Background(), "hello")
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, receiver.received(), tt.attempts)
+		})
+	}
+}
+
+func TestWebhookTimeout(t *testing.T) {
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-block:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(block)
+
+	notifier, err := NewWebhookNotifier(context.Background(), Config{Targets: []Target{{
+		Name:    "slow",
+		URL:     server.URL,
+		Timeout: 50 * time.Millisecond,
+	}}}, nil)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	assert.Error(t, notifier.Send(context.Background(), "hello"))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestWebhookParallelTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	var targets []Target
+	for _, name := range []string{"first", "second", "third"} {
+		targets = append(targets, Target{Name: name, URL: server.URL, Timeout: time.Second})
+	}
+	notifier, err := NewWebhookNotifier(context.Background(), Config{Targets: targets}, nil)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	assert.NoError(t, notifier.Send(context.Background(), "hello"))
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "targets are sent to in parallel")
+}
+
+func TestWebhookOwnsClient(t *testing.T) {
+	cfg := Config{Targets: []Target{{Name: "hook", URL: "https://example.com/hook", Timeout: time.Second}}}
+	notifier, err := NewWebhookNotifier(context.Background(), cfg, nil)
+	assert.NoError(t, err)
+	assert.NotSame(t, http.DefaultClient, notifier.client)
+	assert.NotSame(t, http.DefaultTransport, notifier.client.Transport, "Disconnect only closes its own connections")
+	assert.NoError(t, notifier.Disconnect())
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		section   map[interface{}]interface{}
+		expected  Target
+		expectErr bool
+	}{
+		{
+			name: "defaults",
+			section: map[interface{}]interface{}{"targets": map[interface{}]interface{}{
+				"hook": map[interface{}]interface{}{"url": "https://example.com/hook"},
+			}},
+			expected: Target{
+				Name:    "hook",
+				URL:     "https://example.com/hook",
+				Events:  defaultEvents,
+				Headers: map[string]string{},
+				Timeout: defaultTimeout,
+				Retries: defaultRetries,
+				Backoff: defaultBackoff,
+			},
+		},
+		{
+			name: "all options",
+			section: map[interface{}]interface{}{"targets": map[interface{}]interface{}{
+				"hook": map[interface{}]interface{}{
+					"url":     "http://localhost:8080",
+					"events":  []interface{}{"server.offline"},
+					"headers": map[interface{}]interface{}{"X-Token": "abc"},
+					"secret":  "hmac-secret",
+					"timeout": "2s",
+					"retries": 0,
+					"backoff": "500ms",
+				},
+			}},
+			expected: Target{
+				Name:    "hook",
+				URL:     "http://localhost:8080",
+				Events:  []string{"server.offline"},
+				Headers: map[string]string{"X-Token": "abc"},
+				Secret:  "hmac-secret",
+				Timeout: 2 * time.Second,
+				Backoff: 500 * time.Millisecond,
+			},
+		},
+		{
+			name:    "url without targets",
+			section: map[interface{}]interface{}{"url": "https://example.com/hook", "secret": "hmac-secret"},
+			expected: Target{
+				Name:    "default",
+				URL:     "https://example.com/hook",
+				Events:  defaultEvents,
+				Headers: map[string]string{},
+				Secret:  "hmac-secret",
+				Timeout: defaultTimeout,
+				Retries: defaultRetries,
+				Backoff: defaultBackoff,
+			},
+		},
+		{
+			name: "url and default target",
+			section: map[interface{}]interface{}{"url": "https://example.com/hook", "targets": map[interface{}]interface{}{
+				"default": map[interface{}]interface{}{"url": "https://example.com/other"},
+			}},
+			expectErr: true,
+		},
+		{
+			name:      "missing targets",
+			section:   map[interface{}]interface{}{},
+			expectErr: true,
+		},
+		{
+			name: "missing url",
+			section: map[interface{}]interface{}{"targets": map[interface{}]interface{}{
+				"hook": map[interface{}]interface{}{"secret": "hmac-secret"},
+			}},
+			expectErr: true,
+		},
+		{
+			name: "unsupported scheme",
+			section: map[interface{}]interface{}{"targets": map[interface{}]interface{}{
+				"hook": map[interface{}]interface{}{"url": "ftp://example.com"},
+			}},
+			expectErr: true,
+		},
+		{
+			name: "invalid template",
+			section: map[interface{}]interface{}{"targets": map[interface{}]interface{}{
+				"hook": map[interface{}]interface{}{"url": "https://example.com", "template": "{{ .Type"},
+			}},
+			expectErr: true,
+		},
+		{
+			name: "invalid timeout",
+			section: map[interface{}]interface{}{"targets": map[interface{}]interface{}{
+				"hook": map[interface{}]interface{}{"url": "https://example.com", "timeout": "soon"},
+			}},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig(tt.section)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, cfg.Targets, 1)
+			cfg.Targets[0].template = nil
+			assert.Equal(t, tt.expected, cfg.Targets[0])
+		})
+	}
+}
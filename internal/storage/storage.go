@@ -6,6 +6,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"sync"
 	"time"
@@ -24,12 +25,14 @@ type Database interface {
 	GetByID(context.Context, uuid.UUID) (*model.Server, error)
 	Delete(context.Context, uuid.UUID) error
 	Update(context.Context, *model.Server) error
+	Watch(context.Context, uuid.UUID) (<-chan *model.Server, error)
 	Save() error
 }
 
 type ServerStorage struct {
 	filename string
 	server   map[uuid.UUID]*model.Server
+	watchers map[uuid.UUID]map[chan *model.Server]struct{}
 	mu       sync.Mutex
 }
 
@@ -37,6 +40,7 @@ func NewServerStorage(ctx context.Context, filename string) (*ServerStorage, err
 	store := &ServerStorage{
 		filename: filename,
 		server:   make(map[uuid.UUID]*model.Server),
+		watchers: make(map[uuid.UUID]map[chan *model.Server]struct{}),
 	}
 	if err := store.load(); err != nil {
 		return nil, err
@@ -48,6 +52,13 @@ func NewServerStorage(ctx context.Context, filename string) (*ServerStorage, err
 }
 
 func (s *ServerStorage) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.save()
+}
+
+func (s *ServerStorage) save() error {
 	as_json, err := json.MarshalIndent(s.server, "", "\t")
 	if err != nil {
 		return err
@@ -83,7 +94,7 @@ func (s *ServerStorage) load() error {
 		if err != nil {
 			return err
 		}
-		err = s.Save()
+		err = s.save()
 		if err != nil {
 			return err
 		}
@@ -109,12 +120,12 @@ func (s *ServerStorage) Create(ctx context.Context, server *model.Server) (*mode
 		server.ID = uuid.New()
 	}
 
-	s.server[server.ID] = server
-	if err := s.Save(); err != nil {
+	s.server[server.ID] = server.Clone()
+	if err := s.save(); err != nil {
 		return nil, err
 	}
 
-	return server, nil
+	return server.Clone(), nil
 }
 
 func (s *ServerStorage) Update(ctx context.Context, server *model.Server) error {
@@ -131,7 +142,11 @@ func (s *ServerStorage) Update(ctx context.Context, server *model.Server) error
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
-		s.server[server.ID] = server
+		previous, exists := s.server[server.ID]
+		s.server[server.ID] = server.Clone()
+		if !exists || !reflect.DeepEqual(previous, server) {
+			s.notify(s.server[server.ID])
+		}
 		return nil
 	}
 }
@@ -153,7 +168,7 @@ func (s *ServerStorage) GetByName(ctx context.Context, name string) (*model.Serv
 	fetchedServer := &model.Server{}
 	for _, server := range s.server {
 		if server.Name == name {
-			fetchedServer = server
+			fetchedServer = server.Clone()
 		}
 	}
 	return fetchedServer, nil
@@ -175,7 +190,7 @@ func (s *ServerStorage) GetByID(ctx context.Context, id uuid.UUID) (*model.Serve
 
 	for _, server := range s.server {
 		if server.ID == id {
-			return server, nil
+			return server.Clone(), nil
 		}
 	}
 	return nil, errors.New("server not found")
@@ -201,6 +216,11 @@ func (s *ServerStorage) Delete(ctx context.Context, ID uuid.UUID) error {
 
 	delete(s.server, ID)
 
+	for ch := range s.watchers[ID] {
+		close(ch)
+	}
+	delete(s.watchers, ID)
+
 	return nil
 }
 
@@ -216,9 +236,67 @@ func (s *ServerStorage) List(ctx context.Context) ([]*model.Server, error) {
 
 	serverlist := make([]*model.Server, 0, len(s.server))
 	for _, server := range s.server {
-		serverlist = append(serverlist, server)
+		serverlist = append(serverlist, server.Clone())
 	}
 
 	sort.Slice(serverlist, func(i, j int) bool { return serverlist[i].Name < serverlist[j].Name })
 	return serverlist, nil
 }
+
+// Watch returns a channel that receives the current state of the server
+// followed by every change written through Update. Only the latest state is
+// buffered, so slow readers skip intermediate states instead of blocking the
+// writer. The channel is closed when ctx is done or the server is deleted.
+func (s *ServerStorage) Watch(ctx context.Context, id uuid.UUID) (<-chan *model.Server, error) {
+	_, span := tracer.Start(ctx, "Watch")
+	defer span.End()
+
+	span.AddEvent("Lock")
+	s.mu.Lock()
+
+	defer span.AddEvent("Unlock")
+	defer s.mu.Unlock()
+
+	server, exists := s.server[id]
+	if !exists {
+		return nil, errors.New("server not found")
+	}
+
+	ch := make(chan *model.Server, 1)
+	ch <- server.Clone()
+
+	if s.watchers[id] == nil {
+		s.watchers[id] = make(map[chan *model.Server]struct{})
+	}
+	s.watchers[id][ch] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if _, exists := s.watchers[id][ch]; exists {
+			delete(s.watchers[id], ch)
+			close(ch)
+		}
+	}()
+
+	return ch, nil
+}
+
+// notify hands a copy of the new state to all watchers of the server,
+// replacing a state that has not been picked up yet. Callers must hold s.mu.
+func (s *ServerStorage) notify(server *model.Server) {
+	for ch := range s.watchers[server.ID] {
+		snapshot := server.Clone()
+		select {
+		case ch <- snapshot:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- snapshot
+		}
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/led0nk/ark-overseer/internal/model"
@@ -119,3 +121,202 @@ func TestServerStorageCRUD(t *testing.T) {
 		})
 	}
 }
+
+func TestServerStorageWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := createTempDir(t)
+	defer cleanupTempDir(t, dir)
+
+	storage, err := NewServerStorage(ctx, filepath.Join(dir, "cluster.json"))
+	assert.NoError(t, err)
+
+	server := &model.Server{
+		ID:   uuid.MustParse("5f0c3b5e-8d6a-4c3e-9a0e-2f1d7b3c4a10"),
+		Name: "watched server",
+		Addr: "127.0.0.1:27015",
+	}
+	_, err = storage.Create(ctx, server)
+	assert.NoError(t, err)
+
+	_, err = storage.Watch(ctx, uuid.New())
+	assert.Error(t, err)
+
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	updates, err := storage.Watch(watchCtx, server.ID)
+	assert.NoError(t, err)
+
+	receive := func() (*model.Server, bool) {
+		select {
+		case srv, ok := <-updates:
+			return srv, ok
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for server update")
+			return nil, false
+		}
+	}
+
+	initial, ok := receive()
+	assert.True(t, ok)
+	assert.Equal(t, "watched server", initial.Name)
+
+	// the players of an empty server are nil
+	err = storage.Update(ctx, &model.Server{ID: server.ID, Name: "renamed server", Addr: server.Addr, PlayersInfo: &model.PlayersInfo{}})
+	assert.NoError(t, err)
+	updated, ok := receive()
+	assert.True(t, ok)
+	assert.Equal(t, "renamed server", updated.Name)
+
+	err = storage.Update(ctx, &model.Server{ID: server.ID, Name: "renamed server", Addr: server.Addr, PlayersInfo: &model.PlayersInfo{}})
+	assert.NoError(t, err)
+	select {
+	case <-updates:
+		t.Fatal("unchanged server must not emit an update")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	stopWatch()
+	_, ok = receive()
+	assert.False(t, ok, "channel should be closed after the context is done")
+
+	updates, err = storage.Watch(ctx, server.ID)
+	assert.NoError(t, err)
+	_, _ = receive()
+
+	err = storage.Delete(ctx, server.ID)
+	assert.NoError(t, err)
+	_, ok = receive()
+	assert.False(t, ok, "channel should be closed after the server is deleted")
+}
+
+func TestServerStorageSnapshots(t *testing.T) {
+	ctx := context.Background()
+	dir := createTempDir(t)
+	defer cleanupTempDir(t, dir)
+
+	storage, err := NewServerStorage(ctx, filepath.Join(dir, "cluster.json"))
+	assert.NoError(t, err)
+
+	server := &model.Server{
+		ID:          uuid.MustParse("a3c1f0de-2b7e-4f8a-9d61-0c5e8b7a1f23"),
+		Name:        "snapshot server",
+		Addr:        "127.0.0.1:27015",
+		ServerInfo:  &model.ServerInfo{Players: 1, MaxPlayers: 70},
+		PlayersInfo: &model.PlayersInfo{Players: []*model.Players{{Name: "player"}}},
+	}
+	created, err := storage.Create(ctx, server)
+	assert.NoError(t, err)
+
+	server.ServerInfo.Players = 42
+	created.PlayersInfo.Players[0].Name = "modified by caller"
+
+	retrieved, err := storage.GetByID(ctx, server.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, retrieved.ServerInfo.Players)
+	assert.Equal(t, "player", retrieved.PlayersInfo.Players[0].Name)
+
+	retrieved.ServerInfo.Players = 0
+	retrieved.PlayersInfo.Players = nil
+
+	list, err := storage.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, 1, list[0].ServerInfo.Players)
+	assert.Len(t, list[0].PlayersInfo.Players, 1)
+}
+
+func TestServerStorageConcurrentAccess(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := createTempDir(t)
+	defer cleanupTempDir(t, dir)
+
+	storage, err := NewServerStorage(ctx, filepath.Join(dir, "cluster.json"))
+	assert.NoError(t, err)
+
+	id := uuid.MustParse("c9b2e4d1-7a3f-4e6b-8c0d-1f2a3b4c5d6e")
+	_, err = storage.Create(ctx, &model.Server{
+		ID:          id,
+		Name:        "busy server",
+		Addr:        "127.0.0.1:27015",
+		ServerInfo:  &model.ServerInfo{MaxPlayers: 70},
+		PlayersInfo: &model.PlayersInfo{},
+	})
+	assert.NoError(t, err)
+
+	updates, err := storage.Watch(ctx, id)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	// scraper: replaces the server state with fresh data
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			scraped := &model.Server{
+				ID:          id,
+				Name:        "busy server",
+				Addr:        "127.0.0.1:27015",
+				Status:      i%2 == 0,
+				ServerInfo:  &model.ServerInfo{Players: i % 70, MaxPlayers: 70},
+				PlayersInfo: &model.PlayersInfo{Players: []*model.Players{{Name: "player", Score: i}}},
+			}
+			assert.NoError(t, storage.Update(ctx, scraped))
+			scraped.ServerInfo.Players = -1
+		}
+	}()
+
+	// http handlers: read and mutate what they got handed out
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				srv, err := storage.GetByID(ctx, id)
+				if !assert.NoError(t, err) {
+					return
+				}
+				srv.ServerInfo.Players = 0
+				for _, player := range srv.PlayersInfo.Players {
+					player.Name = ""
+				}
+
+				list, err := storage.List(ctx)
+				if !assert.NoError(t, err) {
+					return
+				}
+				for _, srv := range list {
+					srv.Status = false
+				}
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			assert.NoError(t, storage.Save())
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for {
+		select {
+		case srv := <-updates:
+			srv.ServerInfo.Players = 0
+		case <-done:
+			srv, err := storage.GetByID(ctx, id)
+			assert.NoError(t, err)
+			assert.Equal(t, 199%70, srv.ServerInfo.Players)
+			assert.Equal(t, "player", srv.PlayersInfo.Players[0].Name)
+			return
+		}
+	}
+}
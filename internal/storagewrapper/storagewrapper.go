@@ -26,12 +26,12 @@ func NewStorageWrapper(
 
 func (n *StorageWrapper) Create(ctx context.Context, srv *model.Server) (*model.Server, error) {
 	newServer, err := n.store.Create(ctx, srv)
-	n.em.Publish(events.EventMessage{Type: "server.added", Payload: newServer})
+	n.em.Publish(ctx, events.EventMessage{Type: events.TypeServerAdded, Payload: newServer})
 	return newServer, err
 }
 
 func (n *StorageWrapper) Delete(ctx context.Context, id uuid.UUID) error {
-	n.em.Publish(events.EventMessage{Type: "server.deleted", Payload: id})
+	n.em.Publish(ctx, events.EventMessage{Type: events.TypeServerDeleted, Payload: id})
 	err := n.store.Delete(ctx, id)
 	return err
 }
@@ -52,6 +52,10 @@ func (n *StorageWrapper) Update(ctx context.Context, srv *model.Server) error {
 	return n.store.Update(ctx, srv)
 }
 
+func (n *StorageWrapper) Watch(ctx context.Context, id uuid.UUID) (<-chan *model.Server, error) {
+	return n.store.Watch(ctx, id)
+}
+
 func (n *StorageWrapper) Save() error {
 	return n.store.Save()
 }
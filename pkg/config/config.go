@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,6 +15,8 @@ type Configuration interface {
 	Load() error
 	Save() error
 	Update(string, string, interface{}) error
+	Export() (map[interface{}]interface{}, error)
+	Import(map[interface{}]interface{}) error
 }
 
 type Config struct {
@@ -101,9 +104,7 @@ func (c *Config) Update(section string, key string, value interface{}) error {
 	if err != nil {
 		return err
 	}
-	c.em.Publish(events.EventMessage{Type: "config.changed", Payload: sectionMap})
-
-	return nil
+	return c.publish(sectionMap)
 }
 
 func (c *Config) GetSection(section string) (map[interface{}]interface{}, error) {
@@ -119,3 +120,60 @@ func (c *Config) GetSection(section string) (map[interface{}]interface{}, error)
 
 	return sectionData, nil
 }
+
+// Export returns a deep copy of the whole configuration.
+func (c *Config) Export() (map[interface{}]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return deepCopy(c.config)
+}
+
+// publish announces the notification services as changed. Subscribers get a
+// copy of the section, later updates don't change it under their hands.
+func (c *Config) publish(sectionMap map[interface{}]interface{}) error {
+	payload, err := deepCopy(sectionMap)
+	if err != nil {
+		return err
+	}
+	c.em.Publish(context.Background(), events.EventMessage{Type: events.TypeConfigChanged, Payload: payload})
+	return nil
+}
+
+func deepCopy(value map[interface{}]interface{}) (map[interface{}]interface{}, error) {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := make(map[interface{}]interface{})
+	err = yaml.Unmarshal(data, &copied)
+	if err != nil {
+		return nil, err
+	}
+
+	return copied, nil
+}
+
+// Import replaces the whole configuration, saves it and announces the
+// notification services as changed.
+func (c *Config) Import(config map[interface{}]interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if config == nil {
+		config = make(map[interface{}]interface{})
+	}
+	c.config = config
+
+	err := c.Save()
+	if err != nil {
+		return err
+	}
+
+	sectionMap, ok := c.config["notification-service"].(map[interface{}]interface{})
+	if !ok {
+		sectionMap = make(map[interface{}]interface{})
+	}
+	return c.publish(sectionMap)
+}
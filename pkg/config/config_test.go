@@ -108,3 +108,58 @@ func TestSaveAndLoadConfig(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, value, loadedSection[key])
 }
+
+func TestExportAndImportConfig(t *testing.T) {
+	dir := createTempDir(t)
+	defer cleanupTempDir(t, dir)
+
+	em := events.NewEventManager()
+	cfg, err := NewConfiguration(filepath.Join(dir, "config.yaml"), em)
+	assert.NoError(t, err)
+
+	value := map[interface{}]interface{}{"token": "123456", "channelID": "abcdef"}
+	err = cfg.Update("notification-service", "discord", value)
+	assert.NoError(t, err)
+
+	exported, err := cfg.Export()
+	assert.NoError(t, err)
+
+	section, ok := exported["notification-service"].(map[interface{}]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, value, section["discord"])
+
+	section["discord"] = map[interface{}]interface{}{"token": "654321", "channelID": "fedcba"}
+	current, err := cfg.GetSection("notification-service")
+	assert.NoError(t, err)
+	assert.Equal(t, value, current["discord"], "export must not share state with the config")
+
+	err = cfg.Import(exported)
+	assert.NoError(t, err)
+
+	cfg, err = NewConfiguration(filepath.Join(dir, "config.yaml"), em)
+	assert.NoError(t, err)
+	loadedSection, err := cfg.GetSection("notification-service")
+	assert.NoError(t, err)
+	assert.Equal(t, section["discord"], loadedSection["discord"])
+}
+
+func TestUpdatePublishesCopy(t *testing.T) {
+	dir := createTempDir(t)
+	defer cleanupTempDir(t, dir)
+
+	em := events.NewEventManager()
+	_, ch := em.Subscribe("services", events.Topics(events.TypeConfigChanged), events.Buffer(2))
+	cfg, err := NewConfiguration(filepath.Join(dir, "config.yaml"), em)
+	assert.NoError(t, err)
+
+	assert.NoError(t, cfg.Update("notification-service", "discord", map[interface{}]interface{}{"token": "first"}))
+	first, ok := (<-ch).Section()
+	assert.True(t, ok)
+
+	assert.NoError(t, cfg.Update("notification-service", "slack", map[interface{}]interface{}{"token": "second"}))
+	second, ok := (<-ch).Section()
+	assert.True(t, ok)
+
+	assert.NotContains(t, first, "slack", "the first payload isn't changed by later updates")
+	assert.Contains(t, second, "slack")
+}
@@ -5,37 +5,247 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var meter = otel.GetMeterProvider().Meter("github.com/led0nk/ark-overseer/internal/events")
+var (
+	meter  = otel.GetMeterProvider().Meter("github.com/led0nk/ark-overseer/internal/events")
+	tracer = otel.GetTracerProvider().Tracer("github.com/led0nk/ark-overseer/internal/events")
+)
 
 type EventHandler interface {
 	HandleEvent(context.Context, EventMessage)
 }
 
+const (
+	defaultBuffer  = 5
+	maxDeadLetters = 100
+)
+
 type EventManager struct {
 	logger     *slog.Logger
-	subscriber map[uuid.UUID]chan EventMessage
+	subscriber map[uuid.UUID]*subscription
+	journal    Journal
 	mu         sync.RWMutex
+	// publishMu orders journaling and delivery, so durable subscribers
+	// receive events in the order of their IDs. Nothing waits while it is
+	// held.
+	publishMu   sync.Mutex
+	deadMu      sync.Mutex
+	deadLetters []DeadLetter
 }
 
 type EventMessage struct {
-	Type    string
-	Payload interface{}
+	// ID is assigned by the journal, it is 0 for events that weren't journaled.
+	ID        uint64
+	Type      string
+	Timestamp time.Time
+	Payload   interface{}
+	// SpanContext is the span that published the event, handlers continue
+	// its trace.
+	SpanContext trace.SpanContext
+}
+
+type Option func(*EventManager)
+
+// WithJournal stores every published event in j.
+func WithJournal(j Journal) Option {
+	return func(e *EventManager) {
+		e.journal = j
+	}
+}
+
+// DeliveryPolicy decides what Publish does if the channel of a subscriber
+// is full.
+type DeliveryPolicy int
+
+const (
+	// PolicyDrop skips the subscriber, the event becomes a dead letter.
+	PolicyDrop DeliveryPolicy = iota
+	// PolicyBlock waits up to the subscription's timeout for free space
+	// before the event becomes a dead letter.
+	PolicyBlock
+)
+
+func (p DeliveryPolicy) String() string {
+	switch p {
+	case PolicyBlock:
+		return "block"
+	default:
+		return "drop"
+	}
+}
+
+// DeadLetter is an event that couldn't be delivered to a subscriber.
+type DeadLetter struct {
+	Subscriber string
+	Event      EventMessage
+	Reason     string
+	Time       time.Time
+	// Redelivered is set for durable subscribers, they receive the event
+	// from the journal instead.
+	Redelivered bool
+}
+
+type SubscribeOption func(*subscription)
+
+type subscription struct {
+	name    string
+	ch      chan EventMessage
+	topics  []string
+	durable bool
+	// maxAge skips replayed events older than it, zero replays all of them.
+	maxAge  time.Duration
+	policy  DeliveryPolicy
+	buffer  int
+	timeout time.Duration
+	// concurrency is the number of events handled at the same time, events
+	// are handled in order if it is 1.
+	concurrency int
+	// missed is signaled when an event couldn't be delivered to a durable
+	// subscription, so it catches up from the journal.
+	missed chan struct{}
+	// mu guards sending on ch against Unsubscribe closing it, done wakes up
+	// publishers waiting for the subscriber.
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+func newSubscription(name string, opts ...SubscribeOption) *subscription {
+	sub := &subscription{
+		name:        name,
+		policy:      PolicyDrop,
+		buffer:      defaultBuffer,
+		concurrency: 1,
+		missed:      make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(sub)
+	}
+	sub.ch = make(chan EventMessage, sub.buffer)
+	return sub
+}
+
+// Topics limits the subscription to events whose type matches one of the
+// patterns, see MatchTopic.
+func Topics(patterns ...string) SubscribeOption {
+	return func(s *subscription) {
+		s.topics = append(s.topics, patterns...)
+	}
+}
+
+// Concurrency lets up to n events be handled at the same time. Events are
+// no longer handled in order then, so handlers have to be safe for
+// concurrent use.
+func Concurrency(n int) SubscribeOption {
+	return func(s *subscription) {
+		if n > 0 {
+			s.concurrency = n
+		}
+	}
 }
 
-func NewEventManager() *EventManager {
-	return &EventManager{
+// Buffer sets the capacity of the subscriber's channel, defaults to 5.
+func Buffer(size int) SubscribeOption {
+	return func(s *subscription) {
+		if size >= 0 {
+			s.buffer = size
+		}
+	}
+}
+
+// DropOnFull skips the subscriber while its channel is full. This is the
+// default policy.
+func DropOnFull() SubscribeOption {
+	return func(s *subscription) {
+		s.policy = PolicyDrop
+		s.timeout = 0
+	}
+}
+
+// BlockOnFull makes Publish wait up to timeout for the subscriber to catch up
+// before the event is dropped. Durable subscribers never block, they catch up
+// from the journal instead.
+func BlockOnFull(timeout time.Duration) SubscribeOption {
+	return func(s *subscription) {
+		s.policy = PolicyBlock
+		s.timeout = timeout
+	}
+}
+
+// Durable makes StartListening resume after the last event the subscriber
+// acknowledged, including events published while it wasn't running. Requires
+// an EventManager with a journal and a subscriber name that is stable across
+// restarts.
+func Durable() SubscribeOption {
+	return func(s *subscription) {
+		s.durable = true
+	}
+}
+
+// MaxReplayAge makes a durable subscriber skip journaled events older than
+// age when it catches up, e.g. alerts that are outdated after a long downtime.
+// Zero replays all of them.
+func MaxReplayAge(age time.Duration) SubscribeOption {
+	return func(s *subscription) {
+		s.maxAge = age
+	}
+}
+
+func NewEventManager(opts ...Option) *EventManager {
+	e := &EventManager{
 		logger:     slog.Default().WithGroup("event"),
-		subscriber: make(map[uuid.UUID]chan EventMessage),
+		subscriber: make(map[uuid.UUID]*subscription),
+	}
+	for _, opt := range opts {
+		opt(e)
 	}
+	return e
+}
+
+// Journal returns the journal of the EventManager, nil if there is none.
+func (e *EventManager) Journal() Journal {
+	return e.journal
 }
 
-func (e *EventManager) Subscribe(name string) (uuid.UUID, <-chan EventMessage) {
+// History returns up to limit of the latest journaled events, newest first.
+func (e *EventManager) History(limit int) ([]EventMessage, error) {
+	if e.journal == nil {
+		return nil, nil
+	}
+	return Recent(e.journal, limit)
+}
+
+// DeadLetters returns the latest events that couldn't be delivered, newest
+// first.
+func (e *EventManager) DeadLetters() []DeadLetter {
+	e.deadMu.Lock()
+	defer e.deadMu.Unlock()
+
+	deadLetters := make([]DeadLetter, len(e.deadLetters))
+	for i, deadLetter := range e.deadLetters {
+		deadLetters[len(e.deadLetters)-1-i] = deadLetter
+	}
+	return deadLetters
+}
+
+func (e *EventManager) Subscribe(name string, opts ...SubscribeOption) (uuid.UUID, <-chan EventMessage) {
+	id, sub := e.subscribe(name, opts...)
+	if sub == nil {
+		return uuid.Nil, nil
+	}
+	return id, sub.ch
+}
+
+func (e *EventManager) subscribe(name string, opts ...SubscribeOption) (uuid.UUID, *subscription) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -44,62 +254,291 @@ func (e *EventManager) Subscribe(name string) (uuid.UUID, <-chan EventMessage) {
 		return uuid.Nil, nil
 	}
 
-	ch := make(chan EventMessage, 5)
-	e.subscriber[id] = ch
+	sub := newSubscription(name, opts...)
+	e.subscriber[id] = sub
 
-	e.logger.Info("service subscribed to eventManager", "service id", id, "service name", name)
-	return id, ch
+	e.logger.Info("service subscribed to eventManager", "service id", id, "service name", name, "policy", sub.policy, "buffer", sub.buffer, "topics", sub.topics)
+	return id, sub
 }
 
 func (e *EventManager) Unsubscribe(id uuid.UUID, name string) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	ch := e.subscriber[id]
-	close(ch)
+	sub, ok := e.subscriber[id]
+	if !ok {
+		return
+	}
+	close(sub.done)
+	sub.mu.Lock()
+	sub.closed = true
+	close(sub.ch)
+	sub.mu.Unlock()
 	delete(e.subscriber, id)
 	e.logger.Info("service unsubscribed to eventManager", "service id", id, "service name", name)
 }
 
-func (e *EventManager) Publish(emsg EventMessage) {
-	ctx := context.Background()
+func (e *EventManager) Publish(ctx context.Context, emsg EventMessage) {
+	ctx, span := tracer.Start(ctx, "Publish "+emsg.Type, trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+
 	eventCtr, err := meter.Int64Counter(
 		"eventCtr",
-		metric.WithDescription("number of events published"),
+		metric.WithDescription("number of events delivered, per topic and subscriber"),
+	)
+	if err != nil {
+		return
+	}
+	publishedCtr, err := meter.Int64Counter(
+		"eventPublishedCtr",
+		metric.WithDescription("number of events published, per topic"),
+	)
+	if err != nil {
+		return
+	}
+	deadLetterCtr, err := meter.Int64Counter(
+		"deadLetterCtr",
+		metric.WithDescription("number of events that couldn't be delivered to a subscriber"),
 	)
 	if err != nil {
 		return
 	}
 
-	e.mu.Lock()
-	defer e.mu.Unlock()
+	if emsg.Timestamp.IsZero() {
+		emsg.Timestamp = time.Now()
+	}
+	if !emsg.SpanContext.IsValid() {
+		emsg.SpanContext = span.SpanContext()
+	}
+
+	e.mu.RLock()
+	subs := make([]*subscription, 0, len(e.subscriber))
+	for _, sub := range e.subscriber {
+		if sub.matches(emsg.Type) {
+			subs = append(subs, sub)
+		}
+	}
+	e.mu.RUnlock()
+
+	delivered := func(sub *subscription, reason string) {
+		switch reason {
+		case "":
+			eventCtr.Add(ctx, 1, metric.WithAttributes(
+				attribute.String("topic", emsg.Type),
+				attribute.String("subscriber", sub.name),
+			))
+			e.logger.Debug("publish eventMessage", "debug", "publish", sub.name, fmt.Sprintf("%v", emsg))
+		case reasonUnsubscribed:
+		default:
+			deadLetterCtr.Add(ctx, 1, metric.WithAttributes(
+				attribute.String("topic", emsg.Type),
+				attribute.String("subscriber", sub.name),
+				attribute.String("reason", reason),
+			))
+			e.logger.Warn("failed to deliver event", "subscriber", sub.name, "type", emsg.Type, "reason", reason)
+			e.addDeadLetter(sub, emsg, reason)
+		}
+	}
+
+	// subscribers that block are waited for after the lock is released, so
+	// a slow subscriber only delays its own publisher
+	var blocked []*subscription
+	e.publishMu.Lock()
+	if e.journal != nil {
+		// events that couldn't be journaled are still delivered, without an ID
+		if journaled, err := e.journal.Append(emsg); err != nil {
+			e.logger.Error("failed to append event to journal", "error", err, "type", emsg.Type)
+		} else {
+			emsg = journaled
+		}
+	}
+	publishedCtr.Add(ctx, 1, metric.WithAttributes(attribute.String("topic", emsg.Type)))
+	for _, sub := range subs {
+		reason := sub.send(emsg)
+		if reason == reasonFull && e.blocks(sub, emsg) {
+			blocked = append(blocked, sub)
+			continue
+		}
+		delivered(sub, reason)
+	}
+	e.publishMu.Unlock()
+
+	for _, sub := range blocked {
+		delivered(sub, sub.wait(emsg))
+	}
+}
+
+const (
+	reasonFull         = "channel full"
+	reasonTimeout      = "timeout"
+	reasonUnsubscribed = "unsubscribed"
+)
 
-	for subscriber, ch := range e.subscriber {
+// blocks reports whether Publish waits for the subscriber. Durable
+// subscribers get journaled events from the journal if they fall behind.
+func (e *EventManager) blocks(sub *subscription, emsg EventMessage) bool {
+	if sub.policy != PolicyBlock || sub.timeout <= 0 {
+		return false
+	}
+	return !(sub.durable && e.journal != nil && emsg.ID != 0)
+}
+
+// send delivers emsg if the channel has space and returns why it couldn't,
+// if so.
+func (s *subscription) send(emsg EventMessage) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return reasonUnsubscribed
+	}
+	select {
+	case s.ch <- emsg:
+		return ""
+	default:
+		return reasonFull
+	}
+}
+
+// wait delivers emsg within the timeout of the subscription.
+func (s *subscription) wait(emsg EventMessage) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return reasonUnsubscribed
+	}
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	select {
+	case s.ch <- emsg:
+		return ""
+	case <-s.done:
+		return reasonUnsubscribed
+	case <-timer.C:
+		return reasonTimeout
+	}
+}
+
+func (e *EventManager) addDeadLetter(sub *subscription, emsg EventMessage, reason string) {
+	redelivered := sub.durable && e.journal != nil && emsg.ID != 0
+	if redelivered {
 		select {
-		case ch <- emsg:
-			eventCtr.Add(ctx, 1)
-			e.logger.Debug("publish eventMessage", "debug", "publish", subscriber.String(), fmt.Sprintf("%s", emsg))
+		case sub.missed <- struct{}{}:
 		default:
-			e.logger.Debug("channel blocked, skipping subscriber", "subscriber", subscriber.String())
 		}
 	}
+
+	e.deadMu.Lock()
+	defer e.deadMu.Unlock()
+	e.deadLetters = append(e.deadLetters, DeadLetter{
+		Subscriber:  sub.name,
+		Event:       emsg,
+		Reason:      reason,
+		Time:        time.Now(),
+		Redelivered: redelivered,
+	})
+	if len(e.deadLetters) > maxDeadLetters {
+		e.deadLetters = e.deadLetters[len(e.deadLetters)-maxDeadLetters:]
+	}
 }
 
-func (e *EventManager) StartListening(ctx context.Context, handler EventHandler, serviceName string, onSubscribe func()) {
-	id, ch := e.Subscribe(serviceName)
-	if id == uuid.Nil {
+func (e *EventManager) StartListening(
+	ctx context.Context,
+	handler EventHandler,
+	serviceName string,
+	onSubscribe func(),
+	opts ...SubscribeOption,
+) {
+	id, sub := e.subscribe(serviceName, opts...)
+	if sub == nil {
 		return
 	}
 	defer e.Unsubscribe(id, serviceName)
 
+	l, err := e.newListener(handler, sub)
+	if err != nil {
+		e.logger.Error("failed to create listener", "error", err, "service name", serviceName)
+		return
+	}
+	// in-flight handlers finish before the subscription is closed
+	defer l.wg.Wait()
+
 	onSubscribe()
 
+	durable := sub.durable && e.journal != nil
+	var lastID uint64
+	if durable {
+		lastID = e.resume(ctx, l, sub)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case event := <-ch:
-			handler.HandleEvent(ctx, event)
+		case <-sub.missed:
+			if durable {
+				l.wg.Wait()
+				lastID = e.replay(ctx, l, sub, lastID)
+			}
+		case event := <-sub.ch:
+			if durable && event.ID != 0 && event.ID <= lastID {
+				continue
+			}
+			if durable && event.ID != 0 {
+				lastID = event.ID
+			}
+			if !l.dispatch(ctx, event, durable) {
+				return
+			}
 		}
 	}
 }
+
+// resume replays all events the subscriber hasn't acknowledged yet and
+// returns the ID of the last handled event. Subscribers without a cursor
+// start at the end of the journal.
+func (e *EventManager) resume(ctx context.Context, l *listener, sub *subscription) uint64 {
+	lastID, ok := e.journal.Acked(sub.name)
+	if !ok {
+		lastID = e.journal.LastID()
+		e.ack(sub.name, lastID)
+		return lastID
+	}
+	return e.replay(ctx, l, sub, lastID)
+}
+
+// replay hands all journaled events after lastID that match the subscription
+// and aren't older than its max age to handler and returns the ID of the last
+// replayed event.
+func (e *EventManager) replay(ctx context.Context, l *listener, sub *subscription, lastID uint64) uint64 {
+	var oldest time.Time
+	if sub.maxAge > 0 {
+		oldest = time.Now().Add(-sub.maxAge)
+	}
+	err := e.journal.Replay(lastID, func(event EventMessage) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		lastID = event.ID
+		if !sub.matches(event.Type) || event.Timestamp.Before(oldest) {
+			return nil
+		}
+		l.handle(ctx, event, true)
+		e.ack(sub.name, event.ID)
+		return nil
+	})
+	if err != nil {
+		e.logger.Error("failed to replay journal", "error", err, "service name", sub.name)
+	}
+	e.ack(sub.name, lastID)
+	return lastID
+}
+
+func (e *EventManager) ack(serviceName string, id uint64) {
+	if id == 0 {
+		return
+	}
+	if err := e.journal.Ack(serviceName, id); err != nil {
+		e.logger.Error("failed to acknowledge event", "error", err, "service name", serviceName, "id", id)
+	}
+}
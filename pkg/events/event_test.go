@@ -3,6 +3,7 @@ package events
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -25,7 +26,7 @@ func TestMultipleSubscribers(t *testing.T) {
 	_, ch1 := em.Subscribe("service-1")
 	_, ch2 := em.Subscribe("service-2")
 
-	go em.Publish(EventMessage{Type: "test-event", Payload: "test-payload"})
+	go em.Publish(context.Background(), EventMessage{Type: "test-event", Payload: "test-payload"})
 
 	select {
 	case event := <-ch1:
@@ -50,16 +51,16 @@ func TestStartListeningWithTimeout(t *testing.T) {
 	defer cancel()
 
 	mockHandler := &MockHandler{}
-	subscribed := false
+	var subscribed atomic.Bool
 
 	go em.StartListening(ctx, mockHandler, "test-service", func() {
-		subscribed = true
+		subscribed.Store(true)
 	})
 
 	time.Sleep(100 * time.Millisecond)
-	assert.True(t, subscribed, "subscribed should have been called")
+	assert.True(t, subscribed.Load(), "subscribed should have been called")
 
-	em.Publish(EventMessage{Type: "test-event", Payload: "test-payload"})
+	em.Publish(context.Background(), EventMessage{Type: "test-event", Payload: "test-payload"})
 
 	time.Sleep(100 * time.Millisecond)
 
@@ -72,7 +73,7 @@ func TestStartListeningWithTimeout(t *testing.T) {
 	<-ctx.Done()
 	assert.Equal(t, context.DeadlineExceeded, ctx.Err(), "context should have timed out")
 
-	em.Publish(EventMessage{Type: "after-ctx-done", Payload: "payload-after-ctx-done"})
+	em.Publish(context.Background(), EventMessage{Type: "after-ctx-done", Payload: "payload-after-ctx-done"})
 
 	time.Sleep(100 * time.Millisecond)
 
@@ -80,3 +81,293 @@ func TestStartListeningWithTimeout(t *testing.T) {
 	assert.Len(t, mockHandler.handledEvents, 1, "no more events handled after ctx-done")
 	mockHandler.mu.Unlock()
 }
+
+func TestDeliveryPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []SubscribeOption
+		published   int
+		delivered   int
+		deadLetters int
+		reason      string
+	}{
+		{
+			name:        "drop by default",
+			published:   7,
+			delivered:   5,
+			deadLetters: 2,
+			reason:      "channel full",
+		},
+		{
+			name:      "larger buffer",
+			opts:      []SubscribeOption{Buffer(10)},
+			published: 7,
+			delivered: 7,
+		},
+		{
+			name:        "block with timeout",
+			opts:        []SubscribeOption{Buffer(1), BlockOnFull(20 * time.Millisecond)},
+			published:   3,
+			delivered:   1,
+			deadLetters: 2,
+			reason:      "timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := NewEventManager()
+			_, ch := em.Subscribe("slow-service", tt.opts...)
+
+			for i := 0; i < tt.published; i++ {
+				em.Publish(context.Background(), EventMessage{Type: "test-event", Payload: i})
+			}
+
+			assert.Len(t, ch, tt.delivered)
+			deadLetters := em.DeadLetters()
+			assert.Len(t, deadLetters, tt.deadLetters)
+			for _, deadLetter := range deadLetters {
+				assert.Equal(t, "slow-service", deadLetter.Subscriber)
+				assert.Equal(t, tt.reason, deadLetter.Reason)
+				assert.False(t, deadLetter.Redelivered)
+			}
+			if tt.deadLetters > 0 {
+				assert.Equal(t, tt.published-1, deadLetters[0].Event.Payload, "newest dead letter first")
+			}
+		})
+	}
+}
+
+func TestBlockOnFullWaitsForSubscriber(t *testing.T) {
+	em := NewEventManager()
+	_, ch := em.Subscribe("slow-service", Buffer(1), BlockOnFull(time.Second))
+
+	em.Publish(context.Background(), EventMessage{Type: "test-event", Payload: "first"})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		<-ch
+	}()
+	em.Publish(context.Background(), EventMessage{Type: "test-event", Payload: "second"})
+
+	assert.Empty(t, em.DeadLetters())
+	assert.Equal(t, "second", (<-ch).Payload)
+}
+
+type blockingHandler struct {
+	MockHandler
+	release chan struct{}
+}
+
+func (b *blockingHandler) HandleEvent(ctx context.Context, event EventMessage) {
+	<-b.release
+	b.MockHandler.HandleEvent(ctx, event)
+}
+
+func TestDurableRedeliversDeadLetters(t *testing.T) {
+	j, err := NewFileJournal(t.TempDir())
+	assert.NoError(t, err)
+	defer j.Close()
+	em := NewEventManager(WithJournal(j))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handler := &blockingHandler{release: make(chan struct{})}
+	subscribed := make(chan struct{})
+	go em.StartListening(ctx, handler, "slow-service", func() { close(subscribed) }, Durable(), Buffer(1))
+	<-subscribed
+	time.Sleep(50 * time.Millisecond)
+
+	// the handler blocks on the first event, the second one is buffered and
+	// the rest is dropped
+	for i := 0; i < 5; i++ {
+		em.Publish(context.Background(), EventMessage{Type: "test-event", Payload: "event"})
+	}
+	assert.NotEmpty(t, em.DeadLetters())
+	for _, deadLetter := range em.DeadLetters() {
+		assert.True(t, deadLetter.Redelivered)
+	}
+	close(handler.release)
+
+	assert.Eventually(t, func() bool {
+		handler.mu.Lock()
+		defer handler.mu.Unlock()
+		return len(handler.handledEvents) == 5
+	}, time.Second, 10*time.Millisecond)
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	for i, event := range handler.handledEvents {
+		assert.Equal(t, uint64(i+1), event.ID, "events must be handled once and in order")
+	}
+}
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		pattern string
+		topic   string
+		match   bool
+	}{
+		{"player.*", "player.joined", true},
+		{"player.*", "player.left", true},
+		{"player.*", "server.offline", false},
+		{"player.*", "player", false},
+		{"server.offline", "server.offline", true},
+		{"server.offline", "server.online", false},
+		{"*.offline", "server.offline", true},
+		{"server.**", "server.offline", true},
+		{"server.**", "server", true},
+		{"**", "config.changed", true},
+		{"init", "init.services", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.topic, func(t *testing.T) {
+			assert.Equal(t, tt.match, MatchTopic(tt.pattern, tt.topic))
+		})
+	}
+}
+
+func TestTopicSubscription(t *testing.T) {
+	em := NewEventManager()
+	_, players := em.Subscribe("players", Topics("player.*"))
+	_, offline := em.Subscribe("offline", Topics("server.offline"))
+	_, all := em.Subscribe("all")
+
+	em.Publish(context.Background(), EventMessage{Type: TypePlayerJoined})
+	em.Publish(context.Background(), EventMessage{Type: TypeServerAdded})
+	em.Publish(context.Background(), EventMessage{Type: TypeServerOffline})
+	em.Publish(context.Background(), EventMessage{Type: TypePlayerLeft})
+
+	assert.Len(t, players, 2)
+	assert.Equal(t, TypePlayerJoined, (<-players).Type)
+	assert.Equal(t, TypePlayerLeft, (<-players).Type)
+	assert.Len(t, offline, 1)
+	assert.Equal(t, TypeServerOffline, (<-offline).Type)
+	assert.Len(t, all, 4)
+	assert.Empty(t, em.DeadLetters(), "filtered events are no dead letters")
+}
+
+type panicHandler struct {
+	MockHandler
+}
+
+func (p *panicHandler) HandleEvent(ctx context.Context, event EventMessage) {
+	if event.Type == "panic" {
+		var session *MockHandler
+		session.handledEvents = nil
+	}
+	p.MockHandler.HandleEvent(ctx, event)
+}
+
+func TestStartListeningRecoversPanics(t *testing.T) {
+	em := NewEventManager()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handler := &panicHandler{}
+	subscribed := make(chan struct{})
+	go em.StartListening(ctx, handler, "panicking-service", func() { close(subscribed) })
+	<-subscribed
+
+	em.Publish(context.Background(), EventMessage{Type: "panic"})
+	em.Publish(context.Background(), EventMessage{Type: "test-event"})
+
+	assert.Eventually(t, func() bool {
+		handler.mu.Lock()
+		defer handler.mu.Unlock()
+		return len(handler.handledEvents) == 1
+	}, time.Second, 10*time.Millisecond, "listener must survive a panicking handler")
+}
+
+type slowHandler struct {
+	running atomic.Int32
+	max     atomic.Int32
+	handled atomic.Int32
+}
+
+func (s *slowHandler) HandleEvent(ctx context.Context, event EventMessage) {
+	running := s.running.Add(1)
+	defer s.running.Add(-1)
+	for {
+		max := s.max.Load()
+		if running <= max || s.max.CompareAndSwap(max, running) {
+			break
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	s.handled.Add(1)
+}
+
+func TestStartListeningConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []SubscribeOption
+		expectedMax int32
+	}{
+		{
+			name:        "sequential by default",
+			expectedMax: 1,
+		},
+		{
+			name:        "bounded concurrency",
+			opts:        []SubscribeOption{Concurrency(3)},
+			expectedMax: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em := NewEventManager()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handler := &slowHandler{}
+			subscribed := make(chan struct{})
+			opts := append([]SubscribeOption{Buffer(10)}, tt.opts...)
+			go em.StartListening(ctx, handler, "slow-service", func() { close(subscribed) }, opts...)
+			<-subscribed
+
+			for i := 0; i < 6; i++ {
+				em.Publish(context.Background(), EventMessage{Type: "test-event"})
+			}
+
+			assert.Eventually(t, func() bool { return handler.handled.Load() == 6 }, 2*time.Second, 10*time.Millisecond)
+			assert.Equal(t, tt.expectedMax, handler.max.Load())
+		})
+	}
+}
+
+func TestSlowSubscriberOnlyDelaysItsPublisher(t *testing.T) {
+	em := NewEventManager()
+	_, _ = em.Subscribe("slow-service", Topics("test-event"), Buffer(0), BlockOnFull(time.Second))
+
+	published := make(chan struct{})
+	go func() {
+		em.Publish(context.Background(), EventMessage{Type: "test-event"})
+		close(published)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	id, ch := em.Subscribe("other-service", Topics("other-event"))
+	em.Publish(context.Background(), EventMessage{Type: "other-event"})
+	em.Unsubscribe(id, "other-service")
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "publishers don't wait for each other")
+	assert.Len(t, ch, 1)
+
+	<-published
+	assert.Equal(t, "timeout", em.DeadLetters()[0].Reason)
+}
+
+func TestDurableSubscribersDontBlock(t *testing.T) {
+	j, err := NewFileJournal(t.TempDir())
+	assert.NoError(t, err)
+	defer j.Close()
+	em := NewEventManager(WithJournal(j))
+	_, _ = em.Subscribe("durable-service", Durable(), Buffer(0), BlockOnFull(time.Second))
+
+	start := time.Now()
+	em.Publish(context.Background(), EventMessage{Type: "test-event"})
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.True(t, em.DeadLetters()[0].Redelivered)
+}
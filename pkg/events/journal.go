@@ -0,0 +1,612 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/led0nk/ark-overseer/internal/model"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Journal persists published events so subscribers can resume after a
+// restart and past events can be shown.
+type Journal interface {
+	// Append stores the event and returns it with its assigned ID.
+	Append(EventMessage) (EventMessage, error)
+	// Replay calls fn for every event with an ID greater than afterID, in
+	// order. Replay stops at the first error returned by fn.
+	Replay(afterID uint64, fn func(EventMessage) error) error
+	// LastID returns the ID of the latest event, 0 if the journal is empty.
+	LastID() uint64
+	// Ack records id as processed by the named subscriber.
+	Ack(name string, id uint64) error
+	// Acked returns the last acknowledged ID of the named subscriber.
+	Acked(name string) (uint64, bool)
+	Close() error
+}
+
+const (
+	segmentExt         = ".log"
+	cursorFile         = "cursors.json"
+	defaultSegmentSize = 4 << 20
+	// defaultSyncInterval batches fsyncs and cursor writes, a crash loses
+	// at most that much. Lost cursors only make subscribers handle events
+	// again.
+	defaultSyncInterval = time.Second
+	pruneInterval       = time.Hour
+)
+
+var (
+	payloadMu    sync.RWMutex
+	payloadTypes = map[string]func() interface{}{
+		TypePlayerJoined:  func() interface{} { return &PlayerEvent{} },
+		TypePlayerLeft:    func() interface{} { return &PlayerEvent{} },
+		TypeServerOnline:  func() interface{} { return &ServerEvent{} },
+		TypeServerOffline: func() interface{} { return &ServerEvent{} },
+		TypeServerAdded:   func() interface{} { return &model.Server{} },
+		TypeServerDeleted: func() interface{} { return &uuid.UUID{} },
+	}
+)
+
+// configPayloads carry the configuration including secrets, they are never
+// written to the journal.
+var configPayloads = map[string]bool{
+	TypeInitServices:  true,
+	TypeConfigChanged: true,
+}
+
+// RegisterPayload makes payloads of the given event type decodable from the
+// journal. newPayload has to return a pointer to a new payload value.
+func RegisterPayload(eventType string, newPayload func() interface{}) {
+	payloadMu.Lock()
+	defer payloadMu.Unlock()
+	payloadTypes[eventType] = newPayload
+}
+
+type record struct {
+	ID         uint64          `json:"id"`
+	Type       string          `json:"type"`
+	Timestamp  time.Time       `json:"timestamp"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	TraceID    string          `json:"traceId,omitempty"`
+	SpanID     string          `json:"spanId,omitempty"`
+	TraceFlags byte            `json:"traceFlags,omitempty"`
+}
+
+// MarshalEvent encodes an event as a single line of JSON. Configuration
+// payloads and payloads that can't be encoded are left out.
+func MarshalEvent(emsg EventMessage) ([]byte, error) {
+	rec := record{
+		ID:        emsg.ID,
+		Type:      emsg.Type,
+		Timestamp: emsg.Timestamp,
+	}
+	if emsg.SpanContext.IsValid() {
+		rec.TraceID = emsg.SpanContext.TraceID().String()
+		rec.SpanID = emsg.SpanContext.SpanID().String()
+		rec.TraceFlags = byte(emsg.SpanContext.TraceFlags())
+	}
+	if emsg.Payload != nil && !configPayloads[emsg.Type] {
+		if payload, err := json.Marshal(emsg.Payload); err == nil {
+			rec.Payload = payload
+		}
+	}
+	return json.Marshal(rec)
+}
+
+// UnmarshalEvent decodes an event encoded by MarshalEvent. Payloads of
+// registered types are restored with their original type.
+func UnmarshalEvent(data []byte) (EventMessage, error) {
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return EventMessage{}, err
+	}
+	emsg := EventMessage{
+		ID:        rec.ID,
+		Type:      rec.Type,
+		Timestamp: rec.Timestamp,
+	}
+	if traceID, err := trace.TraceIDFromHex(rec.TraceID); err == nil {
+		if spanID, err := trace.SpanIDFromHex(rec.SpanID); err == nil {
+			emsg.SpanContext = trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    traceID,
+				SpanID:     spanID,
+				TraceFlags: trace.TraceFlags(rec.TraceFlags),
+				Remote:     true,
+			})
+		}
+	}
+	if len(rec.Payload) == 0 || bytes.Equal(rec.Payload, []byte("null")) {
+		return emsg, nil
+	}
+
+	payloadMu.RLock()
+	newPayload, ok := payloadTypes[rec.Type]
+	payloadMu.RUnlock()
+	if !ok {
+		var payload interface{}
+		if err := json.Unmarshal(rec.Payload, &payload); err != nil {
+			return EventMessage{}, err
+		}
+		emsg.Payload = payload
+		return emsg, nil
+	}
+
+	payload := newPayload()
+	if err := json.Unmarshal(rec.Payload, payload); err != nil {
+		return EventMessage{}, fmt.Errorf("invalid payload for %s: %w", rec.Type, err)
+	}
+	emsg.Payload = deref(payload)
+	return emsg, nil
+}
+
+// deref returns value payloads as values, like they are published.
+func deref(payload interface{}) interface{} {
+	switch p := payload.(type) {
+	case *PlayerEvent:
+		return *p
+	case *ServerEvent:
+		return *p
+	case *uuid.UUID:
+		return *p
+	default:
+		return payload
+	}
+}
+
+// FileJournal is an append-only journal stored as segmented JSON-lines files.
+// Each segment is named after the ID of its first event.
+type FileJournal struct {
+	dir          string
+	segmentSize  int64
+	syncInterval time.Duration
+	maxAge       time.Duration
+	maxSegments  int
+	mu           sync.Mutex
+	file         *os.File
+	fileSize     int64
+	lastID       uint64
+	cursors      map[string]uint64
+	// unsynced and cursorsChanged are written by the next flush.
+	unsynced       bool
+	cursorsChanged bool
+	stop           chan struct{}
+	wg             sync.WaitGroup
+}
+
+type JournalOption func(*FileJournal)
+
+// RetainFor removes segments whose latest event is older than maxAge, 0
+// keeps them forever.
+func RetainFor(maxAge time.Duration) JournalOption {
+	return func(j *FileJournal) {
+		j.maxAge = maxAge
+	}
+}
+
+// RetainSegments keeps at most n segments, 0 keeps all of them.
+func RetainSegments(n int) JournalOption {
+	return func(j *FileJournal) {
+		j.maxSegments = n
+	}
+}
+
+// SyncEvery sets how often events and cursors are written to disk, 0 writes
+// them on every Append and Ack.
+func SyncEvery(interval time.Duration) JournalOption {
+	return func(j *FileJournal) {
+		j.syncInterval = interval
+	}
+}
+
+func NewFileJournal(dir string, opts ...JournalOption) (*FileJournal, error) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	j := &FileJournal{
+		dir:          dir,
+		segmentSize:  defaultSegmentSize,
+		syncInterval: defaultSyncInterval,
+		cursors:      make(map[string]uint64),
+		stop:         make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+	if err := j.prune(); err != nil {
+		return nil, err
+	}
+
+	j.wg.Add(1)
+	go j.run()
+	return j, nil
+}
+
+// run flushes the journal every syncInterval and prunes old segments.
+func (j *FileJournal) run() {
+	defer j.wg.Done()
+
+	var flush <-chan time.Time
+	if j.syncInterval > 0 {
+		ticker := time.NewTicker(j.syncInterval)
+		defer ticker.Stop()
+		flush = ticker.C
+	}
+	prune := time.NewTicker(pruneInterval)
+	defer prune.Stop()
+
+	for {
+		select {
+		case <-j.stop:
+			return
+		case <-flush:
+			j.mu.Lock()
+			err := j.flush()
+			j.mu.Unlock()
+			if err != nil {
+				slog.Default().WithGroup("journal").Error("failed to flush journal", "error", err)
+			}
+		case <-prune.C:
+			j.mu.Lock()
+			err := j.prune()
+			j.mu.Unlock()
+			if err != nil {
+				slog.Default().WithGroup("journal").Error("failed to prune journal", "error", err)
+			}
+		}
+	}
+}
+
+// flush syncs appended events and writes changed cursors, j.mu has to be
+// held.
+func (j *FileJournal) flush() error {
+	if j.unsynced && j.file != nil {
+		if err := j.file.Sync(); err != nil {
+			return err
+		}
+		j.unsynced = false
+	}
+	if !j.cursorsChanged {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(j.cursors, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(j.dir, cursorFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(j.dir, cursorFile)); err != nil {
+		return err
+	}
+	j.cursorsChanged = false
+	return nil
+}
+
+// prune removes the oldest segments beyond the retention, the current
+// segment is always kept. j.mu has to be held.
+func (j *FileJournal) prune() error {
+	if j.maxAge <= 0 && j.maxSegments <= 0 {
+		return nil
+	}
+	segments, err := j.segments()
+	if err != nil {
+		return err
+	}
+
+	for i, segment := range segments[:max(len(segments)-1, 0)] {
+		path := filepath.Join(j.dir, segment)
+		remove := j.maxSegments > 0 && len(segments)-i > j.maxSegments
+		if !remove && j.maxAge > 0 {
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			// segments are written in order, the last write is the latest event
+			remove = time.Since(info.ModTime()) > j.maxAge
+		}
+		if !remove {
+			break
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *FileJournal) load() error {
+	data, err := os.ReadFile(filepath.Join(j.dir, cursorFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &j.cursors); err != nil {
+			return fmt.Errorf("invalid cursors: %w", err)
+		}
+	}
+
+	segments, err := j.segments()
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return nil
+	}
+
+	// recover the last segment: find the last ID and drop a partially
+	// written event left behind by a crash
+	last := filepath.Join(j.dir, segments[len(segments)-1])
+	file, err := os.OpenFile(last, os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+
+	var valid int64
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			var rec record
+			if jsonErr := json.Unmarshal(line, &rec); jsonErr != nil {
+				break
+			}
+			j.lastID = rec.ID
+			valid += int64(len(line))
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if err := file.Truncate(valid); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if _, err := file.Seek(valid, 0); err != nil {
+		_ = file.Close()
+		return err
+	}
+	j.file = file
+	j.fileSize = valid
+
+	if j.lastID == 0 {
+		if first, err := strconv.ParseUint(strings.TrimSuffix(segments[len(segments)-1], segmentExt), 10, 64); err == nil && first > 0 {
+			j.lastID = first - 1
+		}
+	}
+	return nil
+}
+
+func (j *FileJournal) segments() ([]string, error) {
+	entries, err := os.ReadDir(j.dir)
+	if err != nil {
+		return nil, err
+	}
+	var segments []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), segmentExt) {
+			continue
+		}
+		segments = append(segments, entry.Name())
+	}
+	sort.Strings(segments)
+	return segments, nil
+}
+
+func segmentName(firstID uint64) string {
+	return fmt.Sprintf("%020d%s", firstID, segmentExt)
+}
+
+func (j *FileJournal) Append(emsg EventMessage) (EventMessage, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	emsg.ID = j.lastID + 1
+	if emsg.Timestamp.IsZero() {
+		emsg.Timestamp = time.Now()
+	}
+
+	line, err := MarshalEvent(emsg)
+	if err != nil {
+		return emsg, err
+	}
+	line = append(line, '\n')
+
+	if j.file == nil || (j.fileSize > 0 && j.fileSize+int64(len(line)) > j.segmentSize) {
+		if err := j.rotate(emsg.ID); err != nil {
+			return emsg, err
+		}
+	}
+
+	n, err := j.file.Write(line)
+	j.fileSize += int64(n)
+	if err != nil {
+		return emsg, err
+	}
+	j.lastID = emsg.ID
+	j.unsynced = true
+
+	if j.syncInterval == 0 {
+		if err := j.flush(); err != nil {
+			return emsg, err
+		}
+	}
+	return emsg, nil
+}
+
+func (j *FileJournal) rotate(firstID uint64) error {
+	if j.file != nil {
+		if err := j.flush(); err != nil {
+			return err
+		}
+		if err := j.file.Close(); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(filepath.Join(j.dir, segmentName(firstID)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	j.file = file
+	j.fileSize = 0
+	return j.prune()
+}
+
+func (j *FileJournal) Replay(afterID uint64, fn func(EventMessage) error) error {
+	j.mu.Lock()
+	segments, err := j.segments()
+	lastID := j.lastID
+	j.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for i, segment := range segments {
+		// skip segments that only contain already processed events
+		if i+1 < len(segments) {
+			next, err := strconv.ParseUint(strings.TrimSuffix(segments[i+1], segmentExt), 10, 64)
+			if err == nil && next <= afterID+1 {
+				continue
+			}
+		}
+
+		done, err := j.replaySegment(filepath.Join(j.dir, segment), afterID, lastID, fn)
+		if err != nil || done {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaySegment hands the events of the segment to fn. A segment pruned since
+// the segments were listed is skipped, its events are past the retention.
+func (j *FileJournal) replaySegment(path string, afterID, lastID uint64, fn func(EventMessage) error) (bool, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	var previousID uint64
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), int(defaultSegmentSize))
+	for scanner.Scan() {
+		emsg, err := UnmarshalEvent(scanner.Bytes())
+		if err != nil {
+			// an event appended while replaying may be written partially
+			if previousID >= lastID {
+				return true, nil
+			}
+			return false, fmt.Errorf("corrupt journal segment %s: %w", filepath.Base(path), err)
+		}
+		previousID = emsg.ID
+		if emsg.ID > lastID {
+			return true, nil
+		}
+		if emsg.ID <= afterID {
+			continue
+		}
+		if err := fn(emsg); err != nil {
+			return true, err
+		}
+	}
+	return false, scanner.Err()
+}
+
+func (j *FileJournal) LastID() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.lastID
+}
+
+func (j *FileJournal) Ack(name string, id uint64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if id <= j.cursors[name] {
+		return nil
+	}
+	j.cursors[name] = id
+	j.cursorsChanged = true
+
+	if j.syncInterval == 0 {
+		return j.flush()
+	}
+	return nil
+}
+
+func (j *FileJournal) Acked(name string) (uint64, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	id, ok := j.cursors[name]
+	return id, ok
+}
+
+// Close writes everything that wasn't synced yet and closes the journal.
+func (j *FileJournal) Close() error {
+	j.mu.Lock()
+	select {
+	case <-j.stop:
+		j.mu.Unlock()
+		return nil
+	default:
+		close(j.stop)
+	}
+	j.mu.Unlock()
+	j.wg.Wait()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	err := j.flush()
+	if j.file != nil {
+		err = errors.Join(err, j.file.Close())
+		j.file = nil
+	}
+	return err
+}
+
+// Recent returns up to limit of the latest events in the journal, newest first.
+func Recent(j Journal, limit int) ([]EventMessage, error) {
+	if limit <= 0 {
+		return nil, errors.New("limit has to be positive")
+	}
+	var afterID uint64
+	if last := j.LastID(); last > uint64(limit) {
+		afterID = last - uint64(limit)
+	}
+
+	recent := make([]EventMessage, 0, limit)
+	err := j.Replay(afterID, func(emsg EventMessage) error {
+		recent = append(recent, emsg)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, k := 0, len(recent)-1; i < k; i, k = i+1, k-1 {
+		recent[i], recent[k] = recent[k], recent[i]
+	}
+	return recent, nil
+}
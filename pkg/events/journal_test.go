@@ -0,0 +1,357 @@
+package events
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestJournal(t *testing.T) (*FileJournal, string) {
+	dir := t.TempDir()
+	j, err := NewFileJournal(dir)
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = j.Close() })
+	return j, dir
+}
+
+func replayAll(t *testing.T, j Journal, afterID uint64) []EventMessage {
+	var replayed []EventMessage
+	err := j.Replay(afterID, func(emsg EventMessage) error {
+		replayed = append(replayed, emsg)
+		return nil
+	})
+	assert.NoError(t, err)
+	return replayed
+}
+
+func TestJournalAppendAndReplay(t *testing.T) {
+	j, _ := newTestJournal(t)
+
+	joined := PlayerEvent{Player: "Raider", ServerID: uuid.New(), ServerName: "island", Duration: time.Minute}
+	emsg, err := j.Append(EventMessage{Type: TypePlayerJoined, Payload: joined})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), emsg.ID)
+	assert.False(t, emsg.Timestamp.IsZero())
+
+	_, err = j.Append(EventMessage{Type: TypeServerOffline, Payload: ServerEvent{ServerName: "island"}})
+	assert.NoError(t, err)
+	_, err = j.Append(EventMessage{Type: TypeConfigChanged, Payload: map[interface{}]interface{}{"token": "secret"}})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), j.LastID())
+
+	replayed := replayAll(t, j, 0)
+	assert.Len(t, replayed, 3)
+	player, ok := replayed[0].PlayerEvent()
+	assert.True(t, ok)
+	assert.Equal(t, joined, player)
+	_, ok = replayed[1].ServerEvent()
+	assert.True(t, ok)
+	assert.Nil(t, replayed[2].Payload, "configuration payloads are left out")
+
+	replayed = replayAll(t, j, 2)
+	assert.Len(t, replayed, 1)
+	assert.Equal(t, uint64(3), replayed[0].ID)
+}
+
+func TestJournalRecovery(t *testing.T) {
+	j, dir := newTestJournal(t)
+	for i := 0; i < 3; i++ {
+		_, err := j.Append(EventMessage{Type: TypeInit})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, j.Close())
+
+	// simulate a crash while writing the fourth event
+	segment := filepath.Join(dir, segmentName(1))
+	file, err := os.OpenFile(segment, os.O_WRONLY|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+	_, err = file.WriteString(`{"id":4,"type":"in`)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	reopened, err := NewFileJournal(dir)
+	assert.NoError(t, err)
+	defer reopened.Close()
+	assert.Equal(t, uint64(3), reopened.LastID())
+
+	emsg, err := reopened.Append(EventMessage{Type: TypeInit})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), emsg.ID)
+	assert.Len(t, replayAll(t, reopened, 0), 4)
+}
+
+func TestJournalSegments(t *testing.T) {
+	j, dir := newTestJournal(t)
+	j.segmentSize = 128
+
+	for i := 0; i < 10; i++ {
+		_, err := j.Append(EventMessage{Type: TypeServerDeleted, Payload: uuid.New()})
+		assert.NoError(t, err)
+	}
+
+	segments, err := j.segments()
+	assert.NoError(t, err)
+	assert.Greater(t, len(segments), 1, "journal should have been rotated")
+
+	replayed := replayAll(t, j, 7)
+	assert.Len(t, replayed, 3)
+	assert.Equal(t, uint64(8), replayed[0].ID)
+	_, ok := replayed[0].ServerID()
+	assert.True(t, ok)
+
+	assert.NoError(t, j.Close())
+	reopened, err := NewFileJournal(dir)
+	assert.NoError(t, err)
+	defer reopened.Close()
+	assert.Equal(t, uint64(10), reopened.LastID())
+}
+
+func TestJournalReplaySkipsPrunedSegments(t *testing.T) {
+	j, dir := newTestJournal(t)
+	j.segmentSize = 128
+
+	for i := 0; i < 10; i++ {
+		_, err := j.Append(EventMessage{Type: TypeServerDeleted, Payload: uuid.New()})
+		assert.NoError(t, err)
+	}
+	segments, err := j.segments()
+	assert.NoError(t, err)
+	assert.Greater(t, len(segments), 2)
+
+	// the second segment is pruned while the first one is replayed
+	var replayed []uint64
+	err = j.Replay(0, func(emsg EventMessage) error {
+		if len(replayed) == 0 {
+			assert.NoError(t, os.Remove(filepath.Join(dir, segments[1])))
+		}
+		replayed = append(replayed, emsg.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), replayed[0])
+	assert.Equal(t, uint64(10), replayed[len(replayed)-1])
+	assert.Less(t, len(replayed), 10)
+}
+
+func TestJournalRetention(t *testing.T) {
+	dir := t.TempDir()
+	j, err := NewFileJournal(dir, RetainSegments(2))
+	assert.NoError(t, err)
+	defer j.Close()
+	j.segmentSize = 128
+
+	for i := 0; i < 10; i++ {
+		_, err := j.Append(EventMessage{Type: TypeServerDeleted, Payload: uuid.New()})
+		assert.NoError(t, err)
+	}
+
+	segments, err := j.segments()
+	assert.NoError(t, err)
+	assert.Len(t, segments, 2)
+	replayed := replayAll(t, j, 0)
+	assert.NotEmpty(t, replayed)
+	assert.Equal(t, uint64(10), replayed[len(replayed)-1].ID)
+
+	// segments outside of the age limit are removed on start, except the
+	// current one
+	assert.NoError(t, j.Close())
+	old := time.Now().Add(-48 * time.Hour)
+	for _, segment := range segments {
+		assert.NoError(t, os.Chtimes(filepath.Join(dir, segment), old, old))
+	}
+	reopened, err := NewFileJournal(dir, RetainFor(24*time.Hour))
+	assert.NoError(t, err)
+	defer reopened.Close()
+	segments, err = reopened.segments()
+	assert.NoError(t, err)
+	assert.Len(t, segments, 1)
+	assert.Equal(t, uint64(10), reopened.LastID())
+}
+
+func TestJournalAck(t *testing.T) {
+	j, dir := newTestJournal(t)
+
+	_, ok := j.Acked("discord")
+	assert.False(t, ok)
+
+	assert.NoError(t, j.Ack("discord", 5))
+	assert.NoError(t, j.Ack("discord", 3), "older acknowledgements are ignored")
+	id, ok := j.Acked("discord")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(5), id)
+	_, err := os.Stat(filepath.Join(dir, cursorFile))
+	assert.True(t, os.IsNotExist(err), "cursors are written in batches")
+
+	assert.NoError(t, j.Close())
+	reopened, err := NewFileJournal(dir)
+	assert.NoError(t, err)
+	defer reopened.Close()
+	id, ok = reopened.Acked("discord")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(5), id)
+}
+
+func TestRecent(t *testing.T) {
+	j, _ := newTestJournal(t)
+	for i := 0; i < 5; i++ {
+		_, err := j.Append(EventMessage{Type: TypeInit})
+		assert.NoError(t, err)
+	}
+
+	recent, err := Recent(j, 3)
+	assert.NoError(t, err)
+	assert.Len(t, recent, 3)
+	assert.Equal(t, uint64(5), recent[0].ID)
+	assert.Equal(t, uint64(3), recent[2].ID)
+
+	recent, err = Recent(j, 10)
+	assert.NoError(t, err)
+	assert.Len(t, recent, 5)
+}
+
+func TestDurableStartListening(t *testing.T) {
+	j, _ := newTestJournal(t)
+	em := NewEventManager(WithJournal(j))
+
+	listen := func(handler *MockHandler) (context.CancelFunc, <-chan struct{}) {
+		ctx, cancel := context.WithCancel(context.Background())
+		subscribed := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			em.StartListening(ctx, handler, "durable-service", func() { close(subscribed) }, Durable())
+		}()
+		<-subscribed
+		return cancel, done
+	}
+	handled := func(handler *MockHandler) []string {
+		handler.mu.Lock()
+		defer handler.mu.Unlock()
+		var payloads []string
+		for _, event := range handler.handledEvents {
+			payloads = append(payloads, event.Payload.(string))
+		}
+		return payloads
+	}
+
+	em.Publish(context.Background(), EventMessage{Type: "test-event", Payload: "before first start"})
+
+	first := &MockHandler{}
+	cancel, done := listen(first)
+	em.Publish(context.Background(), EventMessage{Type: "test-event", Payload: "first"})
+	assert.Eventually(t, func() bool { return len(handled(first)) == 1 }, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	em.Publish(context.Background(), EventMessage{Type: "test-event", Payload: "missed"})
+
+	second := &MockHandler{}
+	cancel, done = listen(second)
+	defer func() {
+		cancel()
+		<-done
+	}()
+	em.Publish(context.Background(), EventMessage{Type: "test-event", Payload: "second"})
+
+	assert.Eventually(t, func() bool { return len(handled(second)) == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"first"}, handled(first))
+	assert.Equal(t, []string{"missed", "second"}, handled(second))
+}
+
+func TestDurableReplayFiltersTopics(t *testing.T) {
+	j, _ := newTestJournal(t)
+	em := NewEventManager(WithJournal(j))
+	// the subscriber has a cursor, everything after it is replayed
+	_, err := j.Append(EventMessage{Type: TypeInit})
+	assert.NoError(t, err)
+	assert.NoError(t, j.Ack("players", 1))
+
+	em.Publish(context.Background(), EventMessage{Type: TypePlayerJoined, Payload: PlayerEvent{Player: "Raider"}})
+	em.Publish(context.Background(), EventMessage{Type: TypeServerAdded})
+	em.Publish(context.Background(), EventMessage{Type: TypePlayerLeft, Payload: PlayerEvent{Player: "Raider"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := &MockHandler{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		em.StartListening(ctx, handler, "players", func() {}, Durable(), Topics("player.*"))
+	}()
+
+	assert.Eventually(t, func() bool {
+		handler.mu.Lock()
+		defer handler.mu.Unlock()
+		return len(handler.handledEvents) == 2
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Equal(t, TypePlayerJoined, handler.handledEvents[0].Type)
+	assert.Equal(t, TypePlayerLeft, handler.handledEvents[1].Type)
+	acked, _ := j.Acked("players")
+	assert.Equal(t, uint64(4), acked)
+}
+
+func TestDurableReplaySkipsOldEvents(t *testing.T) {
+	j, _ := newTestJournal(t)
+	em := NewEventManager(WithJournal(j))
+	_, err := j.Append(EventMessage{Type: TypeInit})
+	assert.NoError(t, err)
+	assert.NoError(t, j.Ack("players", 1))
+
+	// published during a downtime of hours
+	_, err = j.Append(EventMessage{Type: TypePlayerJoined, Timestamp: time.Now().Add(-3 * time.Hour), Payload: PlayerEvent{Player: "Raider"}})
+	assert.NoError(t, err)
+	_, err = j.Append(EventMessage{Type: TypePlayerLeft, Timestamp: time.Now().Add(-time.Minute), Payload: PlayerEvent{Player: "Raider"}})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := &MockHandler{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		em.StartListening(ctx, handler, "players", func() {}, Durable(), MaxReplayAge(5*time.Minute))
+	}()
+
+	assert.Eventually(t, func() bool {
+		acked, _ := j.Acked("players")
+		return acked == 3
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	assert.Len(t, handler.handledEvents, 1)
+	assert.Equal(t, TypePlayerLeft, handler.handledEvents[0].Type)
+}
+
+func TestConcurrentDurableAck(t *testing.T) {
+	j, _ := newTestJournal(t)
+	l := &listener{
+		em:      NewEventManager(WithJournal(j)),
+		sub:     newSubscription("concurrent-service"),
+		pending: make(map[uint64]struct{}),
+	}
+
+	for id := uint64(1); id <= 3; id++ {
+		l.start(id)
+	}
+
+	l.done(2)
+	_, ok := j.Acked("concurrent-service")
+	assert.False(t, ok, "event 1 is still pending")
+
+	l.done(1)
+	acked, _ := j.Acked("concurrent-service")
+	assert.Equal(t, uint64(2), acked)
+
+	l.done(3)
+	acked, _ = j.Acked("concurrent-service")
+	assert.Equal(t, uint64(3), acked)
+}
@@ -0,0 +1,166 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// listener hands the events of a subscription to its handler, see
+// StartListening.
+type listener struct {
+	em      *EventManager
+	handler EventHandler
+	sub     *subscription
+	latency metric.Float64Histogram
+	panics  metric.Int64Counter
+	sem     chan struct{}
+	wg      sync.WaitGroup
+
+	mu sync.Mutex
+	// pending holds the IDs of durable events that are handled right now,
+	// dispatched the highest ID handed out so far.
+	pending    map[uint64]struct{}
+	dispatched uint64
+}
+
+func (e *EventManager) newListener(handler EventHandler, sub *subscription) (*listener, error) {
+	latency, err := meter.Float64Histogram(
+		"eventHandlerLatency",
+		metric.WithDescription("time it takes to handle an event, per topic and subscriber"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	panics, err := meter.Int64Counter(
+		"eventHandlerPanicCtr",
+		metric.WithDescription("number of recovered panics in event handlers"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &listener{
+		em:      e,
+		handler: handler,
+		sub:     sub,
+		latency: latency,
+		panics:  panics,
+		sem:     make(chan struct{}, sub.concurrency),
+		pending: make(map[uint64]struct{}),
+	}, nil
+}
+
+// dispatch handles the event, concurrently if the subscription allows it.
+// It returns false if ctx is done before a slot became free.
+func (l *listener) dispatch(ctx context.Context, event EventMessage, durable bool) bool {
+	if l.sub.concurrency == 1 {
+		l.handle(ctx, event, false)
+		if durable && event.ID != 0 {
+			l.em.ack(l.sub.name, event.ID)
+		}
+		return true
+	}
+
+	select {
+	case l.sem <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	}
+
+	track := durable && event.ID != 0
+	if track {
+		l.start(event.ID)
+	}
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		defer func() { <-l.sem }()
+		l.handle(ctx, event, false)
+		if track {
+			l.done(event.ID)
+		}
+	}()
+	return true
+}
+
+// handle calls the handler, recovers a panic and records the latency.
+// Live events continue the trace of their publisher, replayed events start
+// a new trace linked to it.
+func (l *listener) handle(ctx context.Context, event EventMessage, replayed bool) {
+	attrs := []attribute.KeyValue{
+		attribute.String("topic", event.Type),
+		attribute.String("subscriber", l.sub.name),
+	}
+
+	spanOpts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(attribute.Int64("event.id", int64(event.ID)), attribute.Bool("event.replayed", replayed)),
+	}
+	if event.SpanContext.IsValid() {
+		spanOpts = append(spanOpts, trace.WithLinks(trace.Link{SpanContext: event.SpanContext}))
+		if replayed {
+			spanOpts = append(spanOpts, trace.WithNewRoot())
+		} else {
+			ctx = trace.ContextWithRemoteSpanContext(ctx, event.SpanContext)
+		}
+	}
+	ctx, span := tracer.Start(ctx, "HandleEvent "+event.Type, spanOpts...)
+
+	start := time.Now()
+	defer func() {
+		defer span.End()
+		l.latency.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+		if r := recover(); r != nil {
+			err := fmt.Errorf("panic: %v", r)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			l.panics.Add(ctx, 1, metric.WithAttributes(attrs...))
+			l.em.logger.ErrorContext(
+				ctx,
+				"recovered panic in event handler",
+				"error", fmt.Sprint(r),
+				"service name", l.sub.name,
+				"type", event.Type,
+				"id", event.ID,
+				"stack", string(debug.Stack()),
+			)
+		}
+	}()
+
+	l.handler.HandleEvent(ctx, event)
+}
+
+func (l *listener) start(id uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending[id] = struct{}{}
+	if id > l.dispatched {
+		l.dispatched = id
+	}
+}
+
+// done acknowledges the highest ID all events up to which have been handled.
+// Events finishing out of order don't move the cursor past a pending one.
+func (l *listener) done(id uint64) {
+	l.mu.Lock()
+	delete(l.pending, id)
+	ack := l.dispatched
+	for pending := range l.pending {
+		if pending <= ack {
+			ack = pending - 1
+		}
+	}
+	l.mu.Unlock()
+
+	l.em.ack(l.sub.name, ack)
+}
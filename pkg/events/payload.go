@@ -0,0 +1,120 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/led0nk/ark-overseer/internal/model"
+)
+
+const (
+	TypeInit          = "init"
+	TypeInitServices  = "init.services"
+	TypeConfigChanged = "config.changed"
+	TypeServerAdded   = "server.added"
+	TypeServerDeleted = "server.deleted"
+	TypeServerOnline  = "server.online"
+	TypeServerOffline = "server.offline"
+	TypePlayerJoined  = "player.joined"
+	TypePlayerLeft    = "player.left"
+)
+
+// AlertTopics are the event types notifiers alert on: players joining or
+// leaving and servers going on- or offline.
+var AlertTopics = []string{"player.*", TypeServerOnline, TypeServerOffline}
+
+// PlayerEvent is the payload of player.joined and player.left events.
+type PlayerEvent struct {
+	Player     string    `json:"player"`
+	ServerID   uuid.UUID `json:"serverId"`
+	ServerName string    `json:"serverName"`
+	ServerAddr string    `json:"serverAddr"`
+	Cluster    string    `json:"cluster,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	// Duration the player has been on the server, as last seen.
+	Duration time.Duration `json:"duration"`
+	// Entry is the blacklist entry the player matched.
+	Entry *model.BlacklistPlayers `json:"entry,omitempty"`
+}
+
+// ServerEvent is the payload of server.online and server.offline events.
+type ServerEvent struct {
+	ServerID   uuid.UUID `json:"serverId"`
+	ServerName string    `json:"serverName"`
+	ServerAddr string    `json:"serverAddr"`
+	Cluster    string    `json:"cluster,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	Online     bool      `json:"online"`
+	Players    int       `json:"players"`
+	MaxPlayers int       `json:"maxPlayers"`
+}
+
+// PlayerEvent returns the payload of player events.
+func (e EventMessage) PlayerEvent() (PlayerEvent, bool) {
+	switch payload := e.Payload.(type) {
+	case PlayerEvent:
+		return payload, true
+	case *PlayerEvent:
+		if payload != nil {
+			return *payload, true
+		}
+	}
+	return PlayerEvent{}, false
+}
+
+// ServerEvent returns the payload of server.online and server.offline events.
+func (e EventMessage) ServerEvent() (ServerEvent, bool) {
+	switch payload := e.Payload.(type) {
+	case ServerEvent:
+		return payload, true
+	case *ServerEvent:
+		if payload != nil {
+			return *payload, true
+		}
+	}
+	return ServerEvent{}, false
+}
+
+// Server returns the payload of server.added events.
+func (e EventMessage) Server() (*model.Server, bool) {
+	server, ok := e.Payload.(*model.Server)
+	return server, ok && server != nil
+}
+
+// ServerID returns the payload of server.deleted events.
+func (e EventMessage) ServerID() (uuid.UUID, bool) {
+	id, ok := e.Payload.(uuid.UUID)
+	return id, ok
+}
+
+// Section returns the payload of config.changed events.
+func (e EventMessage) Section() (map[interface{}]interface{}, bool) {
+	section, ok := e.Payload.(map[interface{}]interface{})
+	return section, ok
+}
+
+// Summary describes the event in a short sentence for history views.
+func (e EventMessage) Summary() string {
+	if player, ok := e.PlayerEvent(); ok {
+		switch e.Type {
+		case TypePlayerJoined:
+			return fmt.Sprintf("%s joined %s", player.Player, player.ServerName)
+		case TypePlayerLeft:
+			return fmt.Sprintf("%s left %s", player.Player, player.ServerName)
+		}
+	}
+	if server, ok := e.ServerEvent(); ok {
+		if server.Online {
+			return fmt.Sprintf("%s is online (%d/%d)", server.ServerName, server.Players, server.MaxPlayers)
+		}
+		return fmt.Sprintf("%s is offline", server.ServerName)
+	}
+	if server, ok := e.Server(); ok {
+		return fmt.Sprintf("%s (%s) added", server.Name, server.Addr)
+	}
+	if id, ok := e.ServerID(); ok {
+		return fmt.Sprintf("server %s deleted", id)
+	}
+	return ""
+}
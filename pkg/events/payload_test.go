@@ -0,0 +1,70 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/led0nk/ark-overseer/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayloadAccessors(t *testing.T) {
+	serverID := uuid.MustParse("64dfb157-37b8-41de-b24d-14f304e15402")
+	playerEvent := PlayerEvent{
+		Player:     "Raider",
+		ServerID:   serverID,
+		ServerName: "Island",
+		Timestamp:  time.Unix(1700000000, 0),
+		Duration:   5 * time.Minute,
+		Entry:      &model.BlacklistPlayers{Name: "Raider", ThreatLevel: "high"},
+	}
+	serverEvent := ServerEvent{ServerID: serverID, ServerName: "Island", Online: false}
+
+	tests := []struct {
+		name         string
+		event        EventMessage
+		expectPlayer bool
+		expectServer bool
+	}{
+		{
+			name:         "player event by value",
+			event:        EventMessage{Type: TypePlayerJoined, Payload: playerEvent},
+			expectPlayer: true,
+		},
+		{
+			name:         "player event by pointer",
+			event:        EventMessage{Type: TypePlayerLeft, Payload: &playerEvent},
+			expectPlayer: true,
+		},
+		{
+			name:         "server event",
+			event:        EventMessage{Type: TypeServerOffline, Payload: serverEvent},
+			expectServer: true,
+		},
+		{
+			name:  "preformatted string",
+			event: EventMessage{Type: TypePlayerJoined, Payload: "Raider joined the server Island"},
+		},
+		{
+			name:  "nil pointer",
+			event: EventMessage{Type: TypePlayerJoined, Payload: (*PlayerEvent)(nil)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPlayer, ok := tt.event.PlayerEvent()
+			assert.Equal(t, tt.expectPlayer, ok)
+			if tt.expectPlayer {
+				assert.Equal(t, playerEvent, gotPlayer)
+			}
+
+			gotServer, ok := tt.event.ServerEvent()
+			assert.Equal(t, tt.expectServer, ok)
+			if tt.expectServer {
+				assert.Equal(t, serverEvent, gotServer)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package events
+
+import "strings"
+
+// MatchTopic reports whether topic matches pattern. Topics are event types,
+// their segments are separated by dots. In patterns "*" matches exactly one
+// segment and a trailing "**" matches any number of remaining segments,
+// e.g. "player.*" matches "player.joined" and "server.**" matches
+// "server.offline".
+func MatchTopic(pattern, topic string) bool {
+	patternSegments := strings.Split(pattern, ".")
+	topicSegments := strings.Split(topic, ".")
+
+	for i, segment := range patternSegments {
+		if segment == "**" && i == len(patternSegments)-1 {
+			return true
+		}
+		if i >= len(topicSegments) {
+			return false
+		}
+		if segment != "*" && segment != topicSegments[i] {
+			return false
+		}
+	}
+	return len(patternSegments) == len(topicSegments)
+}
+
+// matches reports whether the subscription wants events of the given type.
+// Subscriptions without topics receive every event.
+func (s *subscription) matches(eventType string) bool {
+	if len(s.topics) == 0 {
+		return true
+	}
+	for _, pattern := range s.topics {
+		if MatchTopic(pattern, eventType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracePropagation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+
+	j, err := NewFileJournal(t.TempDir())
+	assert.NoError(t, err)
+	defer j.Close()
+	em := NewEventManager(WithJournal(j))
+
+	handled := make(chan trace.SpanContext, 2)
+	handler := handlerFunc(func(ctx context.Context, event EventMessage) {
+		handled <- trace.SpanContextFromContext(ctx)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	subscribed := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		em.StartListening(ctx, handler, "traced-service", func() { close(subscribed) }, Durable())
+	}()
+	<-subscribed
+
+	parentCtx, parent := otel.Tracer("test").Start(context.Background(), "addServer")
+	em.Publish(parentCtx, EventMessage{Type: TypeServerAdded})
+	parent.End()
+
+	var live trace.SpanContext
+	select {
+	case live = <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	assert.Equal(t, parent.SpanContext().TraceID(), live.TraceID(), "live events continue the publisher's trace")
+
+	cancel()
+	<-done
+
+	// replayed events start a new trace that links the publisher
+	em.Publish(parentCtx, EventMessage{Type: TypeServerDeleted})
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go em.StartListening(ctx, handler, "traced-service", func() {}, Durable())
+
+	var replayed trace.SpanContext
+	select {
+	case replayed = <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for replayed event")
+	}
+	assert.NotEqual(t, parent.SpanContext().TraceID(), replayed.TraceID())
+
+	var links []sdktrace.Link
+	assert.Eventually(t, func() bool {
+		for _, span := range recorder.Ended() {
+			if span.SpanContext().SpanID() == replayed.SpanID() {
+				links = span.Links()
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+	assert.Len(t, links, 1)
+	assert.Equal(t, parent.SpanContext().TraceID(), links[0].SpanContext.TraceID())
+}
+
+type handlerFunc func(context.Context, EventMessage)
+
+func (f handlerFunc) HandleEvent(ctx context.Context, event EventMessage) {
+	f(ctx, event)
+}
+
+func TestJournalKeepsSpanContext(t *testing.T) {
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02},
+		SpanID:     trace.SpanID{0x03},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	data, err := MarshalEvent(EventMessage{ID: 1, Type: TypeInit, SpanContext: spanContext})
+	assert.NoError(t, err)
+	emsg, err := UnmarshalEvent(data)
+	assert.NoError(t, err)
+
+	assert.Equal(t, spanContext.TraceID(), emsg.SpanContext.TraceID())
+	assert.Equal(t, spanContext.SpanID(), emsg.SpanContext.SpanID())
+	assert.True(t, emsg.SpanContext.IsSampled())
+}